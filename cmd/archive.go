@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xCatch/xcatch/pkg/utools"
+)
+
+// archiveManifestFile is the manifest written at the root of an archive.
+const archiveManifestFile = "manifest.json"
+
+// archiveManifest records what an archive holds. It is rewritten after every
+// section, so an interrupted run can be resumed: sections already marked
+// complete for the same user are skipped.
+type archiveManifest struct {
+	ScreenName string                           `json:"screen_name"`
+	UserID     string                           `json:"user_id"`
+	MaxPages   int                              `json:"max_pages"` // 0 = unlimited
+	StartedAt  time.Time                        `json:"started_at"`
+	UpdatedAt  time.Time                        `json:"updated_at"`
+	Sections   map[string]*archiveSectionStatus `json:"sections"`
+}
+
+// archiveSectionStatus is the manifest entry of one section.
+type archiveSectionStatus struct {
+	File        string     `json:"file"`
+	Count       int        `json:"count"`
+	Complete    bool       `json:"complete"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// archiveSection is one NDJSON file of an archive. fetch pages through the
+// section's data for user, up to maxPages pages (0 = unlimited), passing
+// every record to emit.
+type archiveSection struct {
+	Name  string
+	fetch func(ctx context.Context, user *utools.UserResult, maxPages int, emit func(any) error) error
+}
+
+// clientArchiveSections returns the sections archived by the archive
+// command: tweets, replies, likes, followers and media.
+func clientArchiveSections(client *utools.Client) []archiveSection {
+	return []archiveSection{
+		{Name: "tweets", fetch: func(ctx context.Context, user *utools.UserResult, maxPages int, emit func(any) error) error {
+			// Cancelling on return releases the crawler if we stop reading early.
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			events, err := utools.NewCrawler(client, 1).CrawlUserTweets(ctx, []string{user.RestID}, maxPages)
+			if err != nil {
+				return err
+			}
+			for ev := range events {
+				switch ev.Type {
+				case utools.CrawlPageFetched:
+					tweets, err := utools.ParseTweetTimeline(ev.Data)
+					if err != nil {
+						return fmt.Errorf("page %d: %w", ev.Page, err)
+					}
+					for _, t := range tweets {
+						if err := emit(t); err != nil {
+							return err
+						}
+					}
+				case utools.CrawlError:
+					return ev.Err
+				}
+			}
+			return ctx.Err()
+		}},
+		{Name: "replies", fetch: pagedArchiveSection(client, "/userTweetReply", utools.ParseTweetTimeline)},
+		{Name: "likes", fetch: pagedArchiveSection(client, "/favoritesList", utools.ParseTweetTimeline)},
+		{Name: "followers", fetch: pagedArchiveSection(client, "/followersListV2", utools.ParseUserList)},
+		{Name: "media", fetch: func(ctx context.Context, user *utools.UserResult, maxPages int, emit func(any) error) error {
+			tweets, err := client.GetUserMediaTweets(ctx, user.RestID, maxPages, user.MediaCount)
+			for _, t := range tweets {
+				for _, m := range t.MediaURLs() {
+					if err := emit(m); err != nil {
+						return err
+					}
+				}
+			}
+			return err
+		}},
+	}
+}
+
+// pagedArchiveSection fetches a section by paging path with the user's ID
+// and parsing every page with parse.
+func pagedArchiveSection[T any](client *utools.Client, path string, parse func(json.RawMessage) ([]T, error)) func(context.Context, *utools.UserResult, int, func(any) error) error {
+	return func(ctx context.Context, user *utools.UserResult, maxPages int, emit func(any) error) error {
+		it := client.NewPageIterator(path, map[string]string{
+			"userId": user.RestID,
+		}, maxPages)
+		for it.HasMore() {
+			page, err := it.Next(ctx)
+			if err != nil {
+				return err
+			}
+			if page == nil {
+				break
+			}
+			items, err := parse(page.RawData)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", it.PageCount(), err)
+			}
+			for _, item := range items {
+				if err := emit(item); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// runArchive exports screenName's data into dir: the profile resolved by
+// resolve as user.json, then one <section>.ndjson file per section, logging
+// progress with logf and keeping manifest.json up to date. A section that
+// fails is recorded in the manifest and the next one still runs; the
+// failures are returned together. Re-running into the same dir resumes:
+// complete sections of the same user are kept as they are, unless maxPages
+// differs from the cap they were archived with, in which case every section
+// is archived again.
+func runArchive(ctx context.Context, dir, screenName string, maxPages int,
+	resolve func(context.Context, string) (*utools.UserResult, error),
+	sections []archiveSection, logf func(string, ...any)) (*archiveManifest, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create archive dir: %w", err)
+	}
+
+	user, err := resolve(ctx, screenName)
+	if err != nil {
+		return nil, fmt.Errorf("resolve @%s: %w", screenName, err)
+	}
+	if err := writeArchiveJSON(filepath.Join(dir, "user.json"), user); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	manifest := loadArchiveManifest(filepath.Join(dir, archiveManifestFile))
+	if manifest != nil && manifest.UserID == user.RestID && manifest.MaxPages != maxPages {
+		logf("page cap changed from %d to %d, archiving all sections again", manifest.MaxPages, maxPages)
+		manifest = nil
+	}
+	if manifest == nil || manifest.UserID != user.RestID {
+		manifest = &archiveManifest{StartedAt: now, Sections: map[string]*archiveSectionStatus{}}
+	}
+	manifest.ScreenName, manifest.UserID, manifest.MaxPages = user.ScreenName, user.RestID, maxPages
+
+	var errs []error
+	for _, s := range sections {
+		if st := manifest.Sections[s.Name]; st != nil && st.Complete {
+			logf("[%s] already archived (%d records), skipping", s.Name, st.Count)
+			continue
+		}
+		st := &archiveSectionStatus{File: s.Name + ".ndjson", StartedAt: time.Now().UTC()}
+		manifest.Sections[s.Name] = st
+		logf("[%s] archiving ...", s.Name)
+		err := archiveSectionFile(ctx, filepath.Join(dir, st.File), s, user, maxPages, func(n int) {
+			st.Count = n
+			if n%100 == 0 {
+				logf("[%s] %d records", s.Name, n)
+			}
+		})
+		if err != nil {
+			st.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+			logf("[%s] failed after %d records: %v", s.Name, st.Count, err)
+		} else {
+			done := time.Now().UTC()
+			st.Complete, st.CompletedAt = true, &done
+			logf("[%s] done, %d records", s.Name, st.Count)
+		}
+		manifest.UpdatedAt = time.Now().UTC()
+		if err := writeArchiveJSON(filepath.Join(dir, archiveManifestFile), manifest); err != nil {
+			return manifest, err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return manifest, errors.Join(errs...)
+}
+
+// archiveSectionFile writes section s to path as NDJSON, through a ".part"
+// file renamed into place only once the section is complete. progress is
+// called with the running record count.
+func archiveSectionFile(ctx context.Context, path string, s archiveSection, user *utools.UserResult, maxPages int, progress func(int)) error {
+	part := path + ".part"
+	f, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	n := 0
+	err = s.fetch(ctx, user, maxPages, func(record any) error {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		n++
+		progress(n)
+		return nil
+	})
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(part, path)
+}
+
+// loadArchiveManifest reads the manifest at path, or returns nil when there
+// is none to resume from.
+func loadArchiveManifest(path string) *archiveManifest {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var m archiveManifest
+	if err := json.Unmarshal(data, &m); err != nil || m.Sections == nil {
+		return nil
+	}
+	return &m
+}
+
+func writeArchiveJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// popFlagValue removes flag and its value ("--flag value" or "--flag=value")
+// from args and returns the value, the last one winning.
+func popFlagValue(args []string, flag string) ([]string, string, bool) {
+	rest := make([]string, 0, len(args))
+	value, found := "", false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == flag && i+1 < len(args):
+			value, found = args[i+1], true
+			i++
+		case strings.HasPrefix(a, flag+"="):
+			value, found = strings.TrimPrefix(a, flag+"="), true
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return rest, value, found
+}
+
+func cmdArchive(ctx context.Context, client *utools.Client, args []string) {
+	const usage = "usage: xcatch archive <screen_name> [--out dir] [--pages n]"
+	args, out, _ := popFlagValue(args, "--out")
+	args, pages, hasPages := popFlagValue(args, "--pages")
+	if len(args) < 1 {
+		log.Fatal(usage)
+	}
+	screenName := strings.TrimPrefix(args[0], "@")
+	if out == "" {
+		out = screenName
+	}
+	maxPages := 0
+	if hasPages {
+		n, err := strconv.Atoi(pages)
+		if err != nil || n <= 0 {
+			log.Fatalf("invalid --pages: %q (must be a positive integer)", pages)
+		}
+		maxPages = n
+	}
+
+	log.Printf("Archiving @%s into %s ...", screenName, out)
+	sections := clientArchiveSections(client)
+	manifest, err := runArchive(ctx, out, screenName, maxPages, client.GetUserProfile, sections, log.Printf)
+	if manifest != nil {
+		for _, s := range sections {
+			if st := manifest.Sections[s.Name]; st != nil {
+				fmt.Printf("%-10s %6d  %s\n", s.Name, st.Count, filepath.Join(out, st.File))
+			}
+		}
+	}
+	if err != nil {
+		log.Fatalf("archive incomplete (re-run to resume): %v", err)
+	}
+}
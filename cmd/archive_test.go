@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xCatch/xcatch/pkg/utools"
+)
+
+func TestRunArchiveWritesSectionsAndResumes(t *testing.T) {
+	dir := t.TempDir()
+	resolve := func(_ context.Context, screenName string) (*utools.UserResult, error) {
+		return &utools.UserResult{RestID: "12", ScreenName: screenName}, nil
+	}
+	calls := map[string]int{}
+	followersFail := true
+	stub := func(name string, records ...any) archiveSection {
+		return archiveSection{Name: name, fetch: func(_ context.Context, user *utools.UserResult, maxPages int, emit func(any) error) error {
+			calls[name]++
+			if user.RestID != "12" || maxPages != 3 {
+				t.Errorf("%s: unexpected user %q / maxPages %d", name, user.RestID, maxPages)
+			}
+			for _, r := range records {
+				if err := emit(r); err != nil {
+					return err
+				}
+			}
+			if name == "followers" && followersFail {
+				return errors.New("upstream down")
+			}
+			return nil
+		}}
+	}
+	sections := []archiveSection{
+		stub("tweets", utools.TweetResult{ID: "1"}, utools.TweetResult{ID: "2"}),
+		stub("followers", utools.UserResult{RestID: "7"}),
+		stub("media", utools.MediaDownload{TweetID: "1", MediaID: "m1"}),
+	}
+	logf := func(string, ...any) {}
+
+	manifest, err := runArchive(context.Background(), dir, "jack", 3, resolve, sections, logf)
+	if err == nil || !strings.Contains(err.Error(), "followers: upstream down") {
+		t.Fatalf("expected the followers failure to be reported, got %v", err)
+	}
+	if st := manifest.Sections["tweets"]; st == nil || !st.Complete || st.Count != 2 {
+		t.Fatalf("unexpected tweets status: %+v", st)
+	}
+	if st := manifest.Sections["followers"]; st == nil || st.Complete || st.Error == "" {
+		t.Fatalf("unexpected followers status: %+v", st)
+	}
+	if st := manifest.Sections["media"]; st == nil || !st.Complete || st.Count != 1 {
+		t.Fatalf("expected media to run after the failed section: %+v", st)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "followers.ndjson")); !os.IsNotExist(err) {
+		t.Fatalf("expected no followers.ndjson for a failed section, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "tweets.ndjson"))
+	if err != nil {
+		t.Fatalf("read tweets: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var first utools.TweetResult
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[0]), &first) != nil || first.ID != "1" {
+		t.Fatalf("unexpected tweets.ndjson:\n%s", data)
+	}
+
+	// The re-run resumes: only the failed section is fetched again.
+	followersFail = false
+	manifest, err = runArchive(context.Background(), dir, "jack", 3, resolve, sections, logf)
+	if err != nil {
+		t.Fatalf("resumed archive error: %v", err)
+	}
+	if calls["tweets"] != 1 || calls["media"] != 1 || calls["followers"] != 2 {
+		t.Fatalf("expected only followers to be fetched again, calls: %v", calls)
+	}
+	if st := manifest.Sections["followers"]; !st.Complete || st.Count != 1 || st.Error != "" {
+		t.Fatalf("unexpected resumed followers status: %+v", st)
+	}
+
+	saved := loadArchiveManifest(filepath.Join(dir, archiveManifestFile))
+	if saved == nil || saved.UserID != "12" || saved.ScreenName != "jack" || saved.MaxPages != 3 || len(saved.Sections) != 3 {
+		t.Fatalf("unexpected manifest on disk: %+v", saved)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "user.json")); err != nil {
+		t.Fatalf("expected user.json: %v", err)
+	}
+}
+
+func TestPopFlagValue(t *testing.T) {
+	args, out, ok := popFlagValue([]string{"jack", "--out", "dir", "--pages=2"}, "--out")
+	if !ok || out != "dir" || strings.Join(args, " ") != "jack --pages=2" {
+		t.Fatalf("unexpected popFlagValue result: %v, %q, %v", args, out, ok)
+	}
+	args, pages, ok := popFlagValue(args, "--pages")
+	if !ok || pages != "2" || strings.Join(args, " ") != "jack" {
+		t.Fatalf("unexpected popFlagValue result: %v, %q, %v", args, pages, ok)
+	}
+	if _, _, ok := popFlagValue([]string{"jack"}, "--out"); ok {
+		t.Fatal("flag reported present when absent")
+	}
+}
+
+func TestRunArchivePageCapChangeRedoesSections(t *testing.T) {
+	dir := t.TempDir()
+	resolve := func(_ context.Context, screenName string) (*utools.UserResult, error) {
+		return &utools.UserResult{RestID: "12", ScreenName: screenName}, nil
+	}
+	var pages []int
+	sections := []archiveSection{{Name: "tweets", fetch: func(_ context.Context, _ *utools.UserResult, maxPages int, emit func(any) error) error {
+		pages = append(pages, maxPages)
+		return emit(utools.TweetResult{ID: "1"})
+	}}}
+	logf := func(string, ...any) {}
+
+	for _, maxPages := range []int{3, 3, 0} {
+		if _, err := runArchive(context.Background(), dir, "jack", maxPages, resolve, sections, logf); err != nil {
+			t.Fatalf("--pages %d: %v", maxPages, err)
+		}
+	}
+	// The same cap resumes; a new one archives the complete section again.
+	if len(pages) != 2 || pages[0] != 3 || pages[1] != 0 {
+		t.Fatalf("expected fetches with caps [3 0], got %v", pages)
+	}
+	saved := loadArchiveManifest(filepath.Join(dir, archiveManifestFile))
+	if saved == nil || saved.MaxPages != 0 || !saved.Sections["tweets"].Complete {
+		t.Fatalf("unexpected manifest on disk: %+v", saved)
+	}
+}
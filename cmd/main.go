@@ -4,8 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/tidwall/gjson"
 
@@ -18,6 +23,12 @@ func init() {
 }
 
 func main() {
+	// Listing endpoints needs no API key.
+	if len(os.Args) > 1 && os.Args[1] == "endpoints" {
+		writeEndpoints(os.Stdout, utools.Endpoints())
+		return
+	}
+
 	cfg := config.Load("")
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("config error: %v", err)
@@ -53,6 +64,8 @@ func main() {
 		cmdLikes(ctx, client, os.Args[2:])
 	case "trending":
 		cmdTrending(ctx, client)
+	case "archive":
+		cmdArchive(ctx, client, os.Args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n", cmd)
 		printUsage()
@@ -68,13 +81,20 @@ Usage:
 
 Commands:
   user       <screen_name>              Get user profile by screen name
-  tweets     <user_id> [max_pages]      Get user tweets (default 1 page)
+  tweets     <user_id> [max_pages] [--summary]
+                                        Get user tweets (default 1 page); --summary
+                                        prints a compact table instead of raw JSON
   tweet      <tweet_id>                 Get tweet detail with replies
   search     <query> [type]             Search tweets (type: Latest|Top|People|Photos|Videos)
   followers  <user_id>                  Get user followers (first page)
   followings <user_id>                  Get user followings (first page)
   likes      <user_id>                  Get user liked tweets (first page)
   trending                              Get current trending topics
+  archive    <screen_name> [--out dir] [--pages n]
+                                        Export profile, tweets, replies, likes,
+                                        followers and media to NDJSON files under
+                                        dir (default: screen_name); re-run to resume
+  endpoints                             List the uTools endpoints the client wraps
 
 Configuration:
   Copy config.ini.example to config.ini and fill in your API key.
@@ -128,8 +148,9 @@ func cmdUser(ctx context.Context, client *utools.Client, args []string) {
 }
 
 func cmdTweets(ctx context.Context, client *utools.Client, args []string) {
+	args, summary := popFlag(args, "--summary")
 	if len(args) < 1 {
-		log.Fatal("usage: xcatch tweets <user_id> [max_pages]")
+		log.Fatal("usage: xcatch tweets <user_id> [max_pages] [--summary]")
 	}
 	userID := args[0]
 	maxPages := 1
@@ -155,7 +176,11 @@ func cmdTweets(ctx context.Context, client *utools.Client, args []string) {
 		}
 
 		fmt.Printf("\n=== Page %d ===\n", iter.PageCount())
-		printJSON(page.RawData)
+		if summary {
+			printTweetSummary(page.RawData)
+		} else {
+			printJSON(page.RawData)
+		}
 
 		if page.NextCursor != "" {
 			fmt.Printf("\n[Next cursor: %s]\n", utools.Truncate(page.NextCursor, 50))
@@ -272,6 +297,142 @@ func printJSON(data json.RawMessage) {
 	fmt.Println(string(out))
 }
 
+// popFlag removes every occurrence of flag from args and reports whether it
+// was present, so flags may appear before or after positional arguments.
+func popFlag(args []string, flag string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, a := range args {
+		if a == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+	return rest, found
+}
+
+// printTweetSummary prints a timeline page as a compact table, pinned tweets
+// first. It falls back to raw JSON when the page cannot be parsed.
+func printTweetSummary(data json.RawMessage) {
+	tweets, err := utools.ParseTweetTimeline(data)
+	if err != nil {
+		printJSON(data)
+		return
+	}
+	writeTweetSummary(os.Stdout, tweets, pinnedTweetIDs(data))
+}
+
+// pinnedTweetIDs returns the IDs of tweets carried by TimelinePinEntry
+// instructions.
+func pinnedTweetIDs(data json.RawMessage) map[string]bool {
+	ids := map[string]bool{}
+	gjson.GetBytes(data, "..instructions").ForEach(func(_, list gjson.Result) bool {
+		list.ForEach(func(_, inst gjson.Result) bool {
+			if inst.Get("type").String() == "TimelinePinEntry" {
+				if id := inst.Get("entry.content.itemContent.tweet_results.result.rest_id").String(); id != "" {
+					ids[id] = true
+				}
+				if id := inst.Get("entry.content.itemContent.tweet_results.result.tweet.rest_id").String(); id != "" {
+					ids[id] = true
+				}
+			}
+			return true
+		})
+		return true
+	})
+	return ids
+}
+
+// writeTweetSummary writes one row per tweet (time, author, text, likes /
+// retweets / replies, media count), with pinned tweets in their own section.
+func writeTweetSummary(w io.Writer, tweets []utools.TweetResult, pinned map[string]bool) {
+	var pinnedRows, rows []utools.TweetResult
+	for _, t := range tweets {
+		if pinned[t.ID] {
+			pinnedRows = append(pinnedRows, t)
+		} else {
+			rows = append(rows, t)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writeSection := func(title string, list []utools.TweetResult) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(tw, "%s\n", title)
+		fmt.Fprintf(tw, "TIME\tAUTHOR\tTEXT\tLIKES\tRTS\tREPLIES\tMEDIA\n")
+		for _, t := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+				summaryTime(t.CreatedAt),
+				summaryAuthor(t),
+				summaryText(t.GetText(), 60),
+				t.FavoriteCount, t.RetweetCount, t.ReplyCount,
+				summaryMedia(t),
+			)
+		}
+	}
+	writeSection("Pinned:", pinnedRows)
+	writeSection("Tweets:", rows)
+	_ = tw.Flush()
+}
+
+// writeEndpoints writes one row per endpoint: Client method, HTTP method,
+// path, required params and whether auth_token is needed.
+func writeEndpoints(w io.Writer, endpoints []utools.EndpointInfo) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "NAME\tMETHOD\tPATH\tPARAMS\tAUTH\n")
+	for _, e := range endpoints {
+		params := strings.Join(e.RequiredParams, ",")
+		if params == "" {
+			params = "-"
+		}
+		auth := "-"
+		if e.RequiresAuth {
+			auth = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Name, e.Method, e.Path, params, auth)
+	}
+	_ = tw.Flush()
+}
+
+func summaryTime(createdAt string) string {
+	if ts, err := time.Parse(time.RubyDate, createdAt); err == nil {
+		return ts.UTC().Format("2006-01-02 15:04")
+	}
+	return createdAt
+}
+
+func summaryAuthor(t utools.TweetResult) string {
+	if t.User != nil && t.User.ScreenName != "" {
+		return "@" + t.User.ScreenName
+	}
+	return t.AuthorID()
+}
+
+// summaryText flattens whitespace and truncates to max runes.
+func summaryText(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max]) + "..."
+	}
+	return s
+}
+
+func summaryMedia(t utools.TweetResult) string {
+	n := 0
+	if t.ExtendedEntities != nil {
+		n = len(t.ExtendedEntities.Media)
+	} else if t.Entities != nil {
+		n = len(t.Entities.Media)
+	}
+	if n == 0 {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
+
 func findField(result gjson.Result, field string) string {
 	// Search recursively for the field
 	val := result.Get(field)
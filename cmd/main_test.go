@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xCatch/xcatch/pkg/utools"
+)
+
+func TestWriteTweetSummary(t *testing.T) {
+	tweets := []utools.TweetResult{
+		{
+			ID:            "1",
+			CreatedAt:     "Wed Oct 10 20:19:24 +0000 2018",
+			FullText:      "pinned\nannouncement",
+			FavoriteCount: 10,
+			RetweetCount:  2,
+			ReplyCount:    1,
+			User:          &utools.UserResult{ScreenName: "jack"},
+		},
+		{
+			ID:            "2",
+			CreatedAt:     "Thu Oct 11 08:00:00 +0000 2018",
+			Text:          "two photos " + strings.Repeat("x", 80),
+			FavoriteCount: 5,
+			UserIDStr:     "12",
+			ExtendedEntities: &utools.ExtendedEntities{Media: []utools.MediaEntity{
+				{Type: "photo"}, {Type: "photo"},
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeTweetSummary(&buf, tweets, map[string]bool{"1": true})
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if lines[0] != "Pinned:" || lines[3] != "Tweets:" {
+		t.Fatalf("expected pinned section before regular tweets:\n%s", buf.String())
+	}
+
+	pinned := strings.Fields(lines[2])
+	want := []string{"2018-10-10", "20:19", "@jack", "pinned", "announcement", "10", "2", "1", "-"}
+	if strings.Join(pinned, " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected pinned row (no media): %q", lines[2])
+	}
+
+	regular := lines[5]
+	if !strings.Contains(regular, "2018-10-11 08:00") || !strings.Contains(regular, "12") {
+		t.Fatalf("unexpected regular row: %q", regular)
+	}
+	if !strings.Contains(regular, strings.Repeat("x", 49)+"...") || strings.Contains(regular, strings.Repeat("x", 50)) {
+		t.Fatalf("expected text truncated to 60 runes: %q", regular)
+	}
+	if fields := strings.Fields(regular); fields[len(fields)-1] != "2" {
+		t.Fatalf("expected media count 2, got row %q", regular)
+	}
+}
+
+func TestPopFlag(t *testing.T) {
+	args, ok := popFlag([]string{"123", "--summary", "2"}, "--summary")
+	if !ok || strings.Join(args, " ") != "123 2" {
+		t.Fatalf("unexpected popFlag result: %v, %v", args, ok)
+	}
+	if _, ok := popFlag([]string{"123"}, "--summary"); ok {
+		t.Fatal("flag reported present when absent")
+	}
+}
+
+func TestWriteEndpoints(t *testing.T) {
+	var buf bytes.Buffer
+	writeEndpoints(&buf, []utools.EndpointInfo{
+		{Name: "GetFavoriters", Method: "GET", Path: "/favoritersV2", RequiredParams: []string{"tweetId"}, RequiresAuth: true},
+		{Name: "GetTrending", Method: "GET", Path: "/trending"},
+	})
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 || strings.Join(strings.Fields(lines[0]), " ") != "NAME METHOD PATH PARAMS AUTH" {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+	if got := strings.Join(strings.Fields(lines[1]), " "); got != "GetFavoriters GET /favoritersV2 tweetId yes" {
+		t.Fatalf("unexpected row: %q", got)
+	}
+	if got := strings.Join(strings.Fields(lines[2]), " "); got != "GetTrending GET /trending - -" {
+		t.Fatalf("unexpected row: %q", got)
+	}
+}
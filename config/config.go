@@ -2,10 +2,13 @@ package config
 
 import (
 	"bufio"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +20,10 @@ const (
 	DefaultTimeout    = 30 * time.Second
 	DefaultMaxRetries = 3
 	DefaultRateLimit  = 5.0 // QPS
+
+	// DefaultRateLimitResetThreshold is the x-rate-limit-reset value below
+	// which the gateway's robot token is considered stale.
+	DefaultRateLimitResetThreshold = 9
 )
 
 // Config holds the configuration for the uTools API client.
@@ -35,20 +42,154 @@ type Config struct {
 	// require both auth_token and ct0.
 	CT0 string
 
-	// Timeout is the HTTP request timeout.
+	// Timeout is the HTTP request timeout, applied to each attempt separately.
 	Timeout time.Duration
 
+	// OverallTimeout bounds the total time of a call across all attempts and
+	// retry backoffs. Zero disables it, leaving a call bounded only by
+	// MaxRetries * (Timeout + backoff).
+	OverallTimeout time.Duration
+
+	// FirstAttemptTimeout, when set, replaces Timeout for the first attempt
+	// only, so a slow first response is abandoned early and retried with the
+	// normal, more patient Timeout. It should be shorter than Timeout to have
+	// any effect. Zero disables it.
+	FirstAttemptTimeout time.Duration
+
+	// SlowRequestThreshold makes the client log a warning, with the endpoint
+	// path and the duration, for every attempt that takes longer than it, to
+	// surface upstream slowdowns. Zero disables it.
+	SlowRequestThreshold time.Duration
+
+	// NegativeCacheTTL makes the client remember for this long that a
+	// profile lookup found no such account (ErrUserNotFound), so repeated
+	// lookups of a missing handle return the error without a request. Zero
+	// disables it. See also utools.WithProfileCache.
+	NegativeCacheTTL time.Duration
+
+	// EndpointCacheTTL caches the responses of the endpoints it lists, keyed
+	// by path (e.g. "/tweetTimeline"), for the given TTL, so identical calls
+	// in that window do not cost a request. Only the methods documented as
+	// cached consult it, currently GetTweetDetail, whose entries are keyed
+	// by tweet and cursor. Non-positive TTLs are ignored; empty by default.
+	EndpointCacheTTL map[string]time.Duration
+
 	// MaxRetries is the maximum number of retries on rate limit / transient errors.
 	MaxRetries int
 
+	// RetryableBusinessCodes lists extra API business codes (the "code" field)
+	// to retry like rate limits, e.g. a deployment-specific "service busy".
+	// Empty by default.
+	RetryableBusinessCodes []int
+
+	// DefaultParams are sent with every request, e.g. a deployment-wide
+	// region or apiVersion. Per-call params of the same name take precedence;
+	// apiKey is always the client's own. See also utools.WithDefaultParams.
+	DefaultParams map[string]string
+
+	// MaxEmptyPages is how many consecutive pages with a new cursor but no
+	// items a PageIterator follows before it stops, for sparse timelines that
+	// return empty pages ahead of more data. Zero stops on the first empty
+	// page. See also utools.WithMaxEmptyPages.
+	MaxEmptyPages int
+
 	// RateLimit is the maximum requests per second (QPS).
 	RateLimit float64
+
+	// DisableRateLimit turns the client-side rate limiter off, ignoring
+	// RateLimit, for self-hosted gateways that enforce no limits of their
+	// own. MaxConcurrentRequests still applies.
+	DisableRateLimit bool
+
+	// MaxConcurrentRequests bounds the number of requests in flight at once,
+	// independently of RateLimit, which only bounds how often they start.
+	// Zero means unlimited.
+	MaxConcurrentRequests int
+
+	// StrictParamKeys sends only the preferred key for ID params on endpoints
+	// that historically accepted several spellings (e.g. tweetId / tweet_id /
+	// id). The default (false) keeps sending every alias for compatibility
+	// with older gateways; enable it for gateways that reject unknown params.
+	// It is the inverse of a "legacy aliases" switch defaulting to on,
+	// expressed this way so the zero Config keeps the compatible behavior.
+	StrictParamKeys bool
+
+	// TLSMinVersion is the minimum TLS version accepted, as a crypto/tls
+	// constant (e.g. tls.VersionTLS13). Zero means TLS 1.2.
+	TLSMinVersion uint16
+
+	// TLSInsecureSkipVerify disables server certificate verification.
+	// DEVELOPMENT ONLY: it is meant for local debugging proxies and exposes
+	// credentials to anyone able to intercept the connection. The client logs
+	// a warning when it is enabled.
+	TLSInsecureSkipVerify bool
+
+	// ForceHTTP1 disables HTTP/2, so concurrent requests use separate
+	// HTTP/1.1 connections instead of being multiplexed over one. Enable it
+	// when a proxy in front of the gateway suffers head-of-line blocking
+	// under concurrent load.
+	ForceHTTP1 bool
+
+	// DisableKeepAlives opens a new connection for every request. Enable it
+	// for proxies or load balancers that drop idle connections, which
+	// otherwise surface as sporadic connection-reset errors.
+	DisableKeepAlives bool
+
+	// APIKeyInQuery sends apiKey in the query string on POST requests too,
+	// for gateways that authenticate before parsing the body. Other params
+	// stay in the form body. The default (false) puts apiKey in the body.
+	APIKeyInQuery bool
+
+	// AutoTokenSync makes the client call TokenSync once and retry when a
+	// request fails with utools.ErrRobotTokenExpired. That retry comes on
+	// top of MaxRetries.
+	AutoTokenSync bool
+
+	// RateLimitResetThreshold is the x-rate-limit-reset header value below
+	// which the client logs a tokenSync hint and a 401/403/429 counts as
+	// utools.ErrRobotTokenExpired (and so triggers AutoTokenSync).
+	// Default: 9.
+	RateLimitResetThreshold int
+
+	// FollowHandleRedirects makes the profile methods re-request a user under
+	// its new handle when the requested one has been renamed, instead of
+	// returning *utools.ErrHandleChanged.
+	FollowHandleRedirects bool
+
+	// RepairInvalidUTF8 replaces invalid UTF-8 sequences in response bodies
+	// with U+FFFD instead of failing the attempt with the retryable
+	// utools.ErrCorruptResponse. Invalid UTF-8 usually means a truncated
+	// transfer, so leave this off unless the upstream is known to emit it.
+	RepairInvalidUTF8 bool
+
+	// DecodeCompressedData makes the client accept an envelope "data" string
+	// that is base64-encoded, optionally gzip-compressed, JSON, as returned
+	// by deployments that compress payloads.
+	DecodeCompressedData bool
+
+	// DumpDir, when set, makes the client write every HTTP exchange (method,
+	// URL, headers and raw bodies, failures included) to a timestamped file
+	// in this directory, for reproducing upstream bugs. Credentials are
+	// redacted. Off by default.
+	DumpDir string
 }
 
 // LoadFromFile creates a Config by reading a config.ini file.
 // The INI file format supports [xcatch] section with keys:
 //
-//	api_key, auth_token, ct0, base_url, timeout_sec, max_retries, rate_limit
+//	api_key, auth_token, ct0, base_url, timeout_sec, overall_timeout_sec,
+//	first_attempt_timeout_ms, slow_request_threshold_ms,
+//	negative_cache_ttl_ms, max_retries,
+//	rate_limit, disable_rate_limit, max_concurrent_requests, max_empty_pages,
+//	strict_param_keys,
+//	tls_min_version (1.0-1.3), tls_insecure_skip_verify, auto_token_sync,
+//	force_http1, disable_keep_alives,
+//	rate_limit_reset_threshold,
+//	api_key_in_query, follow_handle_redirects, repair_invalid_utf8, dump_dir,
+//	decode_compressed_data,
+//	retryable_business_codes (comma-separated),
+//	default_params (comma-separated key=value pairs),
+//	endpoint_cache_ttl_ms (comma-separated path=milliseconds pairs)
 func LoadFromFile(path string) (*Config, error) {
 	kvs, err := parseINI(path, "xcatch")
 	if err != nil {
@@ -91,6 +232,42 @@ func LoadFromFile(path string) (*Config, error) {
 			cfg.Timeout = time.Duration(sec) * time.Second
 		}
 	}
+	if v, ok := kvs["overall_timeout_sec"]; ok {
+		if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+			cfg.OverallTimeout = time.Duration(sec) * time.Second
+		}
+	} else if v, ok := kvs["xcatch_overall_timeout_sec"]; ok {
+		if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+			cfg.OverallTimeout = time.Duration(sec) * time.Second
+		}
+	}
+	if v, ok := kvs["first_attempt_timeout_ms"]; ok {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.FirstAttemptTimeout = time.Duration(ms) * time.Millisecond
+		}
+	} else if v, ok := kvs["xcatch_first_attempt_timeout_ms"]; ok {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.FirstAttemptTimeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if v, ok := kvs["slow_request_threshold_ms"]; ok {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.SlowRequestThreshold = time.Duration(ms) * time.Millisecond
+		}
+	} else if v, ok := kvs["xcatch_slow_request_threshold_ms"]; ok {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.SlowRequestThreshold = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if v, ok := kvs["negative_cache_ttl_ms"]; ok {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.NegativeCacheTTL = time.Duration(ms) * time.Millisecond
+		}
+	} else if v, ok := kvs["xcatch_negative_cache_ttl_ms"]; ok {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.NegativeCacheTTL = time.Duration(ms) * time.Millisecond
+		}
+	}
 	if v, ok := kvs["max_retries"]; ok {
 		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
 			cfg.MaxRetries = n
@@ -109,6 +286,164 @@ func LoadFromFile(path string) (*Config, error) {
 			cfg.RateLimit = f
 		}
 	}
+	if v, ok := kvs["disable_rate_limit"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DisableRateLimit = b
+		}
+	} else if v, ok := kvs["xcatch_disable_rate_limit"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DisableRateLimit = b
+		}
+	}
+	if v, ok := kvs["max_concurrent_requests"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxConcurrentRequests = n
+		}
+	} else if v, ok := kvs["xcatch_max_concurrent_requests"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxConcurrentRequests = n
+		}
+	}
+	if v, ok := kvs["max_empty_pages"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxEmptyPages = n
+		}
+	} else if v, ok := kvs["xcatch_max_empty_pages"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxEmptyPages = n
+		}
+	}
+	if v, ok := kvs["strict_param_keys"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.StrictParamKeys = b
+		}
+	} else if v, ok := kvs["xcatch_strict_param_keys"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.StrictParamKeys = b
+		}
+	}
+	if v, ok := kvs["tls_min_version"]; ok {
+		if ver, ok := parseTLSVersion(v); ok {
+			cfg.TLSMinVersion = ver
+		}
+	} else if v, ok := kvs["xcatch_tls_min_version"]; ok {
+		if ver, ok := parseTLSVersion(v); ok {
+			cfg.TLSMinVersion = ver
+		}
+	}
+	if v, ok := kvs["force_http1"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ForceHTTP1 = b
+		}
+	} else if v, ok := kvs["xcatch_force_http1"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ForceHTTP1 = b
+		}
+	}
+	if v, ok := kvs["disable_keep_alives"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DisableKeepAlives = b
+		}
+	} else if v, ok := kvs["xcatch_disable_keep_alives"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DisableKeepAlives = b
+		}
+	}
+	if v, ok := kvs["tls_insecure_skip_verify"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.TLSInsecureSkipVerify = b
+		}
+	} else if v, ok := kvs["xcatch_tls_insecure_skip_verify"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.TLSInsecureSkipVerify = b
+		}
+	}
+	if v, ok := kvs["auto_token_sync"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.AutoTokenSync = b
+		}
+	} else if v, ok := kvs["xcatch_auto_token_sync"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.AutoTokenSync = b
+		}
+	}
+	if v, ok := kvs["rate_limit_reset_threshold"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.RateLimitResetThreshold = n
+		}
+	} else if v, ok := kvs["xcatch_rate_limit_reset_threshold"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.RateLimitResetThreshold = n
+		}
+	}
+	if v, ok := kvs["api_key_in_query"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.APIKeyInQuery = b
+		}
+	} else if v, ok := kvs["xcatch_api_key_in_query"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.APIKeyInQuery = b
+		}
+	}
+	if v, ok := kvs["follow_handle_redirects"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.FollowHandleRedirects = b
+		}
+	} else if v, ok := kvs["xcatch_follow_handle_redirects"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.FollowHandleRedirects = b
+		}
+	}
+	if v, ok := kvs["dump_dir"]; ok {
+		cfg.DumpDir = v
+	} else if v, ok := kvs["xcatch_dump_dir"]; ok {
+		cfg.DumpDir = v
+	}
+	if v, ok := kvs["decode_compressed_data"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DecodeCompressedData = b
+		}
+	} else if v, ok := kvs["xcatch_decode_compressed_data"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DecodeCompressedData = b
+		}
+	}
+	if v, ok := kvs["repair_invalid_utf8"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.RepairInvalidUTF8 = b
+		}
+	} else if v, ok := kvs["xcatch_repair_invalid_utf8"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.RepairInvalidUTF8 = b
+		}
+	}
+	if v, ok := kvs["retryable_business_codes"]; ok {
+		if codes, ok := parseIntList(v); ok {
+			cfg.RetryableBusinessCodes = codes
+		}
+	} else if v, ok := kvs["xcatch_retryable_business_codes"]; ok {
+		if codes, ok := parseIntList(v); ok {
+			cfg.RetryableBusinessCodes = codes
+		}
+	}
+	if v, ok := kvs["default_params"]; ok {
+		if params, ok := parseKeyValueList(v); ok {
+			cfg.DefaultParams = params
+		}
+	} else if v, ok := kvs["xcatch_default_params"]; ok {
+		if params, ok := parseKeyValueList(v); ok {
+			cfg.DefaultParams = params
+		}
+	}
+	if v, ok := kvs["endpoint_cache_ttl_ms"]; ok {
+		if ttls, ok := parseDurationList(v); ok {
+			cfg.EndpointCacheTTL = ttls
+		}
+	} else if v, ok := kvs["xcatch_endpoint_cache_ttl_ms"]; ok {
+		if ttls, ok := parseDurationList(v); ok {
+			cfg.EndpointCacheTTL = ttls
+		}
+	}
 
 	return cfg, nil
 }
@@ -157,6 +492,26 @@ func Load(path string) *Config {
 			cfg.Timeout = time.Duration(sec) * time.Second
 		}
 	}
+	if v := os.Getenv("XCATCH_OVERALL_TIMEOUT_SEC"); v != "" {
+		if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+			cfg.OverallTimeout = time.Duration(sec) * time.Second
+		}
+	}
+	if v := os.Getenv("XCATCH_FIRST_ATTEMPT_TIMEOUT_MS"); v != "" {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.FirstAttemptTimeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if v := os.Getenv("XCATCH_SLOW_REQUEST_THRESHOLD_MS"); v != "" {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.SlowRequestThreshold = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if v := os.Getenv("XCATCH_NEGATIVE_CACHE_TTL_MS"); v != "" {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.NegativeCacheTTL = time.Duration(ms) * time.Millisecond
+		}
+	}
 	if v := os.Getenv("XCATCH_MAX_RETRIES"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
 			cfg.MaxRetries = n
@@ -167,10 +522,169 @@ func Load(path string) *Config {
 			cfg.RateLimit = f
 		}
 	}
+	if v := os.Getenv("XCATCH_DISABLE_RATE_LIMIT"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DisableRateLimit = b
+		}
+	}
+	if v := os.Getenv("XCATCH_MAX_CONCURRENT_REQUESTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxConcurrentRequests = n
+		}
+	}
+	if v := os.Getenv("XCATCH_MAX_EMPTY_PAGES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxEmptyPages = n
+		}
+	}
+	if v := os.Getenv("XCATCH_STRICT_PARAM_KEYS"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.StrictParamKeys = b
+		}
+	}
+	if v := os.Getenv("XCATCH_TLS_MIN_VERSION"); v != "" {
+		if ver, ok := parseTLSVersion(v); ok {
+			cfg.TLSMinVersion = ver
+		}
+	}
+	if v := os.Getenv("XCATCH_FORCE_HTTP1"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ForceHTTP1 = b
+		}
+	}
+	if v := os.Getenv("XCATCH_DISABLE_KEEP_ALIVES"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DisableKeepAlives = b
+		}
+	}
+	if v := os.Getenv("XCATCH_TLS_INSECURE_SKIP_VERIFY"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.TLSInsecureSkipVerify = b
+		}
+	}
+	if v := os.Getenv("XCATCH_AUTO_TOKEN_SYNC"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.AutoTokenSync = b
+		}
+	}
+	if v := os.Getenv("XCATCH_RATE_LIMIT_RESET_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.RateLimitResetThreshold = n
+		}
+	}
+	if v := os.Getenv("XCATCH_API_KEY_IN_QUERY"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.APIKeyInQuery = b
+		}
+	}
+	if v := os.Getenv("XCATCH_FOLLOW_HANDLE_REDIRECTS"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.FollowHandleRedirects = b
+		}
+	}
+	if v := os.Getenv("XCATCH_DUMP_DIR"); v != "" {
+		cfg.DumpDir = v
+	}
+	if v := os.Getenv("XCATCH_DECODE_COMPRESSED_DATA"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DecodeCompressedData = b
+		}
+	}
+	if v := os.Getenv("XCATCH_REPAIR_INVALID_UTF8"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.RepairInvalidUTF8 = b
+		}
+	}
+	if v := os.Getenv("XCATCH_RETRYABLE_BUSINESS_CODES"); v != "" {
+		if codes, ok := parseIntList(v); ok {
+			cfg.RetryableBusinessCodes = codes
+		}
+	}
+	if v := os.Getenv("XCATCH_DEFAULT_PARAMS"); v != "" {
+		if params, ok := parseKeyValueList(v); ok {
+			cfg.DefaultParams = params
+		}
+	}
+	if v := os.Getenv("XCATCH_ENDPOINT_CACHE_TTL_MS"); v != "" {
+		if ttls, ok := parseDurationList(v); ok {
+			cfg.EndpointCacheTTL = ttls
+		}
+	}
 
 	return cfg
 }
 
+// parseKeyValueList parses comma-separated key=value pairs such as
+// "region=us, apiVersion=2". ok is false if any element lacks a key or "=".
+func parseKeyValueList(v string) (map[string]string, bool) {
+	out := make(map[string]string)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, val, found := strings.Cut(part, "=")
+		k = strings.TrimSpace(k)
+		if !found || k == "" {
+			return nil, false
+		}
+		out[k] = strings.TrimSpace(val)
+	}
+	return out, true
+}
+
+// parseDurationList parses comma-separated key=milliseconds pairs such as
+// "/tweetTimeline=30000". ok is false if any pair is malformed or any value
+// is not a non-negative integer.
+func parseDurationList(v string) (map[string]time.Duration, bool) {
+	pairs, ok := parseKeyValueList(v)
+	if !ok {
+		return nil, false
+	}
+	out := make(map[string]time.Duration, len(pairs))
+	for k, val := range pairs {
+		ms, err := strconv.Atoi(val)
+		if err != nil || ms < 0 {
+			return nil, false
+		}
+		out[k] = time.Duration(ms) * time.Millisecond
+	}
+	return out, true
+}
+
+// parseIntList parses a comma-separated list of integers such as "131, 500".
+// ok is false if any element is not an integer.
+func parseIntList(v string) ([]int, bool) {
+	var out []int
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		out = append(out, n)
+	}
+	return out, true
+}
+
+// parseTLSVersion maps "1.0" .. "1.3" to the crypto/tls version constants.
+func parseTLSVersion(v string) (uint16, bool) {
+	switch strings.TrimSpace(v) {
+	case "1.0":
+		return tls.VersionTLS10, true
+	case "1.1":
+		return tls.VersionTLS11, true
+	case "1.2":
+		return tls.VersionTLS12, true
+	case "1.3":
+		return tls.VersionTLS13, true
+	}
+	return 0, false
+}
+
 // parseINI reads an INI file and returns key-value pairs for the given section.
 // If section is empty, it reads keys before any section header.
 func parseINI(path, section string) (map[string]string, error) {
@@ -228,11 +742,65 @@ func (c *Config) Validate() error {
 	if c.Timeout <= 0 {
 		c.Timeout = DefaultTimeout
 	}
+	if c.OverallTimeout < 0 {
+		c.OverallTimeout = 0
+	}
+	if c.FirstAttemptTimeout < 0 {
+		c.FirstAttemptTimeout = 0
+	}
+	if c.SlowRequestThreshold < 0 {
+		c.SlowRequestThreshold = 0
+	}
+	if c.NegativeCacheTTL < 0 {
+		c.NegativeCacheTTL = 0
+	}
+	if c.TLSMinVersion == 0 {
+		c.TLSMinVersion = tls.VersionTLS12
+	}
 	if c.MaxRetries < 0 {
 		c.MaxRetries = DefaultMaxRetries
 	}
-	if c.RateLimit <= 0 {
+	if c.RateLimit <= 0 && !c.DisableRateLimit {
 		c.RateLimit = DefaultRateLimit
 	}
+	if c.RateLimitResetThreshold <= 0 {
+		c.RateLimitResetThreshold = DefaultRateLimitResetThreshold
+	}
+	if c.MaxConcurrentRequests < 0 {
+		c.MaxConcurrentRequests = 0
+	}
+	if c.MaxEmptyPages < 0 {
+		c.MaxEmptyPages = 0
+	}
 	return nil
 }
+
+// With returns a deep copy of c with overrides applied in order, e.g. to
+// derive per-tenant configs from a shared base:
+//
+//	tenant := base.With(config.WithAPIKeyOverride(key))
+//
+// Slices and maps are copied, so changing the result never affects c.
+func (c *Config) With(overrides ...func(*Config)) *Config {
+	clone := *c
+	clone.RetryableBusinessCodes = slices.Clone(c.RetryableBusinessCodes)
+	clone.DefaultParams = maps.Clone(c.DefaultParams)
+	clone.EndpointCacheTTL = maps.Clone(c.EndpointCacheTTL)
+	for _, override := range overrides {
+		override(&clone)
+	}
+	return &clone
+}
+
+// WithAPIKeyOverride is a Config.With override setting APIKey.
+func WithAPIKeyOverride(apiKey string) func(*Config) {
+	return func(c *Config) { c.APIKey = apiKey }
+}
+
+// WithAuthTokenOverride is a Config.With override setting AuthToken and CT0.
+func WithAuthTokenOverride(authToken, ct0 string) func(*Config) {
+	return func(c *Config) {
+		c.AuthToken = authToken
+		c.CT0 = ct0
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigWith(t *testing.T) {
+	base := &Config{
+		APIKey:                 "base-key",
+		AuthToken:              "base-auth",
+		RateLimit:              3,
+		RetryableBusinessCodes: []int{131},
+		DefaultParams:          map[string]string{"region": "us"},
+		EndpointCacheTTL:       map[string]time.Duration{"/tweetTimeline": time.Second},
+	}
+
+	tenant := base.With(WithAPIKeyOverride("tenant-key"), WithAuthTokenOverride("tenant-auth", "tenant-ct0"))
+	if tenant.APIKey != "tenant-key" || tenant.AuthToken != "tenant-auth" || tenant.CT0 != "tenant-ct0" || tenant.RateLimit != 3 {
+		t.Fatalf("unexpected tenant config: %+v", tenant)
+	}
+	if base.APIKey != "base-key" || base.AuthToken != "base-auth" || base.CT0 != "" {
+		t.Fatalf("base config was modified: %+v", base)
+	}
+
+	tenant.RetryableBusinessCodes[0] = 500
+	tenant.RetryableBusinessCodes = append(tenant.RetryableBusinessCodes, 501)
+	tenant.DefaultParams["region"] = "eu"
+	tenant.EndpointCacheTTL["/tweetTimeline"] = time.Minute
+	if !reflect.DeepEqual(base.RetryableBusinessCodes, []int{131}) || base.DefaultParams["region"] != "us" ||
+		base.EndpointCacheTTL["/tweetTimeline"] != time.Second {
+		t.Fatalf("clone shares slices or maps with base: %+v", base)
+	}
+
+	if plain := (&Config{}).With(); plain.DefaultParams != nil || plain.RetryableBusinessCodes != nil || plain.EndpointCacheTTL != nil {
+		t.Fatalf("nil slices and maps should stay nil, got %+v", plain)
+	}
+}
@@ -0,0 +1,136 @@
+package utools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ResponseArchiver receives the raw body of every successful API response
+// (2xx, with an envelope code of 1 or none), together with the endpoint
+// path and the params as sent, default params and ParamSigner output
+// included. Params are redacted before the call: apiKey is removed and
+// credentials are masked.
+//
+// Archive is called synchronously on the request goroutine, so
+// implementations should be fast and safe for concurrent use.
+type ResponseArchiver interface {
+	Archive(path string, params map[string]string, body []byte)
+}
+
+// redactedValue replaces credential values in archived or logged params.
+const redactedValue = "REDACTED"
+
+// redactParams returns a copy of params without apiKey and with auth
+// credentials masked.
+func redactParams(params map[string]string) map[string]string {
+	out := make(map[string]string, len(params))
+	for k, v := range params {
+		switch k {
+		case "apiKey":
+			continue
+		case "auth_token", "ct0":
+			out[k] = redactedValue
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// archive hands a successful body to the archiver, if any, along with the
+// final params it was requested with (see sendParams), redacted. Bodies
+// whose envelope reports a business failure (code other than 0 or 1) are
+// not archived.
+func (c *Client) archive(path string, sent map[string]string, body []byte) {
+	if c.archiver == nil || envelopeFailed(body) {
+		return
+	}
+	c.archiver.Archive(resolveEndpointPath(path), redactParams(sent), body)
+}
+
+// envelopeFailed reports whether body is an API envelope with a failure
+// code.
+func envelopeFailed(body []byte) bool {
+	var envelope struct {
+		Code int `json:"code"`
+	}
+	return unmarshalJSON(body, &envelope) == nil && envelope.Code != 0 && envelope.Code != 1
+}
+
+// FileArchiver is a ResponseArchiver that writes one JSON file per response
+// under a directory. Each file records the endpoint path, redacted params,
+// the capture time, a SHA-256 of the body and the body itself. The body is
+// stored verbatim as a string so the checksum can be re-verified.
+type FileArchiver struct {
+	dir string
+	seq atomic.Uint64
+}
+
+// NewFileArchiver creates a FileArchiver writing into dir, creating it if needed.
+func NewFileArchiver(dir string) (*FileArchiver, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("utools: create archive dir: %w", err)
+	}
+	return &FileArchiver{dir: dir}, nil
+}
+
+type archiveRecord struct {
+	Path       string            `json:"path"`
+	Params     map[string]string `json:"params"`
+	ArchivedAt string            `json:"archived_at"`
+	SHA256     string            `json:"sha256"`
+	Body       string            `json:"body"`
+}
+
+// Archive implements ResponseArchiver. Write failures are logged, not returned,
+// so archival never fails the request it observes.
+func (a *FileArchiver) Archive(path string, params map[string]string, body []byte) {
+	now := time.Now().UTC()
+	sum := sha256.Sum256(body)
+	rec := archiveRecord{
+		Path:       path,
+		Params:     params,
+		ArchivedAt: now.Format(time.RFC3339Nano),
+		SHA256:     hex.EncodeToString(sum[:]),
+		Body:       string(body),
+	}
+
+	compact, err := marshalJSON(rec)
+	if err != nil {
+		log.Printf("[utools] archive %s: %v", path, err)
+		return
+	}
+	var data bytes.Buffer
+	if err := json.Indent(&data, compact, "", "  "); err != nil {
+		log.Printf("[utools] archive %s: %v", path, err)
+		return
+	}
+
+	name := fmt.Sprintf("%s-%06d-%s.json",
+		now.Format("20060102T150405.000000000Z"),
+		a.seq.Add(1),
+		archiveFileSlug(path),
+	)
+	if err := os.WriteFile(filepath.Join(a.dir, name), data.Bytes(), 0o644); err != nil {
+		log.Printf("[utools] archive %s: %v", path, err)
+	}
+}
+
+// archiveFileSlug turns an endpoint path into a file-name-safe fragment.
+func archiveFileSlug(path string) string {
+	path = strings.TrimPrefix(path, apiToolsBasePath)
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "root"
+	}
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(path)
+}
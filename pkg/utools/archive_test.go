@@ -0,0 +1,162 @@
+package utools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xCatch/xcatch/config"
+)
+
+type recordingArchiver struct {
+	mu     sync.Mutex
+	path   string
+	params map[string]string
+	body   []byte
+	calls  int
+}
+
+func (a *recordingArchiver) Archive(path string, params map[string]string, body []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.path = path
+	a.params = params
+	a.body = append([]byte(nil), body...)
+	a.calls++
+}
+
+func TestResponseArchiverReceivesBodyAndRedactedParams(t *testing.T) {
+	const payload = `{"code":1,"data":"{\"hello\":\"world\"}","msg":"SUCCESS"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer ts.Close()
+
+	archiver := &recordingArchiver{}
+	c, err := NewClient(&config.Config{
+		BaseURL:   ts.URL,
+		APIKey:    "test-key",
+		AuthToken: "secret-auth",
+		Timeout:   5 * time.Second,
+		RateLimit: 100,
+	}, WithResponseArchiver(archiver), WithDefaultParams(map[string]string{"lang": "en"}),
+		WithParamSigner(func(method, path string, params map[string]string) map[string]string {
+			return map[string]string{"sign": method + params["userId"]}
+		}))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	var result map[string]string
+	params := map[string]string{"userId": "42", "auth_token": c.authToken}
+	if err := c.Get(context.Background(), "/userTweetsV2", params, &result); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+
+	if archiver.calls != 1 {
+		t.Fatalf("expected one archive call, got %d", archiver.calls)
+	}
+	if archiver.path != "/api/base/apitools/userTweetsV2" {
+		t.Fatalf("unexpected archived path %q", archiver.path)
+	}
+	if string(archiver.body) != payload {
+		t.Fatalf("archived body mismatch: %s", archiver.body)
+	}
+	if _, ok := archiver.params["apiKey"]; ok {
+		t.Fatalf("apiKey must not be archived: %+v", archiver.params)
+	}
+	if archiver.params["auth_token"] != redactedValue {
+		t.Fatalf("auth_token must be redacted, got %q", archiver.params["auth_token"])
+	}
+	if archiver.params["userId"] != "42" || archiver.params["lang"] != "en" || archiver.params["sign"] != "GET42" {
+		t.Fatalf("expected the params as sent (defaults and signature included), got %+v", archiver.params)
+	}
+}
+
+func TestResponseArchiverSkipsFailedResponses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":400,"msg":"bad request"}`))
+	}))
+	defer ts.Close()
+
+	archiver := &recordingArchiver{}
+	c, err := NewClient(&config.Config{
+		BaseURL:   ts.URL,
+		APIKey:    "test-key",
+		Timeout:   5 * time.Second,
+		RateLimit: 100,
+	}, WithResponseArchiver(archiver))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	if _, err := c.GetRaw(context.Background(), "/bad", nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if archiver.calls != 0 {
+		t.Fatalf("failed responses must not be archived, got %d calls", archiver.calls)
+	}
+}
+
+func TestResponseArchiverSkipsBusinessFailures(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":2,"msg":"User not found"}`))
+	}))
+	defer ts.Close()
+
+	archiver := &recordingArchiver{}
+	c := newTestClient(t, ts.URL)
+	WithResponseArchiver(archiver)(c)
+
+	var result json.RawMessage
+	if err := c.Get(context.Background(), "/userTweetsV2", nil, &result); err == nil {
+		t.Fatal("expected a business error")
+	}
+	if _, err := c.GetRaw(context.Background(), "/userTweetsV2", nil); err != nil {
+		t.Fatalf("GetRaw error: %v", err)
+	}
+	if err := c.Get(context.Background(), "/userTweetsV2", nil, nil); err != nil {
+		t.Fatalf("Get without result error: %v", err)
+	}
+	if archiver.calls != 0 {
+		t.Fatalf("business failures must not be archived, got %d calls", archiver.calls)
+	}
+}
+
+func TestFileArchiverWritesRecord(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewFileArchiver(dir)
+	if err != nil {
+		t.Fatalf("NewFileArchiver: %v", err)
+	}
+	a.Archive("/api/base/apitools/search", map[string]string{"words": "go"}, []byte(`{"ok":true}`))
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one archive file, got %d", len(entries))
+	}
+	data, err := os.ReadFile(dir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	var rec archiveRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatalf("decode archive: %v", err)
+	}
+	if rec.Path != "/api/base/apitools/search" || rec.Params["words"] != "go" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if rec.Body != `{"ok":true}` || rec.SHA256 == "" {
+		t.Fatalf("expected body and checksum, got %+v", rec)
+	}
+}
@@ -0,0 +1,93 @@
+package utools
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// BackoffStrategy decides how long to wait before a retry. attempt is the
+// 1-based retry number: 1 before the first retry, 2 before the second, ...
+// Implementations must be safe for concurrent use.
+type BackoffStrategy interface {
+	Next(attempt int) time.Duration
+}
+
+// DefaultBackoff is the strategy used when none is configured:
+// 1s, 2s, 4s, ... capped at 30s.
+var DefaultBackoff BackoffStrategy = ExponentialBackoff{Base: time.Second, Max: 30 * time.Second}
+
+// WithBackoff sets the retry backoff strategy. A nil strategy keeps the default.
+func WithBackoff(b BackoffStrategy) Option {
+	return func(c *Client) {
+		if b != nil {
+			c.backoff = b
+		}
+	}
+}
+
+// ExponentialBackoff waits Base, 2*Base, 4*Base, ... capped at Max
+// (0 = uncapped).
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Next implements BackoffStrategy.
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	d := b.Base
+	for i := 1; i < attempt && d < math.MaxInt64/2; i++ {
+		d *= 2
+		if b.Max > 0 && d >= b.Max {
+			return b.Max
+		}
+	}
+	if b.Max > 0 && d > b.Max {
+		return b.Max
+	}
+	return d
+}
+
+// ConstantBackoff waits the same Delay before every retry.
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// Next implements BackoffStrategy.
+func (b ConstantBackoff) Next(int) time.Duration {
+	return b.Delay
+}
+
+// DecorrelatedJitterBackoff waits a random duration between Base and three
+// times the previous wait, capped at Max ("decorrelated jitter"). The
+// sequence restarts from Base at attempt 1. State is shared by every call
+// using the strategy, so concurrent retries draw from one sequence; the
+// bounds [Base, Max] hold regardless.
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+// Next implements BackoffStrategy.
+func (b *DecorrelatedJitterBackoff) Next(attempt int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if attempt <= 1 || b.prev < b.Base {
+		b.prev = b.Base
+	}
+	upper := b.prev * 3
+	if b.Max > 0 && upper > b.Max {
+		upper = b.Max
+	}
+	d := b.Base
+	if upper > b.Base {
+		d += rand.N(upper - b.Base + 1)
+	}
+	b.prev = d
+	return d
+}
@@ -0,0 +1,107 @@
+package utools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffSequence(t *testing.T) {
+	b := ExponentialBackoff{Base: time.Second, Max: 30 * time.Second}
+	want := []time.Duration{1, 2, 4, 8, 16, 30, 30}
+	for i, w := range want {
+		if got := b.Next(i + 1); got != w*time.Second {
+			t.Fatalf("attempt %d: expected %v, got %v", i+1, w*time.Second, got)
+		}
+	}
+	if got := (ExponentialBackoff{Base: time.Millisecond}).Next(11); got != 1024*time.Millisecond {
+		t.Fatalf("uncapped attempt 11: expected 1.024s, got %v", got)
+	}
+}
+
+func TestDefaultBackoffMatchesCappedExponential(t *testing.T) {
+	for i := 1; i <= 8; i++ {
+		want := time.Duration(1<<(i-1)) * time.Second
+		if want > 30*time.Second {
+			want = 30 * time.Second
+		}
+		if got := DefaultBackoff.Next(i); got != want {
+			t.Fatalf("attempt %d: expected %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestConstantBackoffSequence(t *testing.T) {
+	b := ConstantBackoff{Delay: 250 * time.Millisecond}
+	for i := 1; i <= 5; i++ {
+		if got := b.Next(i); got != 250*time.Millisecond {
+			t.Fatalf("attempt %d: expected 250ms, got %v", i, got)
+		}
+	}
+}
+
+func TestDecorrelatedJitterBackoffBounds(t *testing.T) {
+	b := &DecorrelatedJitterBackoff{Base: 10 * time.Millisecond, Max: 200 * time.Millisecond}
+	for run := 0; run < 50; run++ {
+		prev := b.Base
+		for i := 1; i <= 8; i++ {
+			got := b.Next(i)
+			upper := 3 * prev
+			if i == 1 {
+				upper = 3 * b.Base
+			}
+			if upper > b.Max {
+				upper = b.Max
+			}
+			if got < b.Base || got > upper {
+				t.Fatalf("attempt %d: %v outside [%v, %v]", i, got, b.Base, upper)
+			}
+			prev = got
+		}
+	}
+}
+
+func TestWithBackoffIsUsedForRetries(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"code":88,"msg":"rate limit"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	rec := &recordingBackoff{}
+	c := newTestClient(t, ts.URL)
+	WithBackoff(rec)(c)
+
+	start := time.Now()
+	if err := c.Get(context.Background(), "/flaky", nil, nil); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("custom backoff not applied, took %v", elapsed)
+	}
+	if got := rec.attempts.Load(); got != 2 {
+		t.Fatalf("expected strategy consulted for 2 retries, got %d", got)
+	}
+
+	WithBackoff(nil)(c)
+	if c.backoff != rec {
+		t.Fatal("WithBackoff(nil) must keep the current strategy")
+	}
+}
+
+type recordingBackoff struct {
+	attempts atomic.Int32
+}
+
+func (b *recordingBackoff) Next(int) time.Duration {
+	b.attempts.Add(1)
+	return time.Millisecond
+}
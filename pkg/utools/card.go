@@ -0,0 +1,175 @@
+package utools
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// PollResult is the typed view of a poll card attached to a tweet.
+type PollResult struct {
+	Choices         []PollChoice
+	EndsAt          time.Time // zero if the card does not carry an end time
+	DurationMinutes int
+	CountsAreFinal  bool
+}
+
+// PollChoice is one option of a poll with its vote count.
+type PollChoice struct {
+	Label string
+	Count int
+}
+
+// TotalVotes returns the sum of all choice counts.
+func (p *PollResult) TotalVotes() int {
+	total := 0
+	for _, c := range p.Choices {
+		total += c.Count
+	}
+	return total
+}
+
+// LinkCardResult is the typed view of a link preview card
+// (summary / summary_large_image / player).
+type LinkCardResult struct {
+	Name        string // card type, e.g. "summary_large_image"
+	URL         string // t.co link the card was generated for
+	Title       string
+	Description string
+	Domain      string
+	ImageURL    string
+}
+
+// maxPollChoices is the number of choices X allows in a poll.
+const maxPollChoices = 4
+
+// Poll parses the tweet's card as a poll. ok is false when the tweet has no
+// card or the card is not a poll.
+func (t *TweetResult) Poll() (*PollResult, bool) {
+	name, _, values := t.cardBindings()
+	if !strings.HasPrefix(name, "poll") {
+		return nil, false
+	}
+
+	poll := &PollResult{}
+	for i := 1; i <= maxPollChoices; i++ {
+		label, ok := values["choice"+strconv.Itoa(i)+"_label"]
+		if !ok {
+			break
+		}
+		count, _ := strconv.Atoi(bindingString(values["choice"+strconv.Itoa(i)+"_count"]))
+		poll.Choices = append(poll.Choices, PollChoice{Label: bindingString(label), Count: count})
+	}
+	if len(poll.Choices) == 0 {
+		return nil, false
+	}
+
+	if v := bindingString(values["end_datetime_utc"]); v != "" {
+		if ts, err := time.Parse(time.RFC3339, v); err == nil {
+			poll.EndsAt = ts
+		}
+	}
+	poll.DurationMinutes, _ = strconv.Atoi(bindingString(values["duration_minutes"]))
+	if v, ok := values["counts_are_final"]; ok {
+		poll.CountsAreFinal = v.Get("boolean_value").Bool()
+	}
+	return poll, true
+}
+
+// linkCardImageKeys lists image bindings from the largest to the smallest.
+var linkCardImageKeys = []string{
+	"photo_image_full_size_original",
+	"summary_photo_image_original",
+	"thumbnail_image_original",
+	"photo_image_full_size_large",
+	"summary_photo_image_large",
+	"thumbnail_image_large",
+	"thumbnail_image",
+}
+
+// LinkCard parses the tweet's card as a link preview. ok is false when the
+// tweet has no card, the card is a poll, or it carries no title.
+func (t *TweetResult) LinkCard() (*LinkCardResult, bool) {
+	name, url, values := t.cardBindings()
+	if name == "" || strings.HasPrefix(name, "poll") {
+		return nil, false
+	}
+	title := bindingString(values["title"])
+	if title == "" {
+		return nil, false
+	}
+
+	card := &LinkCardResult{
+		Name:        name,
+		URL:         url,
+		Title:       title,
+		Description: bindingString(values["description"]),
+		Domain:      bindingString(values["domain"]),
+	}
+	if card.Domain == "" {
+		card.Domain = bindingString(values["vanity_url"])
+	}
+	if card.URL == "" {
+		card.URL = bindingString(values["card_url"])
+	}
+	for _, k := range linkCardImageKeys {
+		if u := values[k].Get("image_value.url").String(); u != "" {
+			card.ImageURL = u
+			break
+		}
+	}
+	return card, true
+}
+
+// cardBindings normalizes the two card shapes into a key -> value map.
+// GraphQL cards nest under "legacy" and use a key/value array:
+//
+//	{"legacy":{"name":"poll2choice_text_only","binding_values":[{"key":"choice1_label","value":{...}}]}}
+//
+// Legacy REST cards use an object keyed by binding name:
+//
+//	{"name":"summary","binding_values":{"title":{"string_value":"..."}}}
+func (t *TweetResult) cardBindings() (name, url string, values map[string]gjson.Result) {
+	if len(t.Card) == 0 {
+		return "", "", nil
+	}
+	card := gjson.ParseBytes(t.Card)
+	if legacy := card.Get("legacy"); legacy.IsObject() {
+		card = legacy
+	}
+
+	values = make(map[string]gjson.Result)
+	bindings := card.Get("binding_values")
+	switch {
+	case bindings.IsArray():
+		bindings.ForEach(func(_, kv gjson.Result) bool {
+			values[kv.Get("key").String()] = kv.Get("value")
+			return true
+		})
+	case bindings.IsObject():
+		bindings.ForEach(func(k, v gjson.Result) bool {
+			values[k.String()] = v
+			return true
+		})
+	}
+
+	// GraphQL names are sometimes prefixed with a numeric id ("123:poll2choice_text_only").
+	name = card.Get("name").String()
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name, card.Get("url").String(), values
+}
+
+// bindingString returns the scalar value of a binding regardless of its type.
+func bindingString(v gjson.Result) string {
+	if s := v.Get("string_value"); s.Exists() {
+		return s.String()
+	}
+	if b := v.Get("boolean_value"); b.Exists() {
+		return b.String()
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package utools
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const pollCardFixture = `{
+	"rest_id": "card://1",
+	"legacy": {
+		"name": "poll4choice_text_only",
+		"url": "card://1",
+		"binding_values": [
+			{"key": "choice1_label", "value": {"string_value": "Red", "type": "STRING"}},
+			{"key": "choice1_count", "value": {"string_value": "10", "type": "STRING"}},
+			{"key": "choice2_label", "value": {"string_value": "Green", "type": "STRING"}},
+			{"key": "choice2_count", "value": {"string_value": "20", "type": "STRING"}},
+			{"key": "choice3_label", "value": {"string_value": "Blue", "type": "STRING"}},
+			{"key": "choice3_count", "value": {"string_value": "30", "type": "STRING"}},
+			{"key": "choice4_label", "value": {"string_value": "Other", "type": "STRING"}},
+			{"key": "choice4_count", "value": {"string_value": "40", "type": "STRING"}},
+			{"key": "end_datetime_utc", "value": {"string_value": "2024-05-01T12:00:00Z", "type": "STRING"}},
+			{"key": "duration_minutes", "value": {"string_value": "1440", "type": "STRING"}},
+			{"key": "counts_are_final", "value": {"boolean_value": true, "type": "BOOLEAN"}}
+		]
+	}
+}`
+
+const linkCardFixture = `{
+	"name": "summary_large_image",
+	"url": "https://t.co/abc",
+	"binding_values": {
+		"title": {"type": "STRING", "string_value": "Launch day"},
+		"description": {"type": "STRING", "string_value": "We shipped it."},
+		"domain": {"type": "STRING", "string_value": "example.com"},
+		"thumbnail_image_large": {"type": "IMAGE", "image_value": {"url": "https://pbs.twimg.com/card_img/1/large.jpg"}},
+		"summary_photo_image_original": {"type": "IMAGE", "image_value": {"url": "https://pbs.twimg.com/card_img/1/orig.jpg"}}
+	}
+}`
+
+func TestTweetResultPoll(t *testing.T) {
+	tweet := &TweetResult{Card: json.RawMessage(pollCardFixture)}
+	poll, ok := tweet.Poll()
+	if !ok {
+		t.Fatal("expected poll card")
+	}
+	if len(poll.Choices) != 4 {
+		t.Fatalf("expected 4 choices, got %+v", poll.Choices)
+	}
+	if poll.Choices[0] != (PollChoice{Label: "Red", Count: 10}) || poll.Choices[3] != (PollChoice{Label: "Other", Count: 40}) {
+		t.Fatalf("unexpected choices: %+v", poll.Choices)
+	}
+	if poll.TotalVotes() != 100 {
+		t.Fatalf("expected 100 votes, got %d", poll.TotalVotes())
+	}
+	if !poll.EndsAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) || poll.DurationMinutes != 1440 || !poll.CountsAreFinal {
+		t.Fatalf("unexpected poll metadata: %+v", poll)
+	}
+	if _, ok := tweet.LinkCard(); ok {
+		t.Fatal("poll must not be reported as a link card")
+	}
+}
+
+func TestTweetResultLinkCard(t *testing.T) {
+	tweet := &TweetResult{Card: json.RawMessage(linkCardFixture)}
+	card, ok := tweet.LinkCard()
+	if !ok {
+		t.Fatal("expected link card")
+	}
+	want := LinkCardResult{
+		Name:        "summary_large_image",
+		URL:         "https://t.co/abc",
+		Title:       "Launch day",
+		Description: "We shipped it.",
+		Domain:      "example.com",
+		ImageURL:    "https://pbs.twimg.com/card_img/1/orig.jpg",
+	}
+	if *card != want {
+		t.Fatalf("unexpected card:\n got %+v\nwant %+v", *card, want)
+	}
+	if _, ok := tweet.Poll(); ok {
+		t.Fatal("link card must not be reported as a poll")
+	}
+}
+
+func TestTweetResultWithoutCard(t *testing.T) {
+	tweet := &TweetResult{}
+	if _, ok := tweet.Poll(); ok {
+		t.Fatal("expected no poll")
+	}
+	if _, ok := tweet.LinkCard(); ok {
+		t.Fatal("expected no link card")
+	}
+}
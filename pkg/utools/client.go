@@ -1,19 +1,26 @@
 package utools
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
-	"math"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/time/rate"
 
@@ -26,30 +33,193 @@ const apiToolsBasePath = "/api/base/apitools"
 type Client struct {
 	baseURL    string
 	apiKey     string
+	keys       *keyPool // nil = single key
 	authToken  string
 	ct0        string
 	httpClient *http.Client
 	maxRetries int
 	limiter    *rate.Limiter
+	inflight   chan struct{} // nil = unlimited concurrency
+	archiver   ResponseArchiver
+	backoff    BackoffStrategy
+	validator  ResultValidator
+	signer     ParamSigner
+	profiles   *profileCache  // nil = profiles and not-found lookups are not cached
+	responses  *endpointCache // nil = no endpoint responses are cached
+
+	strictParamKeys bool
+	apiKeyInQuery   bool
+	retryableCodes  []int
+	resetThreshold  int
+	overallTimeout  time.Duration
+	firstTimeout    time.Duration
+	slowThreshold   time.Duration
+	maxEmptyPages   int
+	defaultParams   map[string]string
+
+	followHandleRedirects bool
+	repairInvalidUTF8     bool
+	decodeCompressedData  bool
+
+	autoTokenSync bool
+	tokenSyncMu   sync.Mutex
+	lastTokenSync time.Time
+
+	// active tracks the calls in flight for Close.
+	activeMu sync.Mutex
+	active   map[*activeRequest]struct{}
+	closed   bool
+}
+
+// Option customizes a Client beyond what config.Config expresses.
+type Option func(*Client)
+
+// WithResponseArchiver registers an archiver that receives every successful
+// raw response. See ResponseArchiver.
+func WithResponseArchiver(a ResponseArchiver) Option {
+	return func(c *Client) {
+		c.archiver = a
+	}
+}
+
+// ResultValidator inspects the unwrapped data of a successful response
+// before it is decoded, and returns an error to reject it. path is the full
+// endpoint path. Wrap ErrRetryableResult in the returned error to have the
+// request retried like a transient failure.
+type ResultValidator func(path string, raw json.RawMessage) error
+
+// WithResultValidation registers v to reject implausible responses, such as
+// a user with an empty rest_id, that the API reports as successes. Rejected
+// responses fail with *ErrInvalidResult.
+func WithResultValidation(v ResultValidator) Option {
+	return func(c *Client) {
+		c.validator = v
+	}
+}
+
+// ParamSigner computes extra params, such as a signature and a timestamp,
+// for gateways that require signed requests. It receives the method, the
+// full endpoint path and the final params of the request (defaults, per-call
+// params and apiKey merged) and must not modify them; the params it returns
+// are added to the request, replacing same-named ones.
+type ParamSigner func(method, path string, params map[string]string) (extraParams map[string]string)
+
+// WithParamSigner registers s to sign every request the client sends,
+// retries included.
+func WithParamSigner(s ParamSigner) Option {
+	return func(c *Client) {
+		c.signer = s
+	}
+}
+
+// WithLimiter makes the client throttle against l instead of its own
+// limiter, so several clients sharing an API key can share one budget. The
+// limiter's rate and burst take the place of Config.RateLimit and
+// Config.DisableRateLimit. A nil limiter keeps the client's own.
+func WithLimiter(l *rate.Limiter) Option {
+	return func(c *Client) {
+		if l != nil {
+			c.limiter = l
+		}
+	}
+}
+
+// WithTransport makes the client send its requests through rt instead of
+// its own transport, e.g. to stub responses in tests (see the utoolstest
+// package). The TLS, HTTP/1 and keep-alive settings of config.Config, and
+// Config.DumpDir, apply only to the client's own transport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		if rt != nil {
+			c.httpClient.Transport = rt
+		}
+	}
+}
+
+// WithDefaultParams adds params sent with every request, on top of
+// Config.DefaultParams (same-named keys replace them). Per-call params take
+// precedence over both, and apiKey is always the client's own.
+func WithDefaultParams(params map[string]string) Option {
+	return func(c *Client) {
+		if c.defaultParams == nil {
+			c.defaultParams = make(map[string]string, len(params))
+		}
+		for k, v := range params {
+			c.defaultParams[k] = v
+		}
+	}
 }
 
 // NewClient creates a new uTools API client from the given config.
-func NewClient(cfg *config.Config) (*Client, error) {
+func NewClient(cfg *config.Config, opts ...Option) (*Client, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 
-	return &Client{
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         cfg.TLSMinVersion,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify, // opt-in, dev only
+	}
+	if cfg.ForceHTTP1 {
+		transport.ForceAttemptHTTP2 = false
+		// A non-nil empty map turns off the automatic HTTP/2 upgrade.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	transport.DisableKeepAlives = cfg.DisableKeepAlives
+	if cfg.TLSInsecureSkipVerify {
+		log.Printf("[utools] WARNING: TLS certificate verification is disabled (TLSInsecureSkipVerify); use only for local development")
+	}
+
+	var rt http.RoundTripper = transport
+	if cfg.DumpDir != "" {
+		d, err := newDumpTransport(cfg.DumpDir, transport)
+		if err != nil {
+			return nil, err
+		}
+		rt = d
+	}
+
+	c := &Client{
 		baseURL:   strings.TrimRight(cfg.BaseURL, "/"),
 		apiKey:    cfg.APIKey,
 		authToken: cfg.AuthToken,
 		ct0:       cfg.CT0,
 		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
+			Timeout:   cfg.Timeout,
+			Transport: rt,
 		},
 		maxRetries: cfg.MaxRetries,
 		limiter:    rate.NewLimiter(rate.Limit(cfg.RateLimit), 1),
-	}, nil
+		backoff:    DefaultBackoff,
+		profiles:   newProfileCache(0, cfg.NegativeCacheTTL, false),
+		responses:  newEndpointCache(cfg.EndpointCacheTTL),
+
+		strictParamKeys: cfg.StrictParamKeys,
+		apiKeyInQuery:   cfg.APIKeyInQuery,
+		retryableCodes:  slices.Clone(cfg.RetryableBusinessCodes),
+		resetThreshold:  cfg.RateLimitResetThreshold,
+		overallTimeout:  cfg.OverallTimeout,
+		firstTimeout:    cfg.FirstAttemptTimeout,
+		slowThreshold:   cfg.SlowRequestThreshold,
+		maxEmptyPages:   cfg.MaxEmptyPages,
+		defaultParams:   maps.Clone(cfg.DefaultParams),
+		autoTokenSync:   cfg.AutoTokenSync,
+
+		followHandleRedirects: cfg.FollowHandleRedirects,
+		repairInvalidUTF8:     cfg.RepairInvalidUTF8,
+		decodeCompressedData:  cfg.DecodeCompressedData,
+	}
+	if cfg.DisableRateLimit {
+		c.limiter = rate.NewLimiter(rate.Inf, 0)
+	}
+	if cfg.MaxConcurrentRequests > 0 {
+		c.inflight = make(chan struct{}, cfg.MaxConcurrentRequests)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // Get performs a GET request to the given API path with query parameters.
@@ -64,22 +234,142 @@ func (c *Client) Post(ctx context.Context, path string, params map[string]string
 	return c.doWithRetry(ctx, http.MethodPost, path, params, result)
 }
 
+// getWithPathFallback performs a GET against each path in turn (usually
+// endpointPaths(preferred)), moving on only when shouldRetryWithNextEndpoint
+// says the endpoint may not be deployed under that name. A path reported as
+// not deployed is not retried, so the next one is tried at once. Any other
+// outcome, success or error, ends the search. On failure, the first error
+// other than "not deployed" is returned, such as a 5xx from the preferred
+// path, rather than the 404 of an alias; if every path is missing, the last
+// error is.
+func (c *Client) getWithPathFallback(ctx context.Context, paths []string, params map[string]string, result interface{}) error {
+	ctx = WithRequestOptions(ctx, failFastOnNotDeployed())
+	var err, firstErr error
+	for _, p := range paths {
+		err = c.Get(ctx, p, params, result)
+		if firstErr == nil && err != nil && !isEndpointNotDeployed(err) {
+			firstErr = err
+		}
+		if !shouldRetryWithNextEndpoint(err) {
+			break
+		}
+	}
+	if err != nil && firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
 // GetRaw performs a GET request and returns the raw response body bytes.
 func (c *Client) GetRaw(ctx context.Context, path string, params map[string]string) ([]byte, error) {
 	return c.doRawWithRetry(ctx, http.MethodGet, path, params)
 }
 
+// GetEnvelope performs a GET request and returns the whole envelope, for
+// callers that handle soft errors themselves: unlike Get, a failure code or
+// a message in place of the data is not turned into an error. Data is
+// unwrapped from its string encoding (honouring
+// Config.DecodeCompressedData); it is null when missing or blank, and stays
+// a JSON string when it holds a message rather than JSON. HTTP and transport
+// failures are still returned as errors, after the usual retries.
+func (c *Client) GetEnvelope(ctx context.Context, path string, params map[string]string) (*Envelope, error) {
+	body, err := c.GetRaw(ctx, path, params)
+	if err != nil {
+		return nil, err
+	}
+	env := &Envelope{}
+	if err := unmarshalJSON(body, env); err != nil {
+		return nil, fmt.Errorf("utools: unmarshal envelope: %w (body: %s)", err, Truncate(string(body), 500))
+	}
+
+	var dataStr string
+	if len(env.Data) > 0 && env.Data[0] == '"' && unmarshalJSON(env.Data, &dataStr) == nil {
+		if dataStr, err = c.inflateDataString(dataStr); err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.TrimSpace(dataStr) == "":
+			env.Data = nil
+		case json.Valid([]byte(dataStr)):
+			env.Data = json.RawMessage(dataStr)
+		}
+	}
+	if len(env.Data) == 0 {
+		env.Data = json.RawMessage("null")
+	}
+	return env, nil
+}
+
 func (c *Client) doWithRetry(ctx context.Context, method, path string, params map[string]string, result interface{}) error {
+	return c.retry(ctx, method, path, func(ctx context.Context) error {
+		return c.do(ctx, method, path, params, result)
+	})
+}
+
+func (c *Client) doRawWithRetry(ctx context.Context, method, path string, params map[string]string) ([]byte, error) {
+	var body []byte
+	err := c.retry(ctx, method, path, func(ctx context.Context) error {
+		var err error
+		body, err = c.doRaw(ctx, method, path, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// retry runs attempt under the rate limiter and concurrency cap, retrying retryable errors after
+// the backoff strategy's delay up to maxRetries times. When an overall timeout
+// is configured, the whole loop (attempts and backoffs) is bounded by it. When a
+// first-attempt timeout is configured, attempt 0 runs under that shorter
+// deadline and expiring it is treated as retryable. RequestOptions attached to
+// ctx adjust the policy for this call. The call is tracked for Close.
+func (c *Client) retry(ctx context.Context, method, path string, attempt func(ctx context.Context) error) (err error) {
+	opts := requestOptionsFrom(ctx)
+	if opts.err != nil {
+		return opts.err
+	}
+	ctx, done, err := c.trackRequest(ctx)
+	if err != nil {
+		return err
+	}
+	tracked := ctx
+	defer func() {
+		done()
+		err = closedError(tracked, err)
+	}()
+
+	parent := ctx
+	var overallDeadline time.Time
+	if c.overallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.overallTimeout)
+		defer cancel()
+		overallDeadline, _ = ctx.Deadline()
+	}
+	// overallExpired reports whether ctx ended because of the overall
+	// timeout rather than the caller's own context.
+	overallExpired := func() bool {
+		return !overallDeadline.IsZero() && parent.Err() == nil && ctx.Err() != nil
+	}
+
 	var lastErr error
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		if attempt > 0 {
-			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * time.Second
-			if backoff > 30*time.Second {
-				backoff = 30 * time.Second
+	synced := false
+	maxRetries := c.retriesFor(opts)
+	for i := 0; i <= maxRetries; i++ {
+		if i > 0 {
+			backoff := c.backoff.Next(i)
+			// No point sleeping if the next attempt cannot start in time.
+			if !overallDeadline.IsZero() && time.Until(overallDeadline) < backoff {
+				return c.overallTimeoutError(lastErr)
 			}
-			log.Printf("[utools] retry %d/%d for %s %s (backoff %v)", attempt, c.maxRetries, method, path, backoff)
+			log.Printf("[utools] retry %d/%d for %s %s (backoff %v)", i, maxRetries, method, path, backoff)
 			select {
 			case <-ctx.Done():
+				if overallExpired() {
+					return c.overallTimeoutError(lastErr)
+				}
 				return ctx.Err()
 			case <-time.After(backoff):
 			}
@@ -87,56 +377,117 @@ func (c *Client) doWithRetry(ctx context.Context, method, path string, params ma
 
 		// Wait for rate limiter
 		if err := c.limiter.Wait(ctx); err != nil {
+			if overallExpired() {
+				return c.overallTimeoutError(lastErr)
+			}
 			return fmt.Errorf("utools: rate limiter: %w", err)
 		}
+		if err := c.acquireSlot(ctx); err != nil {
+			if overallExpired() {
+				return c.overallTimeoutError(lastErr)
+			}
+			return fmt.Errorf("utools: concurrency limit: %w", err)
+		}
 
-		lastErr = c.do(ctx, method, path, params, result)
+		attemptCtx, cancelAttempt := ctx, context.CancelFunc(func() {})
+		shortened := i == 0 && c.firstTimeout > 0
+		if shortened {
+			attemptCtx, cancelAttempt = context.WithTimeout(ctx, c.firstTimeout)
+		}
+		lastErr = attempt(attemptCtx)
+		c.releaseSlot()
+		var apiErr *APIError
+		if errors.As(lastErr, &apiErr) {
+			apiErr.retryableCodes = c.retryableCodes
+			apiErr.resetThreshold = c.resetThreshold
+		}
+		firstTimedOut := shortened && attemptCtx.Err() != nil && ctx.Err() == nil
+		cancelAttempt()
 		if lastErr == nil {
 			return nil
 		}
+		if overallExpired() {
+			return c.overallTimeoutError(lastErr)
+		}
+		if firstTimedOut {
+			log.Printf("[utools] first attempt for %s %s exceeded %v", method, path, c.firstTimeout)
+			continue
+		}
+		if c.autoTokenSync && !synced && path != tokenSyncPath && errors.Is(lastErr, ErrRobotTokenExpired) {
+			synced = true
+			c.syncRobotToken(ctx)
+			// The attempt with the fresh token is extra and does not use
+			// up a retry, so it happens even with MaxRetries 0.
+			i--
+			continue
+		}
 
 		if !isRetryableError(lastErr) {
 			return lastErr
 		}
+		if opts.failFastOnRateLimit && isRateLimitError(lastErr) {
+			return lastErr
+		}
+		if opts.failFastOnNotDeployed && isEndpointNotDeployed(lastErr) {
+			return lastErr
+		}
 	}
 	return lastErr
 }
 
-func (c *Client) doRawWithRetry(ctx context.Context, method, path string, params map[string]string) ([]byte, error) {
-	var (
-		lastErr error
-		body    []byte
-	)
-
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		if attempt > 0 {
-			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * time.Second
-			if backoff > 30*time.Second {
-				backoff = 30 * time.Second
-			}
-			log.Printf("[utools] retry %d/%d for %s %s (backoff %v)", attempt, c.maxRetries, method, path, backoff)
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(backoff):
-			}
-		}
+// acquireSlot blocks until fewer than Config.MaxConcurrentRequests requests
+// are in flight or ctx is done. It is a no-op when no cap is configured.
+func (c *Client) acquireSlot(ctx context.Context) error {
+	if c.inflight == nil {
+		return nil
+	}
+	select {
+	case c.inflight <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-		if err := c.limiter.Wait(ctx); err != nil {
-			return nil, fmt.Errorf("utools: rate limiter: %w", err)
-		}
+// releaseSlot frees a slot taken by acquireSlot.
+func (c *Client) releaseSlot() {
+	if c.inflight != nil {
+		<-c.inflight
+	}
+}
 
-		body, lastErr = c.doRaw(ctx, method, path, params)
-		if lastErr == nil {
-			return body, nil
-		}
+func (c *Client) overallTimeoutError(lastErr error) error {
+	if lastErr == nil {
+		return fmt.Errorf("%w (%v)", ErrOverallTimeout, c.overallTimeout)
+	}
+	return fmt.Errorf("%w (%v), last error: %w", ErrOverallTimeout, c.overallTimeout, lastErr)
+}
 
-		if !isRetryableError(lastErr) {
-			return nil, lastErr
-		}
+// readBody reads r to EOF but gives up as soon as ctx is done: r is closed
+// to unblock a stalled read and ctx.Err() is reported in place of the
+// resulting read error.
+func readBody(ctx context.Context, r io.ReadCloser) ([]byte, error) {
+	stop := context.AfterFunc(ctx, func() { _ = r.Close() })
+	defer stop()
+	body, err := io.ReadAll(r)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
+	return body, err
+}
 
-	return nil, lastErr
+// validateUTF8 checks a successful response body before it is parsed, so a
+// body cut off mid-character surfaces as ErrCorruptResponse rather than as
+// a cryptic unmarshal error. With Config.RepairInvalidUTF8 the invalid
+// sequences are replaced with U+FFFD instead.
+func (c *Client) validateUTF8(body []byte) ([]byte, error) {
+	if utf8.Valid(body) {
+		return body, nil
+	}
+	if c.repairInvalidUTF8 {
+		return bytes.ToValidUTF8(body, []byte("\uFFFD")), nil
+	}
+	return nil, fmt.Errorf("%w (%d bytes)", ErrCorruptResponse, len(body))
 }
 
 func isRetryableError(err error) bool {
@@ -146,6 +497,9 @@ func isRetryableError(err error) bool {
 	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return false
 	}
+	if errors.Is(err, ErrCorruptResponse) || errors.Is(err, ErrRetryableResult) {
+		return true
+	}
 
 	var apiErr *APIError
 	if errors.As(err, &apiErr) {
@@ -173,15 +527,52 @@ func resolveEndpointPath(path string) string {
 	return apiToolsBasePath + path
 }
 
-func (c *Client) doRaw(ctx context.Context, method, path string, params map[string]string) ([]byte, error) {
-	reqURL := c.baseURL + resolveEndpointPath(path)
-
-	merged := make(map[string]string, len(params)+1)
+// requestParams returns the params to send for a call: the client's default
+// params, overridden by the per-call params, plus apiKey. Params with an
+// empty value are dropped, so an unset option never goes out as "cursor=";
+// no endpoint gives an explicit empty value a meaning, and "0" or "false"
+// are kept. The caller's map is not modified.
+func (c *Client) requestParams(params map[string]string) map[string]string {
+	merged := make(map[string]string, len(c.defaultParams)+len(params)+1)
+	for k, v := range c.defaultParams {
+		merged[k] = v
+	}
 	for k, v := range params {
 		merged[k] = v
 	}
+	for k, v := range merged {
+		if v == "" {
+			delete(merged, k)
+		}
+	}
 	merged["apiKey"] = c.apiKey
+	return merged
+}
+
+// buildRequest builds the HTTP request for path with params merged by
+// requestParams and sent with apiKey, plus the ParamSigner's params: a GET
+// carries them in the query string, a POST in a form-encoded body (with
+// apiKey moved to the query when Config.APIKeyInQuery is set). Every request
+// the client sends is built here.
+func (c *Client) buildRequest(ctx context.Context, method, path string, params map[string]string, apiKey string) (*http.Request, error) {
+	return c.newRequest(ctx, method, path, c.sendParams(method, path, params, apiKey), apiKey)
+}
 
+// sendParams returns the final params of a request: params merged by
+// requestParams, apiKey, and the ParamSigner's params.
+func (c *Client) sendParams(method, path string, params map[string]string, apiKey string) map[string]string {
+	merged := c.requestParams(params)
+	merged["apiKey"] = apiKey
+	if c.signer != nil {
+		maps.Copy(merged, c.signer(method, resolveEndpointPath(path), maps.Clone(merged)))
+	}
+	return merged
+}
+
+// newRequest builds the HTTP request for path carrying the final params
+// merged, as returned by sendParams.
+func (c *Client) newRequest(ctx context.Context, method, path string, merged map[string]string, apiKey string) (*http.Request, error) {
+	reqURL := c.baseURL + resolveEndpointPath(path)
 	var req *http.Request
 	var err error
 
@@ -203,7 +594,12 @@ func (c *Client) doRaw(ctx context.Context, method, path string, params map[stri
 		for k, v := range merged {
 			form.Set(k, v)
 		}
-		req, err = http.NewRequestWithContext(ctx, method, reqURL, strings.NewReader(form.Encode()))
+		postURL := reqURL
+		if c.apiKeyInQuery {
+			form.Del("apiKey")
+			postURL += "?" + url.Values{"apiKey": {apiKey}}.Encode()
+		}
+		req, err = http.NewRequestWithContext(ctx, method, postURL, strings.NewReader(form.Encode()))
 		if err == nil {
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		}
@@ -217,35 +613,54 @@ func (c *Client) doRaw(ctx context.Context, method, path string, params map[stri
 	}
 
 	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// send makes a single request attempt and returns the status code and body
+// of a 2xx response, after UTF-8 validation, with the final params it sent
+// (see sendParams). Other statuses are returned as *APIError. Callers
+// archive the body once it has been accepted.
+func (c *Client) send(ctx context.Context, method, path string, params map[string]string) (status int, body []byte, sent map[string]string, err error) {
+	apiKey := c.pickAPIKey()
+	sent = c.sendParams(method, path, params, apiKey)
+	req, err := c.newRequest(ctx, method, path, sent, apiKey)
+	if err != nil {
+		return 0, nil, nil, err
+	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("utools: http request: %w", err)
+		c.logSlowRequest(method, path, time.Since(start))
+		return 0, nil, nil, fmt.Errorf("utools: http request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = readBody(ctx, resp.Body)
+	c.logSlowRequest(method, path, time.Since(start))
 	if err != nil {
-		return nil, fmt.Errorf("utools: read body: %w", err)
+		return 0, nil, nil, fmt.Errorf("utools: read body: %w", err)
 	}
 
-	if resetStr := resp.Header.Get("x-rate-limit-reset"); resetStr != "" {
-		if resetVal, parseErr := strconv.Atoi(resetStr); parseErr == nil && resetVal < 9 {
-			log.Printf("[utools] x-rate-limit-reset=%d, consider calling tokenSync", resetVal)
-		}
+	c.checkRateLimitReset(resp.Header)
+	if c.keys != nil {
+		c.keys.observe(apiKey, resp.StatusCode, resp.Header)
 	}
 
+	// Handle non-2xx
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		apiErr := &APIError{
-			StatusCode: resp.StatusCode,
-			RawBody:    string(body),
+			StatusCode:     resp.StatusCode,
+			RawBody:        string(body),
+			RateLimitReset: resp.Header.Get("x-rate-limit-reset"),
 		}
+		// Try to parse error details from body
 		var errResp struct {
 			Code    int    `json:"code"`
 			Message string `json:"message"`
 			Msg     string `json:"msg"`
 		}
-		if json.Unmarshal(body, &errResp) == nil {
+		if unmarshalJSON(body, &errResp) == nil {
 			apiErr.Code = errResp.Code
 			if errResp.Message != "" {
 				apiErr.Message = errResp.Message
@@ -256,102 +671,49 @@ func (c *Client) doRaw(ctx context.Context, method, path string, params map[stri
 		if apiErr.Message == "" {
 			apiErr.Message = string(body)
 		}
-		return nil, apiErr
+		return 0, nil, nil, apiErr
 	}
 
-	return body, nil
-}
-
-func (c *Client) do(ctx context.Context, method, path string, params map[string]string, result interface{}) error {
-	// Build URL
-	reqURL := c.baseURL + resolveEndpointPath(path)
-
-	// Copy params to avoid mutating the caller's map, and inject apiKey
-	merged := make(map[string]string, len(params)+1)
-	for k, v := range params {
-		merged[k] = v
-	}
-	merged["apiKey"] = c.apiKey
-
-	var req *http.Request
-	var err error
-
-	switch method {
-	case http.MethodGet:
-		u, parseErr := url.Parse(reqURL)
-		if parseErr != nil {
-			return fmt.Errorf("utools: parse url: %w", parseErr)
-		}
-		q := u.Query()
-		for k, v := range merged {
-			q.Set(k, v)
-		}
-		u.RawQuery = q.Encode()
-		req, err = http.NewRequestWithContext(ctx, method, u.String(), nil)
-
-	case http.MethodPost:
-		form := url.Values{}
-		for k, v := range merged {
-			form.Set(k, v)
-		}
-		req, err = http.NewRequestWithContext(ctx, method, reqURL, strings.NewReader(form.Encode()))
-		if err == nil {
-			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		}
-
-	default:
-		return fmt.Errorf("utools: unsupported method: %s", method)
+	if body, err = c.validateUTF8(body); err != nil {
+		return 0, nil, nil, err
 	}
+	return resp.StatusCode, body, sent, nil
+}
 
-	if err != nil {
-		return fmt.Errorf("utools: create request: %w", err)
+// logSlowRequest logs a warning when an attempt took longer than
+// Config.SlowRequestThreshold. Only the endpoint path is logged, never the
+// params, which carry credentials.
+func (c *Client) logSlowRequest(method, path string, elapsed time.Duration) {
+	if c.slowThreshold > 0 && elapsed > c.slowThreshold {
+		log.Printf("[utools] WARNING: slow request %s %s took %v (threshold %v)",
+			method, resolveEndpointPath(path), elapsed.Round(time.Millisecond), c.slowThreshold)
 	}
+}
 
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+func (c *Client) doRaw(ctx context.Context, method, path string, params map[string]string) ([]byte, error) {
+	_, body, sent, err := c.send(ctx, method, path, params)
 	if err != nil {
-		return fmt.Errorf("utools: http request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	c.archive(path, sent, body)
+	return body, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *Client) do(ctx context.Context, method, path string, params map[string]string, result interface{}) error {
+	status, body, sent, err := c.send(ctx, method, path, params)
 	if err != nil {
-		return fmt.Errorf("utools: read body: %w", err)
+		return err
 	}
-
-	// Check x-rate-limit-reset header
-	if resetStr := resp.Header.Get("x-rate-limit-reset"); resetStr != "" {
-		if resetVal, parseErr := strconv.Atoi(resetStr); parseErr == nil && resetVal < 9 {
-			log.Printf("[utools] x-rate-limit-reset=%d, consider calling tokenSync", resetVal)
-		}
+	if err := c.decode(path, status, body, result); err != nil {
+		return err
 	}
+	c.archive(path, sent, body)
+	return nil
+}
 
-	// Handle non-2xx
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		apiErr := &APIError{
-			StatusCode: resp.StatusCode,
-			RawBody:    string(body),
-		}
-		// Try to parse error details from body
-		var errResp struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-			Msg     string `json:"msg"`
-		}
-		if json.Unmarshal(body, &errResp) == nil {
-			apiErr.Code = errResp.Code
-			if errResp.Message != "" {
-				apiErr.Message = errResp.Message
-			} else {
-				apiErr.Message = errResp.Msg
-			}
-		}
-		if apiErr.Message == "" {
-			apiErr.Message = string(body)
-		}
-		return apiErr
-	}
+// decode checks the envelope of a successful HTTP response to path and
+// unmarshals its data into result.
+func (c *Client) decode(path string, status int, body []byte, result interface{}) error {
 
 	// Unwrap the API envelope: {"code":1, "data":"<json_string>", "msg":"SUCCESS"}
 	// The "data" field is a JSON-encoded string that needs double-unmarshal.
@@ -362,11 +724,11 @@ func (c *Client) do(ctx context.Context, method, path string, params map[string]
 			Msg    string          `json:"msg"`
 			Result json.RawMessage `json:"result"`
 		}
-		if err := json.Unmarshal(body, &envelope); err == nil && (len(envelope.Data) > 0 || envelope.Code != 0) {
+		if err := unmarshalJSON(body, &envelope); err == nil && (len(envelope.Data) > 0 || envelope.Code != 0) {
 			// Check for business-level errors (code != 1 means failure)
 			if envelope.Code != 0 && envelope.Code != 1 {
 				return &APIError{
-					StatusCode: resp.StatusCode,
+					StatusCode: status,
 					Code:       envelope.Code,
 					Message:    envelope.Msg,
 					RawBody:    string(body),
@@ -376,37 +738,52 @@ func (c *Client) do(ctx context.Context, method, path string, params map[string]
 			// Check if data is a JSON string (starts with `"`)
 			if len(envelope.Data) > 0 && envelope.Data[0] == '"' {
 				var dataStr string
-				if err := json.Unmarshal(envelope.Data, &dataStr); err == nil {
+				if err := unmarshalJSON(envelope.Data, &dataStr); err == nil {
 					if strings.TrimSpace(dataStr) == "" {
-						if err := json.Unmarshal([]byte("null"), result); err != nil {
+						if err := c.validateResult(path, []byte("null")); err != nil {
+							return err
+						}
+						if err := unmarshalJSON([]byte("null"), result); err != nil {
 							return fmt.Errorf("utools: unmarshal empty inner data as null: %w", err)
 						}
 						return nil
 					}
+					if dataStr, err = c.inflateDataString(dataStr); err != nil {
+						return err
+					}
 					if !json.Valid([]byte(dataStr)) {
 						return &APIError{
-							StatusCode: resp.StatusCode,
+							StatusCode: status,
 							Code:       envelope.Code,
 							Message:    dataStr,
 							RawBody:    string(body),
 						}
 					}
+					if err := c.validateResult(path, []byte(dataStr)); err != nil {
+						return err
+					}
 					// dataStr is the inner JSON — unmarshal it into result
-					if err := json.Unmarshal([]byte(dataStr), result); err != nil {
+					if err := unmarshalJSON([]byte(dataStr), result); err != nil {
 						return fmt.Errorf("utools: unmarshal inner data: %w (data: %s)", err, Truncate(dataStr, 500))
 					}
 					return nil
 				}
 			}
 			// data is already a JSON object/array, use it directly
-			if err := json.Unmarshal(envelope.Data, result); err != nil {
+			if err := c.validateResult(path, envelope.Data); err != nil {
+				return err
+			}
+			if err := unmarshalJSON(envelope.Data, result); err != nil {
 				return fmt.Errorf("utools: unmarshal data field: %w (data: %s)", err, Truncate(string(envelope.Data), 500))
 			}
 			return nil
 		}
 
 		// Fallback: no envelope, unmarshal the whole body
-		if err := json.Unmarshal(body, result); err != nil {
+		if err := c.validateResult(path, body); err != nil {
+			return err
+		}
+		if err := unmarshalJSON(body, result); err != nil {
 			return fmt.Errorf("utools: unmarshal response: %w (body: %s)", err, Truncate(string(body), 500))
 		}
 	}
@@ -414,12 +791,110 @@ func (c *Client) do(ctx context.Context, method, path string, params map[string]
 	return nil
 }
 
+// validateResult runs the registered ResultValidator, if any, on the
+// unwrapped data of a response to path.
+func (c *Client) validateResult(path string, raw []byte) error {
+	if c.validator == nil {
+		return nil
+	}
+	full := resolveEndpointPath(path)
+	if err := c.validator(full, raw); err != nil {
+		return &ErrInvalidResult{Path: full, Err: err}
+	}
+	return nil
+}
+
+// inflateDataString returns the JSON carried by a string-encoded "data"
+// field that is not JSON itself, by decoding it as base64 / gzip when
+// Config.DecodeCompressedData is set. JSON strings, and strings that are
+// plain messages, are returned unchanged.
+func (c *Client) inflateDataString(s string) (string, error) {
+	if !c.decodeCompressedData || json.Valid([]byte(s)) {
+		return s, nil
+	}
+	decoded, ok, err := decodeCompressedData(s)
+	if err != nil {
+		return "", fmt.Errorf("utools: decode compressed data: %w (data: %s)", err, Truncate(s, 500))
+	}
+	if !ok {
+		return s, nil
+	}
+	return string(decoded), nil
+}
+
+// decodeCompressedData decodes an envelope "data" string holding base64
+// (standard or URL alphabet, padded or not) of JSON or of gzip-compressed
+// JSON. ok is false, with a nil error, when s does not decode to JSON and
+// carries no gzip stream: such strings are the upstream's plain-text error
+// messages (short words are often valid base64 too) and are reported as an
+// APIError by the caller. A gzip stream that does not inflate to JSON is an
+// error.
+func decodeCompressedData(s string) (decoded []byte, ok bool, err error) {
+	s = strings.TrimSpace(s)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err = enc.DecodeString(s); err == nil {
+			break
+		}
+	}
+	if err != nil || len(decoded) == 0 {
+		return nil, false, nil
+	}
+	if len(decoded) < 2 || decoded[0] != 0x1f || decoded[1] != 0x8b {
+		return decoded, json.Valid(decoded), nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, false, fmt.Errorf("gunzip: %w", err)
+	}
+	defer zr.Close()
+	if decoded, err = io.ReadAll(zr); err != nil {
+		return nil, false, fmt.Errorf("gunzip: %w", err)
+	}
+	if !json.Valid(decoded) {
+		return nil, false, errors.New("decompressed data is not valid JSON")
+	}
+	return decoded, true, nil
+}
+
+// checkRateLimitReset logs a hint when the x-rate-limit-reset header is
+// below Config.RateLimitResetThreshold, the sign of a stale robot token.
+func (c *Client) checkRateLimitReset(h http.Header) {
+	if reset, err := strconv.Atoi(h.Get("x-rate-limit-reset")); err == nil && reset < c.resetThreshold {
+		log.Printf("[utools] x-rate-limit-reset=%d, consider calling tokenSync", reset)
+	}
+}
+
+const tokenSyncPath = "/tokenSync"
+
+// tokenSyncDebounce is how long an automatic token sync covers concurrent
+// calls that hit the same expired token.
+const tokenSyncDebounce = 10 * time.Second
+
 // TokenSync calls the tokenSync endpoint to refresh the robot token.
-// Should be called when x-rate-limit-reset < 9 or persistent errors occur.
+// Should be called when x-rate-limit-reset drops below
+// Config.RateLimitResetThreshold or persistent errors occur.
 func (c *Client) TokenSync(ctx context.Context) error {
 	params := map[string]string{}
 	var result json.RawMessage
-	return c.Get(ctx, "/tokenSync", params, &result)
+	return c.Get(ctx, tokenSyncPath, params, &result)
+}
+
+// syncRobotToken runs TokenSync on behalf of a call that hit
+// ErrRobotTokenExpired, unless another call synced moments ago. Failures are
+// logged; the caller's retry reports the original error if the token stays
+// expired.
+func (c *Client) syncRobotToken(ctx context.Context) {
+	c.tokenSyncMu.Lock()
+	defer c.tokenSyncMu.Unlock()
+	if time.Since(c.lastTokenSync) < tokenSyncDebounce {
+		return
+	}
+	log.Printf("[utools] robot token expired, calling tokenSync")
+	if err := c.TokenSync(ctx); err != nil {
+		log.Printf("[utools] tokenSync failed: %v", err)
+		return
+	}
+	c.lastTokenSync = time.Now()
 }
 
 // Truncate shortens a string to maxLen characters, appending "..." if truncated.
@@ -1,14 +1,30 @@
 package utools
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httputil"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/time/rate"
 
 	"github.com/xCatch/xcatch/config"
 )
@@ -107,3 +123,904 @@ func TestDoWithRetryRetriesOnRateLimitThenSucceeds(t *testing.T) {
 		t.Fatalf("expected exactly one retry, hits=%d", got)
 	}
 }
+
+func TestOverallTimeoutAbortsRetriesEarly(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"code":88,"msg":"rate limit"}`))
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(&config.Config{
+		BaseURL:        ts.URL,
+		APIKey:         "test-key",
+		Timeout:        5 * time.Second,
+		OverallTimeout: 1500 * time.Millisecond,
+		MaxRetries:     3, // backoffs 1s + 2s + 4s would take ~7s
+		RateLimit:      100,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	start := time.Now()
+	err = c.Get(context.Background(), "/slow", nil, nil)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrOverallTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected overall timeout error, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsRateLimited() {
+		t.Fatalf("expected last API error to be preserved, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Fatalf("expected early abort within the overall timeout, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("expected 2 attempts before aborting, got %d", got)
+	}
+}
+
+func TestFirstAttemptTimeoutRetriesSlowFirstHit(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":"{\"ok\":true}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(&config.Config{
+		BaseURL:             ts.URL,
+		APIKey:              "test-key",
+		Timeout:             5 * time.Second,
+		FirstAttemptTimeout: 100 * time.Millisecond,
+		MaxRetries:          1,
+		RateLimit:           100,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	var result struct {
+		OK bool `json:"ok"`
+	}
+	start := time.Now()
+	if err := c.Get(context.Background(), "/slowFirst", nil, &result); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if !result.OK {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got)
+	}
+	// One 1s backoff plus the 100ms first attempt; well under the 2s stall.
+	if elapsed := time.Since(start); elapsed > 1900*time.Millisecond {
+		t.Fatalf("first attempt was not cut short, took %v", elapsed)
+	}
+}
+
+func TestFirstAttemptTimeoutDoesNotMaskCallerCancellation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(&config.Config{
+		BaseURL:             ts.URL,
+		APIKey:              "test-key",
+		Timeout:             5 * time.Second,
+		FirstAttemptTimeout: time.Second,
+		MaxRetries:          3,
+		RateLimit:           100,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = c.Get(ctx, "/hang", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected caller deadline error, got %v", err)
+	}
+}
+
+func TestReadBodyAbortsOnContextCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":"`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(3 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.GetRaw(ctx, "/stream", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("body read did not abort promptly, took %v", elapsed)
+	}
+}
+
+func TestTLSInsecureSkipVerify(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":"{\"ok\":true}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	newClient := func(skipVerify bool) *Client {
+		c, err := NewClient(&config.Config{
+			BaseURL:               ts.URL,
+			APIKey:                "test-key",
+			Timeout:               5 * time.Second,
+			RateLimit:             100,
+			TLSInsecureSkipVerify: skipVerify,
+		})
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		return c
+	}
+
+	if _, err := newClient(false).GetRaw(context.Background(), "/secure", nil); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("expected certificate verification failure by default, got %v", err)
+	}
+	if _, err := newClient(true).GetRaw(context.Background(), "/secure", nil); err != nil {
+		t.Fatalf("expected skip-verify request to succeed, got %v", err)
+	}
+}
+
+func TestTLSMinVersionDefaultsToTLS12(t *testing.T) {
+	c := newTestClient(t, "http://127.0.0.1:0")
+	tr, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("unexpected transport %T", c.httpClient.Transport)
+	}
+	if tr.TLSClientConfig.MinVersion != tls.VersionTLS12 || tr.TLSClientConfig.InsecureSkipVerify {
+		t.Fatalf("expected secure TLS defaults, got %+v", tr.TLSClientConfig)
+	}
+}
+
+func TestRobotTokenExpiredFromResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-rate-limit-reset", "2")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":89,"msg":"Invalid or expired token."}`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	c.maxRetries = 0
+	_, err := c.GetRaw(context.Background(), "/userTweetsV2", nil)
+	if !errors.Is(err, ErrRobotTokenExpired) {
+		t.Fatalf("expected ErrRobotTokenExpired, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RateLimitReset != "2" {
+		t.Fatalf("expected header on APIError, got %+v", apiErr)
+	}
+}
+
+func TestAutoTokenSyncOnExpiredToken(t *testing.T) {
+	// The attempt after the sync is extra: it happens even with no retries.
+	for _, maxRetries := range []int{0, 1} {
+		t.Run(fmt.Sprintf("MaxRetries=%d", maxRetries), func(t *testing.T) {
+			var mu sync.Mutex
+			var calls []string
+			expired := true
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/base/apitools"))
+				switch {
+				case r.URL.Path == "/api/base/apitools/tokenSync":
+					expired = false
+					_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+				case expired:
+					w.WriteHeader(http.StatusUnauthorized)
+					_, _ = w.Write([]byte(`{"code":89,"msg":"Invalid or expired token."}`))
+				default:
+					_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+				}
+			}))
+			defer ts.Close()
+
+			c, err := NewClient(&config.Config{
+				BaseURL:       ts.URL,
+				APIKey:        "test-key",
+				Timeout:       5 * time.Second,
+				MaxRetries:    maxRetries,
+				RateLimit:     100,
+				AutoTokenSync: true,
+			}, WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+			if err != nil {
+				t.Fatalf("new client: %v", err)
+			}
+
+			if err := c.Get(context.Background(), "/userTweetsV2", nil, nil); err != nil {
+				t.Fatalf("expected success after token sync, got %v", err)
+			}
+			if got := strings.Join(calls, ","); got != "/userTweetsV2,/tokenSync,/userTweetsV2" {
+				t.Fatalf("unexpected call sequence: %s", got)
+			}
+		})
+	}
+}
+
+func TestPostAPIKeyPlacement(t *testing.T) {
+	for _, inQuery := range []bool{false, true} {
+		t.Run(map[bool]string{false: "body", true: "query"}[inQuery], func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Fatalf("expected POST, got %s", r.Method)
+				}
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("parse form: %v", err)
+				}
+				queryKey := r.URL.Query().Get("apiKey")
+				bodyKey := r.PostForm.Get("apiKey")
+				if inQuery && (queryKey != "test-key" || bodyKey != "") {
+					t.Fatalf("expected apiKey only in query, got query=%q body=%q", queryKey, bodyKey)
+				}
+				if !inQuery && (queryKey != "" || bodyKey != "test-key") {
+					t.Fatalf("expected apiKey only in body, got query=%q body=%q", queryKey, bodyKey)
+				}
+				if r.PostForm.Get("text") != "hello" || r.URL.Query().Get("text") != "" {
+					t.Fatalf("expected other params in body only, got query=%q", r.URL.RawQuery)
+				}
+				_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+			}))
+			defer ts.Close()
+
+			c := newTestClient(t, ts.URL)
+			c.apiKeyInQuery = inQuery
+			if err := c.Post(context.Background(), "/createTweet", map[string]string{"text": "hello"}, nil); err != nil {
+				t.Fatalf("Post error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRetryableBusinessCodes(t *testing.T) {
+	newServer := func(code int, hits *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(hits, 1) == 1 {
+				_, _ = w.Write([]byte(`{"code":` + strconv.Itoa(code) + `,"data":"","msg":"service busy"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+		}))
+	}
+	newClient := func(url string) *Client {
+		c, err := NewClient(&config.Config{
+			BaseURL:                url,
+			APIKey:                 "test-key",
+			Timeout:                5 * time.Second,
+			MaxRetries:             2,
+			RateLimit:              100,
+			RetryableBusinessCodes: []int{131},
+		}, WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		return c
+	}
+
+	t.Run("listed code is retried", func(t *testing.T) {
+		var hits int32
+		ts := newServer(131, &hits)
+		defer ts.Close()
+		var result map[string]any
+		if err := newClient(ts.URL).Get(context.Background(), "/busy", nil, &result); err != nil {
+			t.Fatalf("expected retry to succeed, got %v", err)
+		}
+		if got := atomic.LoadInt32(&hits); got != 2 {
+			t.Fatalf("expected 2 attempts, got %d", got)
+		}
+	})
+
+	t.Run("unlisted code fails fast", func(t *testing.T) {
+		var hits int32
+		ts := newServer(132, &hits)
+		defer ts.Close()
+		var result map[string]any
+		err := newClient(ts.URL).Get(context.Background(), "/busy", nil, &result)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != 132 {
+			t.Fatalf("expected APIError code 132, got %v", err)
+		}
+		if got := atomic.LoadInt32(&hits); got != 1 {
+			t.Fatalf("expected a single attempt, got %d", got)
+		}
+	})
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inflight, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(&config.Config{
+		BaseURL:               ts.URL,
+		APIKey:                "test-key",
+		Timeout:               5 * time.Second,
+		RateLimit:             1000,
+		MaxConcurrentRequests: 2,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result map[string]any
+			if err := c.Get(context.Background(), "/slow", nil, &result); err != nil {
+				t.Errorf("Get error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&peak); got != 2 {
+		t.Fatalf("expected at most 2 requests in flight (and the cap reached), got peak %d", got)
+	}
+}
+
+func TestDefaultParams(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		got = append(got, r.Form.Get("region")+"|"+r.Form.Get("apiVersion")+"|"+r.Form.Get("apiKey")+"|"+r.Form.Get("text"))
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	cfg := &config.Config{
+		BaseURL:       ts.URL,
+		APIKey:        "test-key",
+		MaxRetries:    2,
+		RateLimit:     100,
+		DefaultParams: map[string]string{"region": "us", "apiVersion": "1", "apiKey": "ignored"},
+	}
+	c, err := NewClient(cfg, WithDefaultParams(map[string]string{"apiVersion": "2"}))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+	if err := c.Get(ctx, "/tweetSimple", map[string]string{"tweetId": "1"}, nil); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if _, err := c.GetRaw(ctx, "/tweetSimple", map[string]string{"region": "eu", "apiKey": "nope"}); err != nil {
+		t.Fatalf("GetRaw error: %v", err)
+	}
+	if err := c.Post(ctx, "/createTweet", map[string]string{"text": "hi"}, nil); err != nil {
+		t.Fatalf("Post error: %v", err)
+	}
+
+	want := []string{"us|2|test-key|", "eu|2|test-key|", "us|2|test-key|hi"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected params: got %v, want %v", got, want)
+	}
+	if cfg.DefaultParams["apiVersion"] != "1" {
+		t.Fatal("WithDefaultParams must not modify Config.DefaultParams")
+	}
+}
+
+func TestDisableRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	cfg := &config.Config{
+		BaseURL:          ts.URL,
+		APIKey:           "test-key",
+		RateLimit:        1, // would take ~20s for 20 requests
+		DisableRateLimit: true,
+	}
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		if err := c.Get(context.Background(), "/tweetSimple", nil, nil); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected no limiter delay, took %v", elapsed)
+	}
+
+	cfg = &config.Config{APIKey: "test-key", DisableRateLimit: true}
+	if err := cfg.Validate(); err != nil || cfg.RateLimit != 0 {
+		t.Fatalf("Validate must keep RateLimit unset when disabled, got %v, %v", cfg.RateLimit, err)
+	}
+}
+
+func TestRateLimitResetThreshold(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-rate-limit-reset", r.URL.Query().Get("reset"))
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":0,"msg":"forbidden"}`))
+	}))
+	defer ts.Close()
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	c, err := NewClient(&config.Config{
+		BaseURL:                 ts.URL,
+		APIKey:                  "test-key",
+		RateLimit:               100,
+		RateLimitResetThreshold: 5,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	c.maxRetries = 0
+
+	for _, tc := range []struct {
+		reset string
+		stale bool
+	}{{"4", true}, {"5", false}, {"8", false}} {
+		logs.Reset()
+		_, err := c.GetRaw(context.Background(), "/userTweetsV2", map[string]string{"reset": tc.reset})
+		if got := errors.Is(err, ErrRobotTokenExpired); got != tc.stale {
+			t.Errorf("reset=%s: ErrRobotTokenExpired = %v, want %v (%v)", tc.reset, got, tc.stale, err)
+		}
+		if got := strings.Contains(logs.String(), "consider calling tokenSync"); got != tc.stale {
+			t.Errorf("reset=%s: tokenSync hint logged = %v, want %v", tc.reset, got, tc.stale)
+		}
+	}
+}
+
+func TestEmptyParamsAreDropped(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		var keys []string
+		for k := range r.Form {
+			keys = append(keys, k+"="+r.Form.Get(k))
+		}
+		sort.Strings(keys)
+		got = append(got, strings.Join(keys, "&"))
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	WithDefaultParams(map[string]string{"region": ""})(c)
+	params := map[string]string{"userId": "1", "cursor": "", "count": "0"}
+	if err := c.Get(context.Background(), "/userTweetsV2", params, nil); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if err := c.Post(context.Background(), "/createTweet", params, nil); err != nil {
+		t.Fatalf("Post error: %v", err)
+	}
+	for _, req := range got {
+		if req != "apiKey=test-key&count=0&userId=1" {
+			t.Fatalf("expected empty params to be omitted and count=0 kept, got %q", req)
+		}
+	}
+	if params["cursor"] != "" || len(params) != 3 {
+		t.Fatalf("caller's params were modified: %v", params)
+	}
+}
+
+func TestInvalidUTF8Response(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// "中" is e4 b8 ad; the transfer is cut off after its first two bytes.
+		_, _ = w.Write([]byte("{\"code\":1,\"data\":\"{\\\"text\\\":\\\"\xe4\xb8"))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	WithBackoff(ConstantBackoff{Delay: time.Millisecond})(c)
+	var out map[string]any
+	err := c.Get(context.Background(), "/tweetSimple", map[string]string{"tweetId": "1"}, &out)
+	if !errors.Is(err, ErrCorruptResponse) {
+		t.Fatalf("expected ErrCorruptResponse, got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected the corrupt response to be retried (3 calls), got %d", got)
+	}
+	if _, err := c.GetRaw(context.Background(), "/tweetSimple", nil); !errors.Is(err, ErrCorruptResponse) {
+		t.Fatalf("GetRaw: expected ErrCorruptResponse, got %v", err)
+	}
+
+	c.repairInvalidUTF8 = true
+	raw, err := c.GetRaw(context.Background(), "/tweetSimple", nil)
+	if err != nil || !utf8.Valid(raw) || !strings.HasSuffix(string(raw), "�") {
+		t.Fatalf("expected a repaired body, got %q, %v", raw, err)
+	}
+}
+
+func TestDecodeCompressedData(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(`{"hello":"gzip"}`))
+	_ = zw.Close()
+	data := map[string]string{
+		"gzip":    base64.StdEncoding.EncodeToString(gz.Bytes()),
+		"base64":  base64.RawURLEncoding.EncodeToString([]byte(`{"hello":"base64"}`)),
+		"plain":   `{"hello":"plain"}`,
+		"message": "rate limited",
+		"corrupt": base64.StdEncoding.EncodeToString(gz.Bytes()[:12]),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner, _ := json.Marshal(data[r.URL.Query().Get("case")])
+		_, _ = w.Write([]byte(`{"code":1,"data":` + string(inner) + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	get := func(name string) (map[string]string, error) {
+		var out map[string]string
+		err := c.Get(context.Background(), "/tweetSimple", map[string]string{"case": name}, &out)
+		return out, err
+	}
+
+	if _, err := get("gzip"); err == nil {
+		t.Fatal("compressed data must not be decoded unless enabled")
+	}
+	c.decodeCompressedData = true
+	for _, name := range []string{"gzip", "base64", "plain"} {
+		out, err := get(name)
+		if err != nil || out["hello"] != name {
+			t.Fatalf("%s: expected the data to unwrap, got %v, %v", name, out, err)
+		}
+	}
+	var apiErr *APIError
+	if _, err := get("message"); !errors.As(err, &apiErr) || apiErr.Message != "rate limited" {
+		t.Fatalf("expected a plain-text data string to stay an APIError, got %v", err)
+	}
+	if _, err := get("corrupt"); err == nil || !strings.Contains(err.Error(), "decode compressed data") {
+		t.Fatalf("expected a decode error for a truncated gzip stream, got %v", err)
+	}
+}
+
+func TestWithLimiterSharedAcrossClients(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	// 20 QPS with no burst beyond one request, shared by both clients, each
+	// of which would allow 100 QPS on its own.
+	shared := rate.NewLimiter(20, 1)
+	a, b := newTestClient(t, ts.URL), newTestClient(t, ts.URL)
+	WithLimiter(shared)(a)
+	WithLimiter(shared)(b)
+
+	const perClient = 5
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, c := range []*Client{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perClient {
+				if err := c.Get(context.Background(), "/tweetSimple", nil, nil); err != nil {
+					t.Errorf("request: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 10 requests at 20 QPS with a burst of 1 need at least 9 intervals of 50ms.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected the shared limiter to bound combined QPS, 10 requests took %v", elapsed)
+	}
+	if got := hits.Load(); got != 2*perClient {
+		t.Fatalf("expected %d requests, got %d", 2*perClient, got)
+	}
+}
+
+func TestTransportHTTP1AndKeepAlives(t *testing.T) {
+	transportOf := func(cfg *config.Config) *http.Transport {
+		t.Helper()
+		cfg.APIKey = "test-key"
+		c, err := NewClient(cfg)
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		tr, ok := c.httpClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("unexpected transport %T", c.httpClient.Transport)
+		}
+		return tr
+	}
+
+	tr := transportOf(&config.Config{})
+	if !tr.ForceAttemptHTTP2 || tr.TLSNextProto != nil || tr.DisableKeepAlives {
+		t.Fatalf("expected HTTP/2 and keep-alives by default, got ForceAttemptHTTP2=%v TLSNextProto=%v DisableKeepAlives=%v",
+			tr.ForceAttemptHTTP2, tr.TLSNextProto, tr.DisableKeepAlives)
+	}
+
+	tr = transportOf(&config.Config{ForceHTTP1: true, DisableKeepAlives: true})
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil || len(tr.TLSNextProto) != 0 || !tr.DisableKeepAlives {
+		t.Fatalf("expected HTTP/1.1 only without keep-alives, got ForceAttemptHTTP2=%v TLSNextProto=%v DisableKeepAlives=%v",
+			tr.ForceAttemptHTTP2, tr.TLSNextProto, tr.DisableKeepAlives)
+	}
+}
+
+func TestGetEnvelope(t *testing.T) {
+	bodies := map[string]string{
+		"ok":    `{"code":1,"data":"{\"user\":{\"id\":\"42\"}}","msg":"SUCCESS"}`,
+		"soft":  `{"code":404,"data":"user not found","msg":"FAILED"}`,
+		"blank": `{"code":1,"data":"  ","msg":"SUCCESS"}`,
+		"plain": `{"code":1,"data":{"n":1},"msg":"SUCCESS"}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(bodies[r.URL.Query().Get("case")]))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	get := func(name string) *Envelope {
+		t.Helper()
+		env, err := c.GetEnvelope(context.Background(), "/userByScreenNameV2", map[string]string{"case": name})
+		if err != nil {
+			t.Fatalf("%s: GetEnvelope error: %v", name, err)
+		}
+		return env
+	}
+
+	if env := get("ok"); env.Code != 1 || env.Msg != "SUCCESS" || string(env.Data) != `{"user":{"id":"42"}}` {
+		t.Fatalf("unexpected success envelope: code=%d msg=%q data=%s", env.Code, env.Msg, env.Data)
+	}
+	if env := get("soft"); env.Code != 404 || env.Msg != "FAILED" || string(env.Data) != `"user not found"` {
+		t.Fatalf("unexpected soft-error envelope: code=%d msg=%q data=%s", env.Code, env.Msg, env.Data)
+	}
+	if env := get("blank"); string(env.Data) != "null" {
+		t.Fatalf("expected null data for a blank string, got %s", env.Data)
+	}
+	if env := get("plain"); string(env.Data) != `{"n":1}` {
+		t.Fatalf("expected object data as is, got %s", env.Data)
+	}
+}
+
+func TestWithResultValidationRejectsEmptyRestID(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			_, _ = w.Write([]byte(`{"code":1,"data":"{\"rest_id\":\"\"}","msg":"SUCCESS"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":"{\"rest_id\":\"42\"}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	errEmptyRestID := errors.New("empty rest_id")
+	validate := func(retry bool) ResultValidator {
+		return func(path string, raw json.RawMessage) error {
+			if path != apiToolsBasePath+"/userByScreenNameV2" {
+				t.Errorf("unexpected validated path %q", path)
+			}
+			if gjson.GetBytes(raw, "rest_id").String() != "" {
+				return nil
+			}
+			if retry {
+				return fmt.Errorf("%w: %w", ErrRetryableResult, errEmptyRestID)
+			}
+			return errEmptyRestID
+		}
+	}
+
+	c := newTestClient(t, ts.URL)
+	WithBackoff(ConstantBackoff{Delay: time.Millisecond})(c)
+	WithResultValidation(validate(false))(c)
+	var out struct {
+		RestID string `json:"rest_id"`
+	}
+	err := c.Get(context.Background(), "/userByScreenNameV2", nil, &out)
+	var invalid *ErrInvalidResult
+	if !errors.As(err, &invalid) || !errors.Is(err, errEmptyRestID) || invalid.Path != apiToolsBasePath+"/userByScreenNameV2" {
+		t.Fatalf("expected ErrInvalidResult wrapping the validator error, got %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected a non-retryable rejection to make one request, got %d", got)
+	}
+
+	WithResultValidation(validate(true))(c)
+	if err := c.Get(context.Background(), "/userByScreenNameV2", nil, &out); err != nil {
+		t.Fatalf("expected success after retrying, got %v", err)
+	}
+	if out.RestID != "42" || hits.Load() != 3 {
+		t.Fatalf("expected rest_id 42 after 3 requests, got %q after %d", out.RestID, hits.Load())
+	}
+}
+
+func TestBuildRequest(t *testing.T) {
+	params := map[string]string{"tweetId": "1", "text": "a b&c", "empty": ""}
+	cases := []struct {
+		name          string
+		method        string
+		apiKeyInQuery bool
+		want          string
+	}{
+		{
+			name:   "GET",
+			method: http.MethodGet,
+			want: "GET /api/base/apitools/tweetSimple?apiKey=test-key&lang=en&text=a+b%26c&tweetId=1 HTTP/1.1\r\n" +
+				"Host: example.com\r\nAccept: application/json\r\n\r\n",
+		},
+		{
+			name:   "POST",
+			method: http.MethodPost,
+			want: "POST /api/base/apitools/tweetSimple HTTP/1.1\r\n" +
+				"Host: example.com\r\nAccept: application/json\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\n" +
+				"apiKey=test-key&lang=en&text=a+b%26c&tweetId=1",
+		},
+		{
+			name:          "POST apiKey in query",
+			method:        http.MethodPost,
+			apiKeyInQuery: true,
+			want: "POST /api/base/apitools/tweetSimple?apiKey=test-key HTTP/1.1\r\n" +
+				"Host: example.com\r\nAccept: application/json\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\n" +
+				"lang=en&text=a+b%26c&tweetId=1",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, "http://example.com")
+			WithDefaultParams(map[string]string{"lang": "en"})(c)
+			c.apiKeyInQuery = tc.apiKeyInQuery
+			req, err := c.buildRequest(context.Background(), tc.method, "/tweetSimple", params, c.apiKey)
+			if err != nil {
+				t.Fatalf("buildRequest error: %v", err)
+			}
+			dump, err := httputil.DumpRequest(req, true)
+			if err != nil {
+				t.Fatalf("dump request: %v", err)
+			}
+			if string(dump) != tc.want {
+				t.Fatalf("unexpected request:\n%q\nwant:\n%q", dump, tc.want)
+			}
+		})
+	}
+
+	c := newTestClient(t, "http://example.com")
+	if _, err := c.buildRequest(context.Background(), http.MethodDelete, "/tweetSimple", nil, c.apiKey); err == nil ||
+		!strings.Contains(err.Error(), "unsupported method") {
+		t.Fatalf("expected unsupported method error, got %v", err)
+	}
+}
+
+func TestWithParamSigner(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+	WithDefaultParams(map[string]string{"lang": "en"})(client)
+	WithParamSigner(func(method, path string, params map[string]string) map[string]string {
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := []string{method, path}
+		for _, k := range keys {
+			parts = append(parts, k+"="+params[k])
+		}
+		params["apiKey"] = "tampered" // the signer works on a copy
+		return map[string]string{"sign": strings.Join(parts, ","), "ts": "1700000000"}
+	})(client)
+
+	var out json.RawMessage
+	if err := client.Get(context.Background(), "/tweetSimple", map[string]string{"tweetId": "1", "cursor": ""}, &out); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	// The signature covers the merged params, without the dropped empty cursor.
+	want := "apiKey=test-key&lang=en&sign=GET%2C%2Fapi%2Fbase%2Fapitools%2FtweetSimple%2CapiKey%3Dtest-key%2Clang%3Den%2CtweetId%3D1&ts=1700000000&tweetId=1"
+	if query != want {
+		t.Fatalf("unexpected signed query: %v", query)
+	}
+}
+
+func TestChallengeRequiredIsNotRetried(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":[{"code":326,"message":"To protect our users from spam and other malicious activity, this account is temporarily locked. Please log in to https://twitter.com to unlock your account.","bounce_location":"https://twitter.com/account/access"}]}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+	client.authToken = "auth-token"
+
+	_, err := client.GetHomeTimeline(context.Background(), "")
+	if !errors.Is(err, ErrChallengeRequired) {
+		t.Fatalf("expected ErrChallengeRequired, got %v", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("expected a single attempt, got %d", n)
+	}
+}
+
+func TestSlowRequestThreshold(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") == "1" {
+			time.Sleep(60 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	c, err := NewClient(&config.Config{
+		BaseURL:              ts.URL,
+		APIKey:               "test-key",
+		RateLimit:            100,
+		SlowRequestThreshold: 30 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	if _, err := c.GetRaw(context.Background(), "/userTweetsV2", nil); err != nil {
+		t.Fatalf("GetRaw error: %v", err)
+	}
+	if strings.Contains(logs.String(), "slow request") {
+		t.Fatalf("unexpected slow-request warning for a fast request: %s", logs.String())
+	}
+
+	if _, err := c.GetRaw(context.Background(), "/userTweetsV2", map[string]string{"slow": "1"}); err != nil {
+		t.Fatalf("GetRaw error: %v", err)
+	}
+	out := logs.String()
+	if !strings.Contains(out, "WARNING: slow request GET /api/base/apitools/userTweetsV2 took") || !strings.Contains(out, "(threshold 30ms)") {
+		t.Fatalf("expected a slow-request warning, got %q", out)
+	}
+	if strings.Contains(out, "test-key") {
+		t.Fatalf("the warning must not leak params: %q", out)
+	}
+}
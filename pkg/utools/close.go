@@ -0,0 +1,93 @@
+package utools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrClientClosed is returned by calls made after Close, and by calls that
+// Close cancelled.
+var ErrClientClosed = errors.New("utools: client closed")
+
+// activeRequest is a call tracked for Close.
+type activeRequest struct {
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// trackRequest registers a call for Close, returning the context to run it
+// under and the func to call when it returns. It fails with ErrClientClosed
+// once Close has been called.
+func (c *Client) trackRequest(ctx context.Context) (context.Context, func(), error) {
+	c.activeMu.Lock()
+	defer c.activeMu.Unlock()
+	if c.closed {
+		return nil, nil, ErrClientClosed
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	r := &activeRequest{cancel: cancel, done: make(chan struct{})}
+	if c.active == nil {
+		c.active = make(map[*activeRequest]struct{})
+	}
+	c.active[r] = struct{}{}
+	return ctx, func() {
+		c.activeMu.Lock()
+		delete(c.active, r)
+		c.activeMu.Unlock()
+		close(r.done)
+		cancel(nil)
+	}, nil
+}
+
+// ActiveRequests returns the number of calls in flight, retries and
+// backoffs included.
+func (c *Client) ActiveRequests() int {
+	c.activeMu.Lock()
+	defer c.activeMu.Unlock()
+	return len(c.active)
+}
+
+// Close shuts the client down gracefully: new calls fail with
+// ErrClientClosed at once, calls in flight are given until ctx is done to
+// finish, and those still running then are cancelled, failing with
+// ErrClientClosed. It returns how many calls were cancelled, with ctx's
+// error when any were. Crawlers, iterators and batch helpers built on the
+// client stop with it. Calling Close again only cancels what is left.
+func (c *Client) Close(ctx context.Context) (int, error) {
+	c.activeMu.Lock()
+	c.closed = true
+	pending := make([]*activeRequest, 0, len(c.active))
+	for r := range c.active {
+		pending = append(pending, r)
+	}
+	c.activeMu.Unlock()
+
+wait:
+	for _, r := range pending {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			break wait
+		}
+	}
+
+	c.activeMu.Lock()
+	defer c.activeMu.Unlock()
+	for r := range c.active {
+		r.cancel(ErrClientClosed)
+	}
+	if n := len(c.active); n > 0 {
+		return n, ctx.Err()
+	}
+	return 0, nil
+}
+
+// closedError reports err as ErrClientClosed when ctx, a context returned
+// by trackRequest, was cancelled by Close.
+func closedError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), ErrClientClosed) && !errors.Is(err, ErrClientClosed) {
+		return fmt.Errorf("%w: %w", ErrClientClosed, err)
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package utools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCloseCancelsSlowRequests(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		delay := 5 * time.Second
+		if r.URL.Query().Get("fast") == "1" {
+			delay = 20 * time.Millisecond
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	start := func(params map[string]string) chan error {
+		errc := make(chan error, 1)
+		go func() {
+			_, err := client.GetRaw(context.Background(), "/tweetSimple", params)
+			errc <- err
+		}()
+		return errc
+	}
+	slow := []chan error{start(nil), start(nil)}
+	fast := start(map[string]string{"fast": "1"})
+	for deadline := time.Now().Add(2 * time.Second); hits.Load() < 3; {
+		if time.Now().After(deadline) {
+			t.Fatalf("requests did not start, %d in flight", client.ActiveRequests())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	began := time.Now()
+	n, err := client.Close(ctx)
+	if n != 2 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected 2 cancelled requests and a deadline error, got %d, %v", n, err)
+	}
+	if elapsed := time.Since(began); elapsed > 2*time.Second {
+		t.Fatalf("Close took %v", elapsed)
+	}
+
+	if err := <-fast; err != nil {
+		t.Fatalf("the fast request should finish within the deadline: %v", err)
+	}
+	for i, errc := range slow {
+		if err := <-errc; !errors.Is(err, ErrClientClosed) {
+			t.Fatalf("slow request %d: expected ErrClientClosed, got %v", i, err)
+		}
+	}
+	if client.ActiveRequests() != 0 {
+		t.Fatalf("expected no active requests, got %d", client.ActiveRequests())
+	}
+
+	before := hits.Load()
+	if _, err := client.GetRaw(context.Background(), "/tweetSimple", nil); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected calls after Close to fail with ErrClientClosed, got %v", err)
+	}
+	if hits.Load() != before {
+		t.Fatal("a call after Close reached the server")
+	}
+	if n, err := client.Close(context.Background()); n != 0 || err != nil {
+		t.Fatalf("expected a second Close to be a no-op, got %d, %v", n, err)
+	}
+}
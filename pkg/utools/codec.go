@@ -0,0 +1,56 @@
+package utools
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+// JSONDecoder decodes JSON. Its contract matches encoding/json.Unmarshal.
+type JSONDecoder interface {
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONEncoder encodes JSON. Its contract matches encoding/json.Marshal.
+type JSONEncoder interface {
+	Marshal(v any) ([]byte, error)
+}
+
+// stdJSON is the default codec, backed by encoding/json.
+type stdJSON struct{}
+
+func (stdJSON) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (stdJSON) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+
+type jsonCodec struct {
+	dec JSONDecoder
+	enc JSONEncoder
+}
+
+var codec atomic.Pointer[jsonCodec]
+
+func init() {
+	codec.Store(&jsonCodec{dec: stdJSON{}, enc: stdJSON{}})
+}
+
+// SetJSONCodec replaces the JSON decoder and encoder used package-wide for
+// envelope unwrapping, the typed parsers and archived records, e.g. to plug
+// in a faster json-iterator-compatible implementation. A nil argument
+// restores encoding/json for that direction. Replacements must be safe for
+// concurrent use; set them once at startup.
+func SetJSONCodec(dec JSONDecoder, enc JSONEncoder) {
+	if dec == nil {
+		dec = stdJSON{}
+	}
+	if enc == nil {
+		enc = stdJSON{}
+	}
+	codec.Store(&jsonCodec{dec: dec, enc: enc})
+}
+
+func unmarshalJSON(data []byte, v any) error {
+	return codec.Load().dec.Unmarshal(data, v)
+}
+
+func marshalJSON(v any) ([]byte, error) {
+	return codec.Load().enc.Marshal(v)
+}
@@ -0,0 +1,90 @@
+package utools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type recordingCodec struct {
+	decodes atomic.Int32
+	encodes atomic.Int32
+}
+
+func (c *recordingCodec) Unmarshal(data []byte, v any) error {
+	c.decodes.Add(1)
+	return json.Unmarshal(data, v)
+}
+
+func (c *recordingCodec) Marshal(v any) ([]byte, error) {
+	c.encodes.Add(1)
+	return json.Marshal(v)
+}
+
+func TestSetJSONCodecRoutesDecoding(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":"{\"users\":[{\"id_str\":\"1\",\"screen_name\":\"a\"}]}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	rec := &recordingCodec{}
+	SetJSONCodec(rec, rec)
+	t.Cleanup(func() { SetJSONCodec(nil, nil) })
+
+	client := newTestClient(t, ts.URL)
+	var raw json.RawMessage
+	if err := client.Get(context.Background(), "/followersV2", nil, &raw); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	afterEnvelope := rec.decodes.Load()
+	if afterEnvelope == 0 {
+		t.Fatal("envelope unwrapping did not go through the codec")
+	}
+
+	users, err := ParseUserList(raw)
+	if err != nil || len(users) != 1 || users[0].ScreenName != "a" {
+		t.Fatalf("unexpected parse result: %+v, %v", users, err)
+	}
+	if rec.decodes.Load() == afterEnvelope {
+		t.Fatal("typed parser did not go through the codec")
+	}
+}
+
+func TestSetJSONCodecNilRestoresDefault(t *testing.T) {
+	SetJSONCodec(&recordingCodec{}, nil)
+	SetJSONCodec(nil, nil)
+	if _, ok := codec.Load().dec.(stdJSON); !ok {
+		t.Fatalf("expected stdlib decoder after reset, got %T", codec.Load().dec)
+	}
+}
+
+func BenchmarkDecodeUserList(b *testing.B) {
+	raw := []byte(userPageFixture("c1", "1", "2", "3", "4", "5", "6", "7", "8"))
+	b.Run("encoding/json", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v map[string]any
+			if err := json.Unmarshal(raw, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("codec", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v map[string]any
+			if err := unmarshalJSON(raw, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ParseUserList", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseUserList(raw); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package utools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// CrawlEventType identifies the kind of a CrawlEvent.
+type CrawlEventType int
+
+const (
+	// CrawlUserStarted is sent before a user's first page is requested.
+	CrawlUserStarted CrawlEventType = iota
+	// CrawlPageFetched carries one page of a user's tweets.
+	CrawlPageFetched
+	// CrawlUserDone is sent when a user's pagination finished without error.
+	CrawlUserDone
+	// CrawlError is sent when a user's pagination failed; Err holds the cause.
+	// It ends that user's crawl, like CrawlUserDone.
+	CrawlError
+)
+
+func (t CrawlEventType) String() string {
+	switch t {
+	case CrawlUserStarted:
+		return "user_started"
+	case CrawlPageFetched:
+		return "page_fetched"
+	case CrawlUserDone:
+		return "user_done"
+	case CrawlError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// CrawlProgress is a snapshot of a crawl's combined progress.
+type CrawlProgress struct {
+	UsersTotal   int
+	UsersDone    int // finished without error
+	UsersFailed  int
+	PagesFetched int
+}
+
+// CrawlEvent reports a step of a crawl. Page, Data and NextCursor are set on
+// CrawlPageFetched, Err on CrawlError. Progress is the combined progress
+// right after the event.
+type CrawlEvent struct {
+	Type       CrawlEventType
+	UserID     string
+	Page       int
+	Data       json.RawMessage
+	NextCursor string
+	Err        error
+	Progress   CrawlProgress
+}
+
+// Crawler paginates many users concurrently through one Client, so every
+// request shares the client's rate limiter and retry policy.
+type Crawler struct {
+	client      *Client
+	concurrency int
+}
+
+// NewCrawler creates a Crawler running at most concurrency users at a time
+// (values below 1 mean 1).
+func NewCrawler(client *Client, concurrency int) *Crawler {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Crawler{client: client, concurrency: concurrency}
+}
+
+// CrawlUserTweets fetches up to maxPagesPer pages (0 = unlimited) of
+// /userTweetsV2 for each user and streams CrawlEvents. Every user gets a
+// CrawlUserStarted followed by its pages and one CrawlUserDone or CrawlError.
+// The channel is closed when all users are finished or ctx is cancelled; after
+// cancellation no further events are sent, so callers may stop reading.
+func (cr *Crawler) CrawlUserTweets(ctx context.Context, userIDs []string, maxPagesPer int) (<-chan CrawlEvent, error) {
+	if len(userIDs) == 0 {
+		return nil, errors.New("utools: crawl: no user IDs")
+	}
+
+	events := make(chan CrawlEvent, cr.concurrency)
+	agg := &crawlAggregator{total: len(userIDs)}
+	sem := make(chan struct{}, cr.concurrency)
+	var wg sync.WaitGroup
+
+	go func() {
+		defer close(events)
+		defer wg.Wait()
+		for _, id := range userIDs {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(userID string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				cr.crawlUser(ctx, userID, maxPagesPer, agg, events)
+			}(id)
+		}
+	}()
+	return events, nil
+}
+
+func (cr *Crawler) crawlUser(ctx context.Context, userID string, maxPages int, agg *crawlAggregator, events chan<- CrawlEvent) {
+	emit := func(ev CrawlEvent) bool {
+		ev.UserID = userID
+		ev.Progress = agg.snapshot()
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if !emit(CrawlEvent{Type: CrawlUserStarted}) {
+		return
+	}
+	it := cr.client.NewPageIterator("/userTweetsV2", map[string]string{
+		"userId": userID,
+	}, maxPages)
+	for it.HasMore() {
+		page, err := it.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			agg.failed.Add(1)
+			emit(CrawlEvent{Type: CrawlError, Page: it.PageCount() + 1, Err: err})
+			return
+		}
+		if page == nil {
+			break
+		}
+		agg.pages.Add(1)
+		if !emit(CrawlEvent{Type: CrawlPageFetched, Page: it.PageCount(), Data: page.RawData, NextCursor: page.NextCursor}) {
+			return
+		}
+	}
+	agg.done.Add(1)
+	emit(CrawlEvent{Type: CrawlUserDone, Page: it.PageCount()})
+}
+
+// crawlAggregator counts progress across a crawl's workers.
+type crawlAggregator struct {
+	total  int
+	done   atomic.Int64
+	failed atomic.Int64
+	pages  atomic.Int64
+}
+
+func (a *crawlAggregator) snapshot() CrawlProgress {
+	return CrawlProgress{
+		UsersTotal:   a.total,
+		UsersDone:    int(a.done.Load()),
+		UsersFailed:  int(a.failed.Load()),
+		PagesFetched: int(a.pages.Load()),
+	}
+}
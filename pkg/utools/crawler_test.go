@@ -0,0 +1,131 @@
+package utools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCrawlUserTweets(t *testing.T) {
+	pages := map[string]map[string]string{
+		"u1": {"": tweetPageFixture("c1", "11", "12"), "c1": tweetPageFixture("", "13")},
+		"u2": {"": tweetPageFixture("c1", "21"), "c1": tweetPageFixture("", "22")},
+	}
+	// Hold both first pages until each user has asked for one, so the two
+	// crawls are guaranteed to overlap.
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/userTweetsV2" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("cursor") == "" {
+			arrived.Done()
+			arrived.Wait()
+		}
+		body, ok := pages[q.Get("userId")][q.Get("cursor")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"msg":"bad request"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	crawler := NewCrawler(newTestClient(t, ts.URL), 2)
+	events, err := crawler.CrawlUserTweets(context.Background(), []string{"u1", "u2"}, 0)
+	if err != nil {
+		t.Fatalf("CrawlUserTweets error: %v", err)
+	}
+
+	var got []CrawlEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+
+	perUser := map[string][]CrawlEventType{}
+	firstDone := -1
+	started := 0
+	for i, ev := range got {
+		perUser[ev.UserID] = append(perUser[ev.UserID], ev.Type)
+		switch ev.Type {
+		case CrawlUserStarted:
+			if firstDone >= 0 {
+				t.Fatalf("user %s started after another finished; crawls did not interleave", ev.UserID)
+			}
+			started++
+		case CrawlUserDone:
+			if firstDone < 0 {
+				firstDone = i
+			}
+		case CrawlError:
+			t.Fatalf("unexpected error event for %s: %v", ev.UserID, ev.Err)
+		}
+	}
+	if started != 2 {
+		t.Fatalf("expected 2 started events, got %d", started)
+	}
+	want := []CrawlEventType{CrawlUserStarted, CrawlPageFetched, CrawlPageFetched, CrawlUserDone}
+	for _, id := range []string{"u1", "u2"} {
+		if len(perUser[id]) != len(want) {
+			t.Fatalf("user %s: expected events %v, got %v", id, want, perUser[id])
+		}
+		for i := range want {
+			if perUser[id][i] != want[i] {
+				t.Fatalf("user %s: expected events %v, got %v", id, want, perUser[id])
+			}
+		}
+	}
+
+	last := got[len(got)-1].Progress
+	if last.UsersTotal != 2 || last.UsersDone != 2 || last.UsersFailed != 0 || last.PagesFetched != 4 {
+		t.Fatalf("unexpected final progress: %+v", last)
+	}
+}
+
+func TestCrawlUserTweetsCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	crawler := NewCrawler(newTestClient(t, ts.URL), 1)
+	events, err := crawler.CrawlUserTweets(ctx, []string{"u1", "u2", "u3"}, 0)
+	if err != nil {
+		t.Fatalf("CrawlUserTweets error: %v", err)
+	}
+
+	if ev := <-events; ev.Type != CrawlUserStarted || ev.UserID != "u1" {
+		t.Fatalf("expected u1 started, got %+v", ev)
+	}
+	cancel()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.Type == CrawlError {
+				t.Fatalf("cancellation should not be reported as an error event: %v", ev.Err)
+			}
+		case <-timeout:
+			t.Fatal("event channel not closed after cancel")
+		}
+	}
+}
+
+func TestCrawlUserTweetsNoUsers(t *testing.T) {
+	crawler := NewCrawler(newTestClient(t, "http://127.0.0.1"), 0)
+	if _, err := crawler.CrawlUserTweets(context.Background(), nil, 0); err == nil {
+		t.Fatal("expected error for empty user list")
+	}
+}
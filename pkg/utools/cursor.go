@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/tidwall/gjson"
 )
@@ -30,24 +34,81 @@ type PageIterator struct {
 	hasMore    bool
 	pageCount  int
 	maxPages   int // 0 = unlimited
+
+	maxEmptyPages int // consecutive empty pages tolerated
+	emptyPages    int // current run of empty pages
+
+	// newerCursor is the Top cursor NextNewer fetches from, taken from the
+	// first page and advanced by each newer page.
+	newerCursor string
+
+	cursorParam string // request param carrying the cursor, "cursor" by default
+
+	pageDelay       time.Duration
+	pageDelayJitter time.Duration
+	jitter          func(n time.Duration) time.Duration        // rand.N; replaced in tests
+	sleep           func(context.Context, time.Duration) error // sleepContext; replaced in tests
+}
+
+// IteratorOption customizes a PageIterator.
+type IteratorOption func(*PageIterator)
+
+// WithMaxEmptyPages lets the iterator follow up to n consecutive pages that
+// carry a new cursor but no items before it stops, overriding
+// Config.MaxEmptyPages. Sparse timelines (media, articles) sometimes return
+// such pages ahead of more data. n <= 0 stops on the first empty page.
+func WithMaxEmptyPages(n int) IteratorOption {
+	return func(it *PageIterator) {
+		it.maxEmptyPages = max(n, 0)
+	}
+}
+
+// WithCursorParam makes the iterator send the cursor as the name param
+// instead of "cursor", for endpoints that expect another name. An empty
+// name keeps "cursor".
+func WithCursorParam(name string) IteratorOption {
+	return func(it *PageIterator) {
+		if name != "" {
+			it.cursorParam = name
+		}
+	}
+}
+
+// WithPageDelay makes Next wait delay plus a random extra of up to jitter
+// before each page after the first, so back-to-back page requests look less
+// like a bot. The wait comes on top of the client's rate limiter and ends
+// early, with the context error, when ctx is done.
+func WithPageDelay(delay, jitter time.Duration) IteratorOption {
+	return func(it *PageIterator) {
+		it.pageDelay = max(delay, 0)
+		it.pageDelayJitter = max(jitter, 0)
+	}
 }
 
 // NewPageIterator creates a new PageIterator for the given API path.
 // maxPages controls the maximum number of pages to fetch (0 = unlimited).
-func (c *Client) NewPageIterator(path string, params map[string]string, maxPages int) *PageIterator {
+func (c *Client) NewPageIterator(path string, params map[string]string, maxPages int, opts ...IteratorOption) *PageIterator {
 	// Copy params to avoid mutation
 	copied := make(map[string]string, len(params))
 	for k, v := range params {
 		copied[k] = v
 	}
 
-	return &PageIterator{
-		client:     c,
-		path:       path,
-		baseParams: copied,
-		hasMore:    true,
-		maxPages:   maxPages,
+	it := &PageIterator{
+		client:        c,
+		path:          path,
+		baseParams:    copied,
+		hasMore:       true,
+		maxPages:      maxPages,
+		maxEmptyPages: c.maxEmptyPages,
+		cursorParam:   "cursor",
+		jitter:        rand.N[time.Duration],
+		sleep:         sleepContext,
 	}
+	for _, opt := range opts {
+		opt(it)
+	}
+	return it
 }
 
 // HasMore returns true if there are more pages to fetch.
@@ -63,7 +124,13 @@ func (it *PageIterator) PageCount() int {
 // Next fetches the next page of results.
 // Returns the PageResult and an error. When no more pages are available,
 // PageResult will be nil and error will be nil.
+//
+// A cancelled or expired ctx is reported before any request is made; the
+// iterator keeps its cursor, so it can be resumed with a fresh context.
 func (it *PageIterator) Next(ctx context.Context) (*PageResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("page iterator: %w", err)
+	}
 	if !it.hasMore {
 		return nil, nil
 	}
@@ -73,39 +140,164 @@ func (it *PageIterator) Next(ctx context.Context) (*PageResult, error) {
 		return nil, nil
 	}
 
-	// Build params for this request
+	if it.pageCount > 0 {
+		if err := it.waitPageDelay(ctx); err != nil {
+			return nil, fmt.Errorf("page iterator: %w", err)
+		}
+	}
+
+	result, err := it.fetch(ctx, it.nextCursor)
+	if err != nil {
+		return nil, err
+	}
+	raw, nextCursor := result.RawData, result.NextCursor
+
+	it.pageCount++
+	if it.pageCount == 1 {
+		it.newerCursor = result.PreviousCursor
+	}
+
+	if nextCursor == "" || nextCursor == it.nextCursor {
+		it.hasMore = false
+	} else {
+		it.nextCursor = nextCursor
+	}
+
+	// Stop after too many consecutive pages without items, even if they
+	// keep handing out fresh cursors.
+	if n, ok := pageItemCount(raw); ok && n == 0 {
+		it.emptyPages++
+		if it.emptyPages > it.maxEmptyPages {
+			it.hasMore = false
+		}
+	} else {
+		it.emptyPages = 0
+	}
+
+	return result, nil
+}
+
+// waitPageDelay sleeps for the delay set by WithPageDelay, if any.
+func (it *PageIterator) waitPageDelay(ctx context.Context) error {
+	d := it.pageDelay
+	if it.pageDelayJitter > 0 {
+		d += it.jitter(it.pageDelayJitter + 1)
+	}
+	if d <= 0 {
+		return nil
+	}
+	return it.sleep(ctx, d)
+}
+
+// sleepContext waits for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// NextNewer fetches the items newer than everything seen so far, using the
+// Top (previous) cursor of the first page and then of each newer page, for
+// "refresh" polling loops on live timelines. It returns nil, nil when there
+// is no Top cursor to follow: no page has been fetched with Next yet, or the
+// endpoint does not hand one out. A newer page without a Top cursor of its
+// own keeps the current one, so polling can simply call NextNewer again.
+// Newer pages do not count towards PageCount or maxPages.
+func (it *PageIterator) NextNewer(ctx context.Context) (*PageResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("page iterator: %w", err)
+	}
+	if it.newerCursor == "" {
+		return nil, nil
+	}
+	result, err := it.fetch(ctx, it.newerCursor)
+	if err != nil {
+		return nil, err
+	}
+	if result.PreviousCursor != "" {
+		it.newerCursor = result.PreviousCursor
+	}
+	return result, nil
+}
+
+// fetch requests the page at cursor (the first page when empty) and extracts
+// its cursors.
+func (it *PageIterator) fetch(ctx context.Context, cursor string) (*PageResult, error) {
 	params := make(map[string]string, len(it.baseParams)+1)
 	for k, v := range it.baseParams {
 		params[k] = v
 	}
-	if it.nextCursor != "" {
-		params["cursor"] = it.nextCursor
+	if cursor != "" {
+		params[it.cursorParam] = cursor
 	}
 
-	// Execute request
 	var raw json.RawMessage
 	if err := it.client.Get(ctx, it.path, params, &raw); err != nil {
 		return nil, fmt.Errorf("page iterator: %w", err)
 	}
 
-	it.pageCount++
-
-	// Extract cursors from response
 	result := &PageResult{
 		RawData: raw,
 	}
+	result.NextCursor, result.PreviousCursor = extractCursors(string(raw))
+	return result, nil
+}
 
-	nextCursor, prevCursor := extractCursors(string(raw))
-	result.NextCursor = nextCursor
-	result.PreviousCursor = prevCursor
+// pageItemListPaths are the legacy REST list fields counted by pageItemCount.
+var pageItemListPaths = []string{"users", "ids", "statuses", "tweets"}
 
-	if nextCursor == "" || nextCursor == it.nextCursor {
-		it.hasMore = false
-	} else {
-		it.nextCursor = nextCursor
+// pageItemCount counts the items of a page: the non-cursor timeline entries,
+// or else the elements of a legacy list (users / ids / statuses / tweets, or
+// a bare array). ok is false when the page has neither shape, in which case
+// its emptiness cannot be judged.
+func pageItemCount(raw json.RawMessage) (n int, ok bool) {
+	root := gjson.ParseBytes(raw)
+	walkEntries(root, 0, func(entry gjson.Result) {
+		ok = true
+		if entry.Get("content.cursorType").Exists() || entry.Get("cursorType").Exists() ||
+			strings.HasPrefix(entry.Get("entryId").String(), "cursor-") {
+			return
+		}
+		n++
+	})
+	if ok {
+		return n, true
 	}
 
-	return result, nil
+	list := root
+	for _, path := range pageItemListPaths {
+		if list.IsArray() {
+			break
+		}
+		list = root.Get(path)
+	}
+	if !list.IsArray() {
+		return 0, false
+	}
+	return len(list.Array()), true
+}
+
+// walkEntries calls fn for every element of every "entries" array in value.
+func walkEntries(value gjson.Result, depth int, fn func(gjson.Result)) {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
+		return
+	}
+	value.ForEach(func(k, child gjson.Result) bool {
+		if value.IsObject() && k.String() == "entries" && child.IsArray() {
+			child.ForEach(func(_, entry gjson.Result) bool {
+				fn(entry)
+				return true
+			})
+			return true
+		}
+		walkEntries(child, depth+1, fn)
+		return true
+	})
 }
 
 // extractCursors extracts the bottom (next) and top (previous) cursor values
@@ -142,37 +334,46 @@ func extractCursors(jsonStr string) (next string, prev string) {
 
 	// Strategy 2: Direct cursor fields (some endpoints)
 	if next == "" {
-		next = gjson.Get(jsonStr, "cursor_bottom").String()
-		if next == "" {
-			next = gjson.Get(jsonStr, "next_cursor").String()
-		}
-		if next == "" {
-			next = gjson.Get(jsonStr, "next_cursor_str").String()
-		}
+		next = directCursor(jsonStr, "cursor_bottom", "next_cursor_str", "next_cursor")
 	}
 	if prev == "" {
-		prev = gjson.Get(jsonStr, "cursor_top").String()
-		if prev == "" {
-			prev = gjson.Get(jsonStr, "previous_cursor").String()
-		}
-		if prev == "" {
-			prev = gjson.Get(jsonStr, "previous_cursor_str").String()
-		}
+		prev = directCursor(jsonStr, "cursor_top", "previous_cursor_str", "previous_cursor")
 	}
 
 	// Strategy 3: Deep search for cursor objects
 	if next == "" {
 		gjson.Parse(jsonStr).ForEach(func(key, value gjson.Result) bool {
-			return findCursorDeep(value, &next, &prev)
+			return findCursorDeep(value, 1, &next, &prev)
 		})
 	}
 
 	return next, prev
 }
 
-// findCursorDeep recursively searches for cursor values in nested JSON.
-func findCursorDeep(value gjson.Result, next, prev *string) bool {
-	if !value.IsObject() && !value.IsArray() {
+// directCursor returns the first of keys set at the top level of jsonStr.
+// Legacy REST endpoints (favoritesList, followersIds, ...) send numeric
+// cursors, both as numbers and as *_str strings, with 0 marking the end, so
+// the string form is preferred and "0" means no cursor.
+func directCursor(jsonStr string, keys ...string) string {
+	for _, k := range keys {
+		if v := gjson.Get(jsonStr, k).String(); v != "" {
+			if v == "0" {
+				return ""
+			}
+			return v
+		}
+	}
+	return ""
+}
+
+// findCursorDeep searches value depth-first for cursor objects
+// ({"cursorType": "Bottom" | "Top", "value": ...}), keeping the first value
+// found for each. It reports whether the search should go on, i.e. false as
+// soon as both cursors are known, which stops the enclosing ForEach loops.
+// Nesting below maxParseDepth is not searched, so adversarial payloads
+// cannot exhaust the stack.
+func findCursorDeep(value gjson.Result, depth int, next, prev *string) bool {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
 		return true
 	}
 
@@ -188,13 +389,152 @@ func findCursorDeep(value gjson.Result, next, prev *string) bool {
 	}
 
 	value.ForEach(func(_, child gjson.Result) bool {
-		return findCursorDeep(child, next, prev)
+		return findCursorDeep(child, depth+1, next, prev)
 	})
 
 	return *next == "" || *prev == ""
 }
 
+// CollectMeta describes how a typed *All helper gathered its result. It is
+// returned by the ...WithMeta variants (GetListTimelineAllWithMeta,
+// GetRetweetersAllWithMeta, ...), so an empty result can be told apart from
+// a page that came back in a shape the parser did not recognize.
+type CollectMeta struct {
+	// Pages is the number of pages fetched.
+	Pages int
+
+	// LastCursor is the next cursor of the last page fetched, from which a
+	// later call can resume. Empty once the end of the list was reached.
+	LastCursor string
+
+	// Items is the number of items the helper returned.
+	Items int
+
+	// MalformedPages counts the pages that had neither timeline entries nor
+	// a legacy list (users / statuses / tweets), typically an error payload
+	// or a changed response shape, and so yielded nothing.
+	MalformedPages int
+}
+
+// WellFormedEmpty reports whether the helper returned nothing because there
+// is nothing: pages were fetched and each was a recognized, empty list.
+func (m *CollectMeta) WellFormedEmpty() bool {
+	return m.Pages > 0 && m.Items == 0 && m.MalformedPages == 0
+}
+
+// observePage records a fetched page.
+func (m *CollectMeta) observePage(page *PageResult) {
+	m.Pages++
+	m.LastCursor = page.NextCursor
+	if _, ok := pageItemCount(page.RawData); !ok {
+		m.MalformedPages++
+	}
+}
+
+// collectUsers drains it, parsing each page with ParseUserList and dropping
+// users already seen (by rest_id). Order follows the responses, each user at
+// its first position. On error, the users collected so far are returned
+// together with the error. meta describes the pages fetched either way.
+func collectUsers(ctx context.Context, it *PageIterator) (users []UserResult, meta CollectMeta, err error) {
+	users = []UserResult{}
+	seen := make(map[string]struct{})
+	defer func() { meta.Items = len(users) }()
+	for it.HasMore() {
+		page, err := it.Next(ctx)
+		if err != nil {
+			return users, meta, err
+		}
+		if page == nil {
+			break
+		}
+		meta.observePage(page)
+		parsed, err := ParseUserList(page.RawData)
+		if err != nil {
+			return users, meta, fmt.Errorf("page %d: %w", it.PageCount(), err)
+		}
+		for _, u := range parsed {
+			key := u.RestID
+			if key == "" {
+				key = "@" + strings.ToLower(u.ScreenName)
+			}
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			users = append(users, u)
+		}
+	}
+	return users, meta, nil
+}
+
+// collectTweets drains it, parsing each page with ParseTweetTimeline and
+// de-duplicating tweets by ID. A tweet keeps the position it was first seen
+// at, but takes the engagement counts of its latest copy, since pages
+// fetched later carry fresher metrics. On error, the tweets collected so
+// far are returned together with the error. meta describes the pages
+// fetched either way.
+func collectTweets(ctx context.Context, it *PageIterator) (tweets []TweetResult, meta CollectMeta, err error) {
+	tweets = []TweetResult{}
+	seen := make(map[string]int)
+	defer func() { meta.Items = len(tweets) }()
+	for it.HasMore() {
+		page, err := it.Next(ctx)
+		if err != nil {
+			return tweets, meta, err
+		}
+		if page == nil {
+			break
+		}
+		meta.observePage(page)
+		parsed, err := ParseTweetTimeline(page.RawData)
+		if err != nil {
+			return tweets, meta, fmt.Errorf("page %d: %w", it.PageCount(), err)
+		}
+		for _, t := range parsed {
+			if i, dup := seen[t.ID]; dup {
+				tweets[i].updateMetrics(t)
+				continue
+			}
+			seen[t.ID] = len(tweets)
+			tweets = append(tweets, t)
+		}
+	}
+	return tweets, meta, nil
+}
+
+// updateMetrics replaces t's engagement counts with those of newer, a later
+// copy of the same tweet. A copy without a full legacy node (no created_at),
+// such as a partial or limited result, reads as zero for counts it does not
+// carry, so only its non-zero counts are taken.
+func (t *TweetResult) updateMetrics(newer TweetResult) {
+	full := newer.CreatedAt != ""
+	for _, c := range []struct{ dst, src *int }{
+		{&t.RetweetCount, &newer.RetweetCount},
+		{&t.FavoriteCount, &newer.FavoriteCount},
+		{&t.ReplyCount, &newer.ReplyCount},
+		{&t.QuoteCount, &newer.QuoteCount},
+		{&t.BookmarkCount, &newer.BookmarkCount},
+	} {
+		if full || *c.src != 0 {
+			*c.dst = *c.src
+		}
+	}
+	if newer.ViewCount != "" {
+		t.ViewCount = newer.ViewCount
+	}
+}
+
+// sortTweetsChronologically orders tweets oldest first. Snowflake IDs grow
+// with time, so ID order is creation order.
+func sortTweetsChronologically(tweets []TweetResult) {
+	sort.SliceStable(tweets, func(i, j int) bool {
+		return compareIDs(tweets[i].ID, tweets[j].ID) < 0
+	})
+}
+
 // CollectAll is a convenience method that fetches all pages and collects raw results.
+// It stops as soon as ctx is cancelled, returning the pages fetched so far
+// together with the context error.
 func (it *PageIterator) CollectAll(ctx context.Context) ([]json.RawMessage, error) {
 	var pages []json.RawMessage
 	for it.HasMore() {
@@ -1,6 +1,17 @@
 package utools
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
 
 func TestExtractCursorsFromDirectFields(t *testing.T) {
 	jsonStr := `{"next_cursor":"next-123","previous_cursor":"prev-456"}`
@@ -30,3 +41,410 @@ func TestExtractCursorsFromTimelineEntries(t *testing.T) {
 		t.Fatalf("expected top cursor as previous, got %q", prev)
 	}
 }
+
+func TestExtractCursorsDeepNesting(t *testing.T) {
+	nest := func(depth int, inner string) string {
+		return strings.Repeat(`{"a":[`, depth) + inner + strings.Repeat(`]}`, depth)
+	}
+	cursors := `{"cursorType":"Bottom","value":"deep-bottom"},{"cursorType":"Top","value":"deep-top"}`
+
+	next, prev := extractCursors(nest(20, cursors))
+	if next != "deep-bottom" || prev != "deep-top" {
+		t.Fatalf("expected cursors at moderate depth, got %q / %q", next, prev)
+	}
+
+	// Far beyond maxParseDepth: must neither panic nor find anything.
+	next, prev = extractCursors(nest(100000, cursors))
+	if next != "" || prev != "" {
+		t.Fatalf("expected no cursors below the depth limit, got %q / %q", next, prev)
+	}
+}
+
+func TestFindCursorDeepStopsOnceBothFound(t *testing.T) {
+	first := gjson.Parse(`{"x":[{"cursorType":"Top","value":"top-1"},{"cursorType":"Bottom","value":"bottom-1"}]}`)
+	var next, prev string
+	if findCursorDeep(first, 1, &next, &prev) {
+		t.Fatal("expected the search to stop once both cursors are found")
+	}
+
+	jsonStr := `{"first":` + first.Raw + `,"second":{"cursorType":"Bottom","value":"bottom-2"}}`
+	next, prev = extractCursors(jsonStr)
+	if next != "bottom-1" || prev != "top-1" {
+		t.Fatalf("expected the first cursors to win, got %q / %q", next, prev)
+	}
+}
+
+func TestPageIteratorMaxEmptyPages(t *testing.T) {
+	pages := map[string]string{
+		"":   tweetPageFixture("c1", "1", "2"),
+		"c1": tweetPageFixture("c2"), // cursor only, no tweets
+		"c2": tweetPageFixture("", "3"),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[r.URL.Query().Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	count := func(it *PageIterator) int {
+		t.Helper()
+		all, err := it.CollectAll(context.Background())
+		if err != nil {
+			t.Fatalf("CollectAll error: %v", err)
+		}
+		return len(all)
+	}
+
+	if got := count(client.NewPageIterator("/listLatestTweetsTimeline", nil, 0)); got != 2 {
+		t.Fatalf("default: expected to stop on the empty page (2 pages), got %d", got)
+	}
+	if got := count(client.NewPageIterator("/listLatestTweetsTimeline", nil, 0, WithMaxEmptyPages(1))); got != 3 {
+		t.Fatalf("WithMaxEmptyPages(1): expected to reach the page after the empty one (3 pages), got %d", got)
+	}
+	client.maxEmptyPages = 1 // as set by Config.MaxEmptyPages
+	if got := count(client.NewPageIterator("/listLatestTweetsTimeline", nil, 0)); got != 3 {
+		t.Fatalf("Config.MaxEmptyPages=1: expected 3 pages, got %d", got)
+	}
+}
+
+func TestPageIteratorPageDelay(t *testing.T) {
+	pages := map[string]string{
+		"":   tweetPageFixture("c1", "1"),
+		"c1": tweetPageFixture("c2", "2"),
+		"c2": tweetPageFixture("", "3"),
+	}
+	var events []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events = append(events, "page "+r.URL.Query().Get("cursor"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[r.URL.Query().Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	it := client.NewPageIterator("/listLatestTweetsTimeline", nil, 0, WithPageDelay(time.Second, 500*time.Millisecond))
+	jitters := []time.Duration{100 * time.Millisecond, 400 * time.Millisecond}
+	it.jitter = func(n time.Duration) time.Duration {
+		if n != 500*time.Millisecond+1 {
+			t.Fatalf("expected jitter drawn from [0, 500ms], got bound %v", n)
+		}
+		d := jitters[0]
+		jitters = jitters[1:]
+		return d
+	}
+	it.sleep = func(_ context.Context, d time.Duration) error {
+		events = append(events, "sleep "+d.String())
+		return nil
+	}
+	if _, err := it.CollectAll(context.Background()); err != nil {
+		t.Fatalf("CollectAll error: %v", err)
+	}
+	want := "page ,sleep 1.1s,page c1,sleep 1.4s,page c2"
+	if got := strings.Join(events, ","); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	// A cancelled context ends the wait without fetching the next page.
+	events = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	it = client.NewPageIterator("/listLatestTweetsTimeline", nil, 0, WithPageDelay(time.Hour, 0))
+	if _, err := it.Next(ctx); err != nil {
+		t.Fatalf("first page error: %v", err)
+	}
+	go cancel()
+	if _, err := it.Next(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the delay to end with context.Canceled, got %v", err)
+	}
+	if got := strings.Join(events, ","); got != "page " {
+		t.Fatalf("expected only the first page to be fetched, got %q", got)
+	}
+}
+
+func TestExtractCursorsLegacyNumeric(t *testing.T) {
+	cases := []struct {
+		raw, next, prev string
+	}{
+		// The number and string forms agree; the string is authoritative.
+		{`{"ids":[1],"next_cursor":1590000000000000001,"next_cursor_str":"1590000000000000001","previous_cursor":-1,"previous_cursor_str":"-1"}`, "1590000000000000001", "-1"},
+		{`{"users":[],"next_cursor":1590000000000000001,"previous_cursor":0}`, "1590000000000000001", ""},
+		// 0 marks the last page.
+		{`{"ids":[3],"next_cursor":0,"next_cursor_str":"0","previous_cursor_str":"-1590000000000000001"}`, "", "-1590000000000000001"},
+	}
+	for i, tc := range cases {
+		next, prev := extractCursors(tc.raw)
+		if next != tc.next || prev != tc.prev {
+			t.Fatalf("case %d: expected (%q, %q), got (%q, %q)", i, tc.next, tc.prev, next, prev)
+		}
+	}
+}
+
+func TestPageIteratorLegacyCursors(t *testing.T) {
+	pages := map[string]string{
+		"":                    `{"ids":[1,2],"next_cursor":1590000000000000001,"next_cursor_str":"1590000000000000001","previous_cursor_str":"0"}`,
+		"1590000000000000001": `{"ids":[3,4],"next_cursor":1580000000000000002,"next_cursor_str":"1580000000000000002","previous_cursor_str":"-1590000000000000001"}`,
+		"1580000000000000002": `{"ids":[5],"next_cursor":0,"next_cursor_str":"0","previous_cursor_str":"-1580000000000000002"}`,
+	}
+	for _, param := range []string{"cursor", "next_cursor"} {
+		t.Run(param, func(t *testing.T) {
+			var seen []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if other := map[string]string{"cursor": "next_cursor", "next_cursor": "cursor"}[param]; q.Has(other) {
+					t.Errorf("unexpected %s param in %s", other, r.URL)
+				}
+				seen = append(seen, q.Get(param))
+				body, ok := pages[q.Get(param)]
+				if !ok {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+			}))
+			defer ts.Close()
+
+			client := newTestClient(t, ts.URL)
+			var opts []IteratorOption
+			if param != "cursor" {
+				opts = append(opts, WithCursorParam(param))
+			}
+			it := client.NewPageIterator("/followersIds", map[string]string{"userId": "12"}, 0, opts...)
+			all, err := it.CollectAll(context.Background())
+			if err != nil {
+				t.Fatalf("CollectAll error: %v", err)
+			}
+			if len(all) != 3 || it.HasMore() {
+				t.Fatalf("expected 3 pages ending on next_cursor 0, got %d (has more: %v)", len(all), it.HasMore())
+			}
+			if got := strings.Join(seen, ","); got != ",1590000000000000001,1580000000000000002" {
+				t.Fatalf("unexpected cursor sequence: %s", got)
+			}
+		})
+	}
+}
+
+func TestPageItemCount(t *testing.T) {
+	cases := []struct {
+		raw   string
+		n     int
+		known bool
+	}{
+		{raw: tweetPageFixture("c1", "1", "2"), n: 2, known: true},
+		{raw: tweetPageFixture("c1"), n: 0, known: true},
+		{raw: `{"users":[],"next_cursor_str":"5"}`, n: 0, known: true},
+		{raw: `{"ids":[1,2,3]}`, n: 3, known: true},
+		{raw: `{"result":{"ok":true}}`, known: false},
+	}
+	for i, tc := range cases {
+		n, known := pageItemCount([]byte(tc.raw))
+		if n != tc.n || known != tc.known {
+			t.Fatalf("case %d: expected (%d, %v), got (%d, %v)", i, tc.n, tc.known, n, known)
+		}
+	}
+}
+
+func TestPageIteratorNextCancelledBetweenPages(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + tweetPageFixture("c1", "1") + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := client.NewPageIterator("/listLatestTweetsTimeline", nil, 0)
+	if _, err := it.Next(ctx); err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	cancel()
+
+	page, err := it.Next(ctx)
+	if !errors.Is(err, context.Canceled) || page != nil {
+		t.Fatalf("expected context.Canceled and no page, got %v, %v", page, err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected no request after cancellation, got %d requests", requests)
+	}
+	if !it.HasMore() || it.PageCount() != 1 {
+		t.Fatalf("cancellation must not end the iteration: hasMore=%v pages=%d", it.HasMore(), it.PageCount())
+	}
+
+	pages, err := it.CollectAll(ctx)
+	if !errors.Is(err, context.Canceled) || len(pages) != 0 || requests != 1 {
+		t.Fatalf("CollectAll on a cancelled context: pages=%d err=%v requests=%d", len(pages), err, requests)
+	}
+}
+
+func TestPageIteratorNextNewer(t *testing.T) {
+	page := func(top, bottom string, ids ...string) string {
+		var entries []string
+		for _, id := range ids {
+			entries = append(entries, `{"entryId":"tweet-`+id+`","content":{"itemContent":{"tweet_results":{"result":{"rest_id":"`+id+`","legacy":{"full_text":"t`+id+`"}}}}}}`)
+		}
+		if top != "" {
+			entries = append(entries, `{"entryId":"cursor-top","content":{"cursorType":"Top","value":"`+top+`"}}`)
+		}
+		if bottom != "" {
+			entries = append(entries, `{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"`+bottom+`"}}`)
+		}
+		return `{"timeline":{"instructions":[{"entries":[` + strings.Join(entries, ",") + `]}]}}`
+	}
+	pages := map[string]string{
+		"":   page("t1", "b1", "20", "19"),
+		"b1": page("", "", "18"),
+		"t1": page("t2", "", "21"),
+		"t2": page("", "", "22"), // no Top cursor: keep polling from t2
+	}
+	var cursors []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursors = append(cursors, r.URL.Query().Get("cursor"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[r.URL.Query().Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+	ctx := context.Background()
+
+	it := client.NewPageIterator("/listLatestTweetsTimeline", nil, 0)
+	if p, err := it.NextNewer(ctx); p != nil || err != nil || len(cursors) != 0 {
+		t.Fatalf("expected nil before the first page, got %v, %v", p, err)
+	}
+	if _, err := it.CollectAll(ctx); err != nil {
+		t.Fatalf("CollectAll error: %v", err)
+	}
+
+	var newer []string
+	for i := 0; i < 3; i++ {
+		p, err := it.NextNewer(ctx)
+		if err != nil || p == nil {
+			t.Fatalf("NextNewer %d: %v, %v", i, p, err)
+		}
+		tweets, _ := ParseTweetTimeline(p.RawData)
+		newer = append(newer, tweetIDs(tweets))
+	}
+	if got := strings.Join(newer, " "); got != "21 22 22" {
+		t.Fatalf("expected newer tweets 21, 22, 22, got %s", got)
+	}
+	if got := strings.Join(cursors, ","); got != ",b1,t1,t2,t2" {
+		t.Fatalf("unexpected cursor sequence %q", got)
+	}
+	if it.PageCount() != 2 {
+		t.Fatalf("newer pages must not count towards PageCount, got %d", it.PageCount())
+	}
+
+	// An endpoint without Top cursors has nothing newer to offer.
+	pages[""] = page("", "", "20")
+	it = client.NewPageIterator("/listLatestTweetsTimeline", nil, 0)
+	if _, err := it.Next(ctx); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if p, err := it.NextNewer(ctx); p != nil || err != nil {
+		t.Fatalf("expected nil without a Top cursor, got %v, %v", p, err)
+	}
+}
+
+func TestGetListTimelineAllWithMeta(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		body          string
+		wantMalformed int
+		wantEmpty     bool
+	}{
+		// A timeline holding nothing but its cursor entry.
+		{"empty", tweetPageFixture("end"), 0, true},
+		// An error payload without any list in it.
+		{"malformed", `{"data":{"list":{}},"errors":[{"message":"Internal error"}]}`, 1, false},
+	} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"code":1,"data":` + tc.body + `,"msg":"SUCCESS"}`))
+		}))
+		client := newTestClient(t, ts.URL)
+
+		tweets, meta, err := client.GetListTimelineAllWithMeta(context.Background(), "L1", 0)
+		ts.Close()
+		if err != nil || len(tweets) != 0 {
+			t.Fatalf("%s: expected no tweets and no error, got %d, %v", tc.name, len(tweets), err)
+		}
+		if meta.Pages != 1 || meta.Items != 0 || meta.MalformedPages != tc.wantMalformed || meta.WellFormedEmpty() != tc.wantEmpty {
+			t.Fatalf("%s: unexpected meta: %+v (well-formed empty %v)", tc.name, meta, meta.WellFormedEmpty())
+		}
+	}
+}
+
+func TestGetListTimelineAllWithMeta_PagesAndCursor(t *testing.T) {
+	pages := map[string]string{
+		"":   tweetPageFixture("c1", "3", "2"),
+		"c1": tweetPageFixture("c2", "2", "1"),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[r.URL.Query().Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	_, meta, err := client.GetListTimelineAllWithMeta(context.Background(), "L1", 2)
+	if err != nil {
+		t.Fatalf("GetListTimelineAllWithMeta error: %v", err)
+	}
+	want := CollectMeta{Pages: 2, LastCursor: "c2", Items: 3}
+	if meta != want {
+		t.Fatalf("expected meta %+v, got %+v", want, meta)
+	}
+}
+
+func TestGetListTimelineAllWithMeta_Concurrent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := tweetPageFixture("end", "1")
+		if r.URL.Query().Get("listId") == "bad" {
+			body = `{"data":{"list":{}}}`
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	var wg sync.WaitGroup
+	metas := make([]CollectMeta, 8)
+	for i := range metas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			listID := "good"
+			if i%2 == 1 {
+				listID = "bad"
+			}
+			_, metas[i], _ = client.GetListTimelineAllWithMeta(context.Background(), listID, 1)
+		}(i)
+	}
+	wg.Wait()
+	for i, meta := range metas {
+		want := CollectMeta{Pages: 1, LastCursor: "end", Items: 1}
+		if i%2 == 1 {
+			want = CollectMeta{Pages: 1, MalformedPages: 1}
+		}
+		if meta != want {
+			t.Fatalf("call %d: expected meta %+v, got %+v", i, want, meta)
+		}
+	}
+}
+
+func TestUpdateMetrics_PartialCopy(t *testing.T) {
+	base := TweetResult{ID: "1", CreatedAt: "Mon Jan 01 00:00:00 +0000 2024", FavoriteCount: 5, RetweetCount: 3, ReplyCount: 2, ViewCount: "50"}
+
+	// A partial copy carries no legacy counts; only its non-zero ones apply.
+	tweet := base
+	tweet.updateMetrics(TweetResult{ID: "1", FavoriteCount: 7})
+	if tweet.FavoriteCount != 7 || tweet.RetweetCount != 3 || tweet.ReplyCount != 2 || tweet.ViewCount != "50" {
+		t.Fatalf("expected a partial copy to keep the other counts, got %+v", tweet)
+	}
+
+	// A full copy is authoritative, zeros included.
+	tweet = base
+	tweet.updateMetrics(TweetResult{ID: "1", CreatedAt: base.CreatedAt, FavoriteCount: 4})
+	if tweet.FavoriteCount != 4 || tweet.RetweetCount != 0 || tweet.ReplyCount != 0 {
+		t.Fatalf("expected a full copy to replace the counts, got %+v", tweet)
+	}
+}
@@ -0,0 +1,197 @@
+package utools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dumpSecretParams are the query / form params masked in dumps. Unlike
+// archived params, apiKey is kept (masked) so a dump shows where it was sent.
+var dumpSecretParams = []string{"apiKey", "auth_token", "ct0"}
+
+// dumpSecretHeaders are the headers masked in dumps.
+var dumpSecretHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// dumpTransport is the http.RoundTripper behind Config.DumpDir. It records
+// every exchange, failed ones included, to one JSON file under dir: the
+// request method, URL, headers and body, and the response status, headers
+// and raw body exactly as read (a truncated transfer stays truncated).
+// Credentials are redacted. The response body is captured as the client
+// reads it and the file is written when the body is closed, so dumping
+// neither buffers ahead nor defeats context cancellation.
+type dumpTransport struct {
+	dir  string
+	next http.RoundTripper
+	seq  atomic.Uint64
+}
+
+// newDumpTransport creates a dumpTransport writing into dir, creating it if
+// needed.
+func newDumpTransport(dir string, next http.RoundTripper) (*dumpTransport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("utools: create dump dir: %w", err)
+	}
+	return &dumpTransport{dir: dir, next: next}, nil
+}
+
+type dumpRecord struct {
+	Time            string      `json:"time"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"request_headers"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	Status          int         `json:"status,omitempty"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+	Error           string      `json:"error,omitempty"`
+	DurationMS      int64       `json:"duration_ms"`
+}
+
+// RoundTrip implements http.RoundTripper.
+func (d *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	rec := &dumpRecord{
+		Time:           start.UTC().Format(time.RFC3339Nano),
+		Method:         req.Method,
+		URL:            redactDumpURL(req.URL),
+		RequestHeaders: redactDumpHeader(req.Header),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			_ = body.Close()
+			rec.RequestBody = redactDumpForm(string(data))
+		}
+	}
+
+	resp, err := d.next.RoundTrip(req)
+	if err != nil {
+		rec.Error = err.Error()
+		rec.DurationMS = time.Since(start).Milliseconds()
+		d.write(rec, req.URL.Path, start)
+		return nil, err
+	}
+	rec.Status = resp.StatusCode
+	rec.ResponseHeaders = redactDumpHeader(resp.Header)
+	resp.Body = &dumpBody{ReadCloser: resp.Body, finish: func(body []byte, readErr error) {
+		rec.ResponseBody = string(body)
+		if readErr != nil {
+			rec.Error = readErr.Error()
+		}
+		rec.DurationMS = time.Since(start).Milliseconds()
+		d.write(rec, req.URL.Path, start)
+	}}
+	return resp, nil
+}
+
+// write stores rec as an indented JSON file. Failures are logged, not
+// returned, so dumping never fails the request it observes.
+func (d *dumpTransport) write(rec *dumpRecord, path string, at time.Time) {
+	compact, err := marshalJSON(rec)
+	if err != nil {
+		log.Printf("[utools] dump %s: %v", path, err)
+		return
+	}
+	var data bytes.Buffer
+	if err := json.Indent(&data, compact, "", "  "); err != nil {
+		log.Printf("[utools] dump %s: %v", path, err)
+		return
+	}
+	name := fmt.Sprintf("%s-%06d-%s.json",
+		at.UTC().Format("20060102T150405.000000000Z"),
+		d.seq.Add(1),
+		archiveFileSlug(path),
+	)
+	if err := os.WriteFile(filepath.Join(d.dir, name), data.Bytes(), 0o644); err != nil {
+		log.Printf("[utools] dump %s: %v", path, err)
+	}
+}
+
+// dumpBody tees a response body into a buffer and hands it to finish once,
+// on Close.
+type dumpBody struct {
+	io.ReadCloser
+	finish func(body []byte, readErr error)
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	readErr error
+	once    sync.Once
+}
+
+func (b *dumpBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	b.buf.Write(p[:n])
+	if err != nil && !errors.Is(err, io.EOF) && b.readErr == nil {
+		b.readErr = err
+	}
+	b.mu.Unlock()
+	return n, err
+}
+
+func (b *dumpBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.mu.Lock()
+		body, readErr := bytes.Clone(b.buf.Bytes()), b.readErr
+		b.mu.Unlock()
+		b.finish(body, readErr)
+	})
+	return err
+}
+
+// redactDumpURL returns u as a string with dumpSecretParams masked.
+func redactDumpURL(u *url.URL) string {
+	redacted := *u
+	q := u.Query()
+	if redactDumpValues(q) {
+		redacted.RawQuery = q.Encode()
+	}
+	return redacted.String()
+}
+
+// redactDumpForm masks dumpSecretParams in a form-encoded request body.
+// Bodies that are not forms are returned as-is.
+func redactDumpForm(body string) string {
+	form, err := url.ParseQuery(body)
+	if err != nil || !redactDumpValues(form) {
+		return body
+	}
+	return form.Encode()
+}
+
+// redactDumpValues masks dumpSecretParams in v and reports whether any was
+// present.
+func redactDumpValues(v url.Values) bool {
+	found := false
+	for _, k := range dumpSecretParams {
+		if _, ok := v[k]; ok {
+			v.Set(k, redactedValue)
+			found = true
+		}
+	}
+	return found
+}
+
+// redactDumpHeader returns a copy of h with dumpSecretHeaders masked.
+func redactDumpHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, k := range dumpSecretHeaders {
+		if _, ok := out[k]; ok {
+			out.Set(k, redactedValue)
+		}
+	}
+	return out
+}
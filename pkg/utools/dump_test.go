@@ -0,0 +1,91 @@
+package utools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xCatch/xcatch/config"
+)
+
+func TestDumpDirRecordsRedactedExchanges(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret-cookie")
+		if r.URL.Path == "/api/base/apitools/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"msg":"bad request"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	dir := filepath.Join(t.TempDir(), "dumps")
+	c, err := NewClient(&config.Config{
+		BaseURL:   ts.URL,
+		APIKey:    "secret-key",
+		AuthToken: "secret-auth",
+		CT0:       "secret-ct0",
+		Timeout:   5 * time.Second,
+		RateLimit: 100,
+		DumpDir:   dir,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	if _, err := c.GetHomeTimeline(context.Background(), ""); err != nil {
+		t.Fatalf("GetHomeTimeline error: %v", err)
+	}
+	if err := c.Post(context.Background(), "/bad", map[string]string{"auth_token": "secret-auth", "text": "hi"}, nil); err == nil {
+		t.Fatal("expected error from /bad")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dump dir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	if len(names) != 2 {
+		t.Fatalf("expected two dump files, got %v", names)
+	}
+
+	var recs []dumpRecord
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read dump: %v", err)
+		}
+		if s := string(data); strings.Contains(s, "secret-") {
+			t.Fatalf("dump %s leaks a secret:\n%s", name, s)
+		}
+		var rec dumpRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			t.Fatalf("decode dump: %v", err)
+		}
+		recs = append(recs, rec)
+	}
+
+	ok, failed := recs[0], recs[1]
+	if ok.Method != http.MethodGet || !strings.Contains(ok.URL, "/homeTimeline?") || !strings.Contains(ok.URL, "apiKey="+redactedValue) ||
+		!strings.Contains(ok.URL, "auth_token="+redactedValue) || ok.Status != http.StatusOK ||
+		ok.ResponseBody != `{"code":1,"data":"{}","msg":"SUCCESS"}` || ok.RequestHeaders.Get("Accept") != "application/json" {
+		t.Fatalf("unexpected dump of the successful request: %+v", ok)
+	}
+	if failed.Method != http.MethodPost || failed.Status != http.StatusBadRequest || failed.ResponseBody != `{"code":400,"msg":"bad request"}` ||
+		!strings.Contains(failed.RequestBody, "text=hi") || !strings.Contains(failed.RequestBody, "auth_token="+redactedValue) ||
+		failed.ResponseHeaders.Get("Set-Cookie") != redactedValue {
+		t.Fatalf("unexpected dump of the failed request: %+v", failed)
+	}
+}
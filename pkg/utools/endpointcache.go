@@ -0,0 +1,106 @@
+package utools
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEndpointCacheEntries bounds an endpoint cache, which holds whole
+// response pages. When it is full, expired entries are dropped, and if that
+// is not enough, all of them.
+const maxEndpointCacheEntries = 1000
+
+// endpointCache caches raw responses per endpoint for the TTLs of
+// Config.EndpointCacheTTL. It is safe for concurrent use, and its methods
+// are no-ops on a nil cache.
+type endpointCache struct {
+	mu      sync.Mutex
+	ttls    map[string]time.Duration // by endpointCachePath
+	entries map[string]endpointEntry // by endpointCachePath + "\x00" + key
+	now     func() time.Time         // time.Now; replaced in tests
+}
+
+// endpointEntry is a cached response.
+type endpointEntry struct {
+	raw     json.RawMessage
+	expires time.Time
+}
+
+// newEndpointCache returns a cache for the endpoints with a positive TTL in
+// ttls, or nil if there are none.
+func newEndpointCache(ttls map[string]time.Duration) *endpointCache {
+	e := &endpointCache{
+		ttls:    make(map[string]time.Duration),
+		entries: make(map[string]endpointEntry),
+		now:     time.Now,
+	}
+	for path, ttl := range ttls {
+		if ttl > 0 {
+			e.ttls[endpointCachePath(path)] = ttl
+		}
+	}
+	if len(e.ttls) == 0 {
+		return nil
+	}
+	return e
+}
+
+// endpointCachePath returns the form of an endpoint path used as cache key:
+// "/tweetTimeline" for "tweetTimeline" and "/api/base/apitools/tweetTimeline"
+// alike.
+func endpointCachePath(path string) string {
+	path = strings.TrimPrefix(strings.TrimSpace(path), apiToolsBasePath)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// get returns a copy of the response cached for path under key, which
+// identifies the call (such as tweet and cursor).
+func (e *endpointCache) get(path, key string) (json.RawMessage, bool) {
+	if e == nil {
+		return nil, false
+	}
+	k := endpointCachePath(path) + "\x00" + key
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entry, ok := e.entries[k]
+	if !ok {
+		return nil, false
+	}
+	if !e.now().Before(entry.expires) {
+		delete(e.entries, k)
+		return nil, false
+	}
+	return bytes.Clone(entry.raw), true
+}
+
+// put caches a copy of raw for path under key, if path has a TTL.
+func (e *endpointCache) put(path, key string, raw json.RawMessage) {
+	if e == nil {
+		return
+	}
+	path = endpointCachePath(path)
+	ttl, ok := e.ttls[path]
+	if !ok {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	if len(e.entries) >= maxEndpointCacheEntries {
+		for k, old := range e.entries {
+			if !now.Before(old.expires) {
+				delete(e.entries, k)
+			}
+		}
+		if len(e.entries) >= maxEndpointCacheEntries {
+			clear(e.entries)
+		}
+	}
+	e.entries[path+"\x00"+key] = endpointEntry{raw: bytes.Clone(raw), expires: now.Add(ttl)}
+}
@@ -0,0 +1,58 @@
+package utools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xCatch/xcatch/config"
+)
+
+func TestEndpointCacheTTL_TweetDetailByCursor(t *testing.T) {
+	var hits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		q := r.URL.Query()
+		_, _ = w.Write([]byte(`{"code":1,"data":{"tweet":"` + q.Get("tweetId") + `","cursor":"` + q.Get("cursor") + `"},"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(&config.Config{
+		BaseURL:          ts.URL,
+		APIKey:           "test-key",
+		Timeout:          5 * time.Second,
+		RateLimit:        100,
+		EndpointCacheTTL: map[string]time.Duration{"/tweetTimeline": 30 * time.Second, "/userTweetsV2": 0},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	now := time.Now()
+	client.responses.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	detail := func(tweetID, cursor string, wantHits int) {
+		t.Helper()
+		raw, err := client.GetTweetDetail(ctx, tweetID, cursor)
+		if err != nil {
+			t.Fatalf("GetTweetDetail(%q, %q) error: %v", tweetID, cursor, err)
+		}
+		if want := `{"tweet":"` + tweetID + `","cursor":"` + cursor + `"}`; string(raw) != want {
+			t.Fatalf("GetTweetDetail(%q, %q) = %s, want %s", tweetID, cursor, raw, want)
+		}
+		if hits != wantHits {
+			t.Fatalf("GetTweetDetail(%q, %q): expected %d requests, got %d", tweetID, cursor, wantHits, hits)
+		}
+	}
+
+	detail("1", "", 1)
+	detail("1", "", 1) // same cursor: cached
+	detail("1", "c2", 2)
+	detail("1", "c2", 2)
+	detail("2", "c2", 3) // same cursor, other tweet: missed
+
+	now = now.Add(30 * time.Second)
+	detail("1", "", 4) // expired
+}
@@ -0,0 +1,101 @@
+package utools
+
+import (
+	"net/http"
+	"slices"
+)
+
+// EndpointInfo describes the uTools endpoint behind a Client method.
+type EndpointInfo struct {
+	Name           string   // Client method, e.g. "GetUserTweets"
+	Method         string   // HTTP method
+	Path           string   // preferred path, relative to /api/base/apitools
+	RequiredParams []string // params always sent (preferred keys only, apiKey excluded)
+	RequiresAuth   bool     // needs Config.AuthToken (auth_token / ct0 are sent)
+}
+
+// endpoints lists the Client methods that wrap a single endpoint: every
+// method returning the raw (json.RawMessage, error) payload, plus typed
+// methods with no raw counterpart. Paginated and parsed helpers built on top
+// of them (…All, …Parsed, …Raw) are not listed. Keep it in sync when adding
+// an endpoint; TestEndpointsRegistry checks it against the Client methods.
+var endpoints = []EndpointInfo{
+	// User
+	{Name: "GetUserByScreenName", Path: "/getUserByIdOrNameShow", RequiredParams: []string{"screenName"}},
+	{Name: "GetUserByID", Path: "/usersByIdRestIds", RequiredParams: []string{"userIds"}},
+	{Name: "GetUsersByIDs", Path: "/usersByIdRestIds", RequiredParams: []string{"userIds"}},
+	{Name: "GetUsernameChanges", Path: "/usernameChanges", RequiredParams: []string{"userId"}},
+	{Name: "LookupUser", Path: "/getUserByIdOrNameLookup"}, // screenName or userId
+	{Name: "GetUserByScreenNameV2", Path: "/userByScreenNameV2", RequiredParams: []string{"screenName"}},
+	{Name: "GetUserByIDV2", Path: "/uerByIdRestIdV2", RequiredParams: []string{"userId"}},
+	{Name: "GetUsersByIDsV2", Path: "/usersByIdRestIds", RequiredParams: []string{"userIds"}},
+	{Name: "GetAccountAnalytics", Path: "/accountAnalytics", RequiresAuth: true},
+
+	// Tweet
+	{Name: "GetUserTweets", Path: "/userTweetsV2", RequiredParams: []string{"userId"}},
+	{Name: "GetUserTimeline", Path: "/userTimeline", RequiredParams: []string{"userId"}},
+	{Name: "GetUserMedia", Path: "/userMedia", RequiredParams: []string{"userId"}},
+	{Name: "GetTweetDetail", Path: "/tweetTimeline", RequiredParams: []string{"tweetId"}},
+	{Name: "GetTweetSimple", Path: "/tweetSimple", RequiredParams: []string{"tweetId"}},
+	{Name: "GetTweetsByIDs", Path: "/tweetResultsByRestIds", RequiredParams: []string{"tweetIds"}},
+	{Name: "GetUserReplies", Path: "/userTweetReply", RequiredParams: []string{"userId"}},
+	{Name: "GetUserLikes", Path: "/favoritesList", RequiredParams: []string{"userId"}},
+	{Name: "GetUserLikesV2", Path: "/userLikeV2", RequiredParams: []string{"userId"}},
+	{Name: "GetUserHighlights", Path: "/highlightsV2", RequiredParams: []string{"userId"}},
+	{Name: "GetUserArticlesTweets", Path: "/userArticlesTweets", RequiredParams: []string{"userId"}},
+	{Name: "GetHomeTimeline", Path: "/homeTimeline", RequiresAuth: true},
+	{Name: "GetMentionsTimeline", Path: "/mentionsTimeline", RequiresAuth: true},
+	{Name: "GetRetweeters", Path: "/retweetersV2", RequiredParams: []string{"tweetId"}},
+	{Name: "GetRetweetersIDs", Path: "/retweetersIds", RequiredParams: []string{"tweetId"}},
+	{Name: "GetFavoriters", Path: "/favoritersV2", RequiredParams: []string{"tweetId"}, RequiresAuth: true},
+	{Name: "GetQuotes", Path: "/quotesV2", RequiredParams: []string{"tweetId"}},
+
+	// Search & discovery
+	{Name: "Search", Path: "/search", RequiredParams: []string{"words"}},
+	{Name: "SearchWithOptions", Path: "/search", RequiredParams: []string{"words"}},
+	{Name: "SearchBox", Path: "/searchBox", RequiredParams: []string{"words"}},
+	{Name: "GetTrends", Path: "/trends"}, // id (WOEID) is optional
+	{Name: "GetAvailableTrendLocations", Path: "/trendsAvailable"},
+	{Name: "GetClosestTrendLocation", Path: "/trendsClosest", RequiredParams: []string{"lat", "long"}},
+	{Name: "GetTrending", Path: "/trending"},
+	{Name: "GetTrendingForYou", Path: "/trending", RequiresAuth: true},
+	{Name: "GetNews", Path: "/news"},
+	{Name: "GetExplorePage", Path: "/explore"},
+	{Name: "GetSports", Path: "/sports"},
+	{Name: "GetEntertainment", Path: "/entertainment"},
+
+	// Social graph, lists & communities
+	{Name: "GetFollowers", Path: "/followersListV2", RequiredParams: []string{"userId"}},
+	{Name: "GetFollowings", Path: "/followingsListV2", RequiredParams: []string{"userId"}},
+	{Name: "GetFollowerIDs", Path: "/followersIds", RequiredParams: []string{"userId"}},
+	{Name: "GetFollowingIDs", Path: "/followingsIds", RequiredParams: []string{"userId"}},
+	{Name: "GetRelationship", Path: "/getFriendshipsShow", RequiredParams: []string{"sourceId", "targetId"}},
+	{Name: "GetFollowersYouKnow", Path: "/followersYouKnowV2", RequiredParams: []string{"userId"}},
+	{Name: "GetBlueVerifiedFollowers", Path: "/blueVerifiedFollowersV2", RequiredParams: []string{"userId"}},
+	{Name: "GetUserRecommendations", Path: "/userRecommendations", RequiredParams: []string{"userId"}, RequiresAuth: true},
+	{Name: "GetSimilarUsers", Path: "/similarUsersV2", RequiredParams: []string{"userId"}, RequiresAuth: true},
+	{Name: "GetListByUser", Path: "/getListByUserIdOrScreenName"}, // userId or screenName
+	{Name: "GetListMembers", Path: "/listMembersByListIdV2", RequiredParams: []string{"listId"}},
+	{Name: "GetListTimeline", Path: "/listLatestTweetsTimeline", RequiredParams: []string{"listId"}},
+	{Name: "GetCommunitiesByScreenName", Path: "/getCommunitiesByScreenName", RequiredParams: []string{"screenName"}},
+	{Name: "GetCommunityInfo", Path: "/communitiesFetchOneQuery", RequiredParams: []string{"communityId"}},
+	{Name: "GetCommunityTweets", Path: "/communitiesTweetsTimelineV2", RequiredParams: []string{"communityId"}},
+	{Name: "GetCommunityMembers", Path: "/communitiesMemberV2", RequiredParams: []string{"communityId"}},
+
+	// Maintenance
+	{Name: "TokenSync", Path: tokenSyncPath},
+}
+
+// Endpoints returns the endpoints wrapped by the Client methods, for
+// discovery tooling and tests. The returned slice is a copy.
+func Endpoints() []EndpointInfo {
+	out := make([]EndpointInfo, len(endpoints))
+	for i, e := range endpoints {
+		if e.Method == "" {
+			e.Method = http.MethodGet // every endpoint above is a GET
+		}
+		e.RequiredParams = slices.Clone(e.RequiredParams)
+		out[i] = e
+	}
+	return out
+}
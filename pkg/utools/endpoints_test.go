@@ -0,0 +1,97 @@
+package utools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEndpointsRegistry(t *testing.T) {
+	clientType := reflect.TypeOf(&Client{})
+	listed := make(map[string]bool)
+	for _, e := range Endpoints() {
+		if listed[e.Name] {
+			t.Errorf("%s listed twice", e.Name)
+		}
+		listed[e.Name] = true
+		if !strings.HasPrefix(e.Path, "/") || e.Method == "" {
+			t.Errorf("%s: incomplete entry %+v", e.Name, e)
+		}
+		if _, ok := clientType.MethodByName(e.Name); !ok {
+			t.Errorf("%s is not a Client method", e.Name)
+		}
+	}
+
+	// Every method returning the raw payload wraps an endpoint and must be
+	// listed.
+	ctxType := reflect.TypeOf((*context.Context)(nil)).Elem()
+	rawType := reflect.TypeOf(json.RawMessage(nil))
+	errType := reflect.TypeOf((*error)(nil)).Elem()
+	for i := 0; i < clientType.NumMethod(); i++ {
+		m := clientType.Method(i)
+		if m.Type.NumIn() < 2 || m.Type.In(1) != ctxType || m.Type.NumOut() != 2 ||
+			m.Type.Out(0) != rawType || m.Type.Out(1) != errType {
+			continue
+		}
+		if !listed[m.Name] {
+			t.Errorf("%s returns a raw payload but is missing from Endpoints()", m.Name)
+		}
+	}
+}
+
+// TestEndpointsRequestMapping calls every registered method with dummy
+// arguments and checks the request against its entry.
+func TestEndpointsRequestMapping(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got == nil {
+			got = r
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":[],"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+	client.authToken = "test-auth"
+	client.strictParamKeys = true
+
+	for _, e := range Endpoints() {
+		got = nil
+		m := reflect.ValueOf(client).MethodByName(e.Name)
+		args := []reflect.Value{reflect.ValueOf(context.Background())}
+		for i := 1; i < m.Type().NumIn(); i++ {
+			arg := reflect.New(m.Type().In(i)).Elem()
+			switch arg.Kind() {
+			case reflect.String:
+				arg.SetString("1")
+			case reflect.Slice:
+				arg.Set(reflect.ValueOf([]string{"1"}))
+			case reflect.Float64:
+				arg.SetFloat(1.5)
+			}
+			args = append(args, arg)
+		}
+		m.Call(args)
+
+		if got == nil {
+			t.Errorf("%s: no request issued", e.Name)
+			continue
+		}
+		if got.Method != e.Method || got.URL.Path != apiToolsBasePath+e.Path {
+			t.Errorf("%s: expected %s %s, got %s %s", e.Name, e.Method, apiToolsBasePath+e.Path, got.Method, got.URL.Path)
+		}
+		q := got.URL.Query()
+		for _, p := range e.RequiredParams {
+			if q.Get(p) == "" {
+				t.Errorf("%s: missing required param %q in %s", e.Name, p, got.URL.RawQuery)
+			}
+		}
+		if e.RequiresAuth != (q.Get("auth_token") != "") {
+			t.Errorf("%s: RequiresAuth=%v but auth_token sent=%v", e.Name, e.RequiresAuth, q.Get("auth_token") != "")
+		}
+	}
+}
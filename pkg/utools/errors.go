@@ -1,20 +1,111 @@
 package utools
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 var (
 	ErrAuthTokenRequired = errors.New("utools: auth_token is required for this endpoint")
+	ErrUserNotFound      = errors.New("utools: user not found")
+	ErrTweetNotFound     = errors.New("utools: tweet not found")
+
+	// ErrUserSuspended and ErrUserDeactivated refine ErrUserNotFound; both
+	// match it via errors.Is.
+	ErrUserSuspended   = fmt.Errorf("%w (suspended)", ErrUserNotFound)
+	ErrUserDeactivated = fmt.Errorf("%w (deactivated)", ErrUserNotFound)
+
+	// ErrTweetDeleted, ErrTweetAuthorSuspended, ErrTweetBlocked and
+	// ErrTweetProtected refine ErrTweetNotFound for tombstoned tweets; all
+	// match it via errors.Is.
+	ErrTweetDeleted         = fmt.Errorf("%w (deleted)", ErrTweetNotFound)
+	ErrTweetAuthorSuspended = fmt.Errorf("%w (author suspended)", ErrTweetNotFound)
+	ErrTweetBlocked         = fmt.Errorf("%w (blocked by the author)", ErrTweetNotFound)
+	ErrTweetProtected       = fmt.Errorf("%w (author's posts are protected)", ErrTweetNotFound)
+
+	ErrViewsUnavailable = errors.New("utools: view count not available")
+
+	// ErrTooManyIDs is returned by GetTweetsByIDs (and the helpers built on
+	// it) for more IDs than one request can carry.
+	ErrTooManyIDs = errors.New("utools: too many IDs for one request")
+
+	// ErrOverallTimeout is returned when Config.OverallTimeout elapses across
+	// attempts and backoffs. It matches context.DeadlineExceeded via errors.Is.
+	ErrOverallTimeout = fmt.Errorf("utools: overall timeout exceeded: %w", context.DeadlineExceeded)
+
+	// ErrRobotTokenExpired matches (via errors.Is) an APIError reporting that
+	// the shared robot token used by the gateway has expired. Calling
+	// TokenSync refreshes it; see also Config.AutoTokenSync.
+	ErrRobotTokenExpired = errors.New("utools: robot token expired")
+
+	// ErrChallengeRequired matches (via errors.Is) an APIError reporting
+	// that the auth_token / ct0 session is locked behind a challenge: the
+	// account must be re-verified in a browser before authenticated calls
+	// work again. It is not retried, and is neither a transient failure nor
+	// a sign of invalid credentials.
+	ErrChallengeRequired = errors.New("utools: account locked, challenge required")
+
+	// ErrCorruptResponse is returned when a response body is not valid
+	// UTF-8, typically a transfer cut off mid-character. It is retryable;
+	// see Config.RepairInvalidUTF8 to repair such bodies instead.
+	ErrCorruptResponse = errors.New("utools: truncated or corrupt response (invalid UTF-8)")
+
+	// ErrRetryableResult is wrapped by a ResultValidator error to have the
+	// rejected response retried.
+	ErrRetryableResult = errors.New("utools: retryable invalid result")
 )
 
+// ErrHandleChanged is returned by the profile methods when the requested
+// handle belongs to an account that has since been renamed: the response
+// either points to the new handle or carries a user under a different one.
+// Set Config.FollowHandleRedirects to fetch the new handle instead.
+type ErrHandleChanged struct {
+	OldHandle string
+	NewHandle string
+}
+
+func (e *ErrHandleChanged) Error() string {
+	return fmt.Sprintf("utools: handle @%s changed to @%s", e.OldHandle, e.NewHandle)
+}
+
+// ErrInvalidResult is returned when a ResultValidator rejects an otherwise
+// successful response. It unwraps to the validator's error.
+type ErrInvalidResult struct {
+	Path string
+	Err  error
+}
+
+func (e *ErrInvalidResult) Error() string {
+	return fmt.Sprintf("utools: invalid result from %s: %v", e.Path, e.Err)
+}
+
+func (e *ErrInvalidResult) Unwrap() error {
+	return e.Err
+}
+
 // APIError represents an error returned by the uTools API.
 type APIError struct {
 	StatusCode int
 	Code       int // Twitter error code (e.g. 88 = rate limit)
 	Message    string
 	RawBody    string
+
+	// RateLimitReset is the raw x-rate-limit-reset response header, empty
+	// when the gateway did not send it.
+	RateLimitReset string
+
+	// retryableCodes are extra business codes treated as transient, from
+	// Config.RetryableBusinessCodes of the client that returned the error.
+	retryableCodes []int
+
+	// resetThreshold is Config.RateLimitResetThreshold of the client that
+	// returned the error; zero means robotTokenResetThreshold.
+	resetThreshold int
 }
 
 func (e *APIError) Error() string {
@@ -36,7 +127,169 @@ func (e *APIError) IsUnauthorized() bool {
 	return e.StatusCode == 401
 }
 
-// IsRetryable returns true if the request should be retried.
+// robotTokenResetThreshold is the default x-rate-limit-reset value below
+// which the gateway's robot token is considered stale; see
+// Config.RateLimitResetThreshold.
+const robotTokenResetThreshold = 9
+
+// IsRobotTokenExpired returns true if the error reports an expired robot
+// token: Twitter code 89 ("Invalid or expired token") or 239 ("Bad guest
+// token"), an "expired token" message, or a 401/403/429 that arrives with an
+// x-rate-limit-reset below the threshold (Config.RateLimitResetThreshold,
+// 9 by default).
+func (e *APIError) IsRobotTokenExpired() bool {
+	if e.Code == 89 || e.Code == 239 {
+		return true
+	}
+	msg := strings.ToLower(e.Message)
+	if strings.Contains(msg, "token") && strings.Contains(msg, "expired") {
+		return true
+	}
+	if e.StatusCode == 401 || e.StatusCode == 403 || e.StatusCode == 429 {
+		threshold := e.resetThreshold
+		if threshold <= 0 {
+			threshold = robotTokenResetThreshold
+		}
+		if reset, err := strconv.Atoi(e.RateLimitReset); err == nil && reset < threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedAccountPhrases are the messages, lower-cased, with which the API
+// reports a locked account without code 326.
+var lockedAccountPhrases = []string{
+	"this account is temporarily locked",
+	"your account is temporarily locked",
+	"your account has been locked",
+}
+
+// IsChallengeRequired returns true if the error reports a locked session:
+// Twitter code 326 ("account temporarily locked"), one of the
+// locked-account messages, or a body redirecting to the account access /
+// LoginAcid challenge flow. Other messages that merely mention a challenge
+// do not count.
+func (e *APIError) IsChallengeRequired() bool {
+	if e.Code == 326 {
+		return true
+	}
+	msg := strings.ToLower(e.Message)
+	for _, phrase := range lockedAccountPhrases {
+		if strings.Contains(msg, phrase) {
+			return true
+		}
+	}
+	body := strings.ToLower(e.RawBody)
+	return strings.Contains(body, "/account/access") || strings.Contains(body, "loginacid")
+}
+
+// Is lets errors.Is(err, ErrRobotTokenExpired) and
+// errors.Is(err, ErrChallengeRequired) classify API errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrChallengeRequired:
+		return e.IsChallengeRequired()
+	case ErrRobotTokenExpired:
+		return !e.IsChallengeRequired() && e.IsRobotTokenExpired()
+	}
+	return false
+}
+
+// IsRetryable returns true if the request should be retried: rate limits,
+// 403s and any code listed in Config.RetryableBusinessCodes, unless the
+// session requires a challenge, which waiting does not resolve.
 func (e *APIError) IsRetryable() bool {
-	return e.IsRateLimited() || e.IsForbidden()
+	if e.IsChallengeRequired() {
+		return false
+	}
+	return e.IsRateLimited() || e.IsForbidden() || (e.Code != 0 && slices.Contains(e.retryableCodes, e.Code))
+}
+
+// MultiError collects per-key failures of a batch operation (keyed by user
+// ID, tweet ID, ...). errors.Is and errors.As match against every member.
+// Batch helpers return it only when at least one key failed; see ErrOrNil.
+type MultiError struct {
+	Errors map[string]error
+}
+
+// Add records err for key. A nil err is ignored.
+func (m *MultiError) Add(key string, err error) {
+	if err == nil {
+		return
+	}
+	if m.Errors == nil {
+		m.Errors = make(map[string]error)
+	}
+	m.Errors[key] = err
+}
+
+// Get returns the error recorded for key, or nil.
+func (m *MultiError) Get(key string) error {
+	if m == nil {
+		return nil
+	}
+	return m.Errors[key]
+}
+
+// Len returns the number of failed keys.
+func (m *MultiError) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.Errors)
+}
+
+// ErrOrNil returns m as an error, or an untyped nil when nothing failed, so
+// callers can return it directly without the typed-nil pitfall.
+func (m *MultiError) ErrOrNil() error {
+	if m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+// Error summarizes the failures, grouping identical messages with counts,
+// e.g. `utools: 3 errors (2 x "utools: user not found", 1 x "...")`.
+func (m *MultiError) Error() string {
+	counts := make(map[string]int, len(m.Errors))
+	for _, err := range m.Errors {
+		counts[err.Error()]++
+	}
+	msgs := make([]string, 0, len(counts))
+	for msg := range counts {
+		msgs = append(msgs, msg)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		if counts[msgs[i]] != counts[msgs[j]] {
+			return counts[msgs[i]] > counts[msgs[j]]
+		}
+		return msgs[i] < msgs[j]
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "utools: %d errors (", len(m.Errors))
+	for i, msg := range msgs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%d x %q", counts[msg], msg)
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// Unwrap returns the member errors ordered by key, which lets errors.Is and
+// errors.As inspect each of them.
+func (m *MultiError) Unwrap() []error {
+	keys := make([]string, 0, len(m.Errors))
+	for k := range m.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	errs := make([]error, len(keys))
+	for i, k := range keys {
+		errs[i] = m.Errors[k]
+	}
+	return errs
 }
@@ -0,0 +1,103 @@
+package utools
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMultiErrorIsAndAs(t *testing.T) {
+	var m MultiError
+	m.Add("alice", fmt.Errorf("lookup alice: %w", ErrUserNotFound))
+	m.Add("bob", &APIError{StatusCode: 429, Code: 88, Message: "rate limit"})
+	m.Add("carol", nil)
+
+	err := m.ErrOrNil()
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected errors.Is to match a member sentinel, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsRateLimited() {
+		t.Fatalf("expected errors.As to find the APIError member, got %v", err)
+	}
+	if errors.Is(err, ErrAuthTokenRequired) {
+		t.Fatal("unexpected match for a sentinel no member carries")
+	}
+	if m.Get("carol") != nil || m.Len() != 2 {
+		t.Fatalf("nil errors must not be recorded: %+v", m.Errors)
+	}
+	if !errors.Is(m.Get("alice"), ErrUserNotFound) {
+		t.Fatalf("Get returned %v", m.Get("alice"))
+	}
+}
+
+func TestMultiErrorSummaryCounts(t *testing.T) {
+	var m MultiError
+	m.Add("1", ErrUserNotFound)
+	m.Add("2", ErrUserNotFound)
+	m.Add("3", ErrAuthTokenRequired)
+
+	want := `utools: 3 errors (2 x "utools: user not found", 1 x "utools: auth_token is required for this endpoint")`
+	if got := m.Error(); got != want {
+		t.Fatalf("unexpected summary:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestMultiErrorErrOrNil(t *testing.T) {
+	var m MultiError
+	if err := m.ErrOrNil(); err != nil {
+		t.Fatalf("expected untyped nil for an empty MultiError, got %#v", err)
+	}
+}
+
+func TestRobotTokenExpiredClassification(t *testing.T) {
+	cases := []struct {
+		name string
+		err  *APIError
+		want bool
+	}{
+		{"code 89", &APIError{StatusCode: 403, Code: 89, Message: "Invalid or expired token."}, true},
+		{"bad guest token", &APIError{StatusCode: 403, Code: 239, Message: "Bad guest token."}, true},
+		{"message only", &APIError{StatusCode: 200, Code: 500, Message: "robot token expired, please sync"}, true},
+		{"low reset header", &APIError{StatusCode: 429, Code: 88, Message: "rate limit", RateLimitReset: "3"}, true},
+		{"high reset header", &APIError{StatusCode: 429, Code: 88, Message: "rate limit", RateLimitReset: "600"}, false},
+		{"plain forbidden", &APIError{StatusCode: 403, Message: "forbidden"}, false},
+		{"low reset on 404", &APIError{StatusCode: 404, RateLimitReset: "1"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("page iterator: %w", tc.err)
+			if got := errors.Is(wrapped, ErrRobotTokenExpired); got != tc.want {
+				t.Fatalf("errors.Is(ErrRobotTokenExpired) = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestChallengeRequiredClassification(t *testing.T) {
+	cases := []struct {
+		name string
+		err  *APIError
+		want bool
+	}{
+		{"code 326", &APIError{StatusCode: 403, Code: 326, Message: "To protect our users from spam and other malicious activity, this account is temporarily locked."}, true},
+		{"access redirect", &APIError{StatusCode: 403, RawBody: `{"errors":[{"message":"Forbidden","bounce_location":"https://twitter.com/account/access"}]}`}, true},
+		{"LoginAcid redirect", &APIError{StatusCode: 200, Code: 500, RawBody: `{"redirect":"https://twitter.com/account/login_challenge?challenge_type=LoginAcid"}`}, true},
+		{"locked message", &APIError{StatusCode: 200, Code: 500, Message: "Your account is temporarily locked."}, true},
+		{"unrelated challenge", &APIError{StatusCode: 400, Code: 214, Message: "Invalid challenge_response parameter"}, false},
+		{"unrelated lock", &APIError{StatusCode: 403, Message: "This account's tweets are locked by the owner"}, false},
+		{"plain forbidden", &APIError{StatusCode: 403, Message: "forbidden"}, false},
+		{"rate limit", &APIError{StatusCode: 429, Code: 88, Message: "Rate limit exceeded"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("page iterator: %w", tc.err)
+			if got := errors.Is(wrapped, ErrChallengeRequired); got != tc.want {
+				t.Fatalf("errors.Is(ErrChallengeRequired) = %v, want %v", got, tc.want)
+			}
+			if tc.want && (tc.err.IsRetryable() || isRetryableError(wrapped)) {
+				t.Fatal("a challenge must not be retried")
+			}
+		})
+	}
+}
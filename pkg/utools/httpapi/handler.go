@@ -0,0 +1,217 @@
+// Package httpapi exposes a utools.Client over HTTP for internal tools.
+//
+// Results are streamed as newline-delimited JSON (NDJSON): one JSON value per
+// line, flushed as soon as it is available, so paginated endpoints can be
+// consumed incrementally. The package only depends on the standard library
+// and the utools package.
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/xCatch/xcatch/pkg/utools"
+)
+
+const (
+	// DefaultPages is the number of pages fetched when ?pages= is absent.
+	DefaultPages = 1
+	// MaxPages caps ?pages= to keep a single request bounded.
+	MaxPages = 50
+)
+
+// Handler maps HTTP routes to Client calls:
+//
+//	GET /v1/user/{screenName}                      user profile (one line)
+//	GET /v1/tweets/{userId}?pages=N                user tweets, one line per page
+//	GET /v1/tweets/{userId}?pages=N&format=tweets  user tweets, one parsed tweet per line
+type Handler struct {
+	client *utools.Client
+	parser *utools.TweetParser
+	mux    *http.ServeMux
+}
+
+// NewHandler creates a Handler serving requests with client.
+func NewHandler(client *utools.Client) *Handler {
+	h := &Handler{client: client, parser: utools.NewTweetParser(), mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /v1/user/{screenName}", h.handleUser)
+	h.mux.HandleFunc("GET /v1/tweets/{userId}", h.handleTweets)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// pageLine is the NDJSON record emitted for each fetched page.
+type pageLine struct {
+	Page       int             `json:"page"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type errorLine struct {
+	Error string `json:"error"`
+}
+
+func (h *Handler) handleUser(w http.ResponseWriter, r *http.Request) {
+	screenName := r.PathValue("screenName")
+	raw, err := h.client.GetUserByScreenNameV2(r.Context(), screenName)
+	if err == nil {
+		_, err = utools.ParseUserProfile(raw)
+	}
+	if err != nil {
+		failRequest(w, r, err)
+		return
+	}
+
+	nd := newNDJSONWriter(w)
+	_ = nd.write(raw)
+}
+
+func (h *Handler) handleTweets(w http.ResponseWriter, r *http.Request) {
+	pages := DefaultPages
+	if v := r.URL.Query().Get("pages"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "pages must be a positive integer")
+			return
+		}
+		pages = min(n, MaxPages)
+	}
+	var perTweet bool
+	switch r.URL.Query().Get("format") {
+	case "", "pages":
+	case "tweets":
+		perTweet = true
+	default:
+		writeError(w, http.StatusBadRequest, "format must be pages or tweets")
+		return
+	}
+
+	ctx := r.Context()
+	iter := h.client.NewPageIterator("/userTweetsV2", map[string]string{
+		"userId": r.PathValue("userId"),
+	}, pages)
+
+	var nd *ndjsonWriter
+	for iter.HasMore() {
+		page, err := iter.Next(ctx)
+		if err != nil {
+			if nd == nil {
+				failRequest(w, r, err)
+			} else if ctx.Err() == nil {
+				// Headers are already sent; report the failure in-band.
+				logError(r, err)
+				_, msg := publicError(err)
+				_ = nd.write(errorLine{Error: msg})
+			}
+			return
+		}
+		if page == nil {
+			break
+		}
+		if nd == nil {
+			nd = newNDJSONWriter(w)
+		}
+		if perTweet {
+			if !h.writeTweets(r, nd, page.RawData) {
+				return
+			}
+			continue
+		}
+		line := pageLine{Page: iter.PageCount(), NextCursor: page.NextCursor, Data: page.RawData}
+		if err := nd.write(line); err != nil {
+			return
+		}
+	}
+	if nd == nil {
+		// No pages at all: still answer with an empty NDJSON stream.
+		newNDJSONWriter(w)
+	}
+}
+
+// writeTweets writes the tweets of a timeline page one per line, streaming
+// them out of raw so large pages are never parsed into memory whole. It
+// reports whether the response can go on.
+func (h *Handler) writeTweets(r *http.Request, nd *ndjsonWriter, raw json.RawMessage) bool {
+	var writeErr error
+	err := h.parser.StreamTimeline(bytes.NewReader(raw), func(t utools.TweetResult) error {
+		writeErr = nd.write(t)
+		return writeErr
+	})
+	if err != nil && writeErr == nil {
+		logError(r, err)
+		_, msg := publicError(err)
+		_ = nd.write(errorLine{Error: msg})
+	}
+	return err == nil
+}
+
+// publicError maps a client error to the HTTP status and the fixed message
+// reported to callers. The error's own text is never sent: it can hold the
+// upstream request URL, and with it the apiKey.
+func publicError(err error) (int, string) {
+	var apiErr *utools.APIError
+	switch {
+	case errors.Is(err, utools.ErrUserNotFound):
+		return http.StatusNotFound, "not found"
+	case errors.Is(err, utools.ErrAuthTokenRequired):
+		return http.StatusUnauthorized, "authentication required"
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.StatusCode == http.StatusNotFound:
+			return http.StatusNotFound, "not found"
+		case apiErr.IsRateLimited():
+			return http.StatusTooManyRequests, "rate limited"
+		}
+	}
+	return http.StatusBadGateway, "upstream error"
+}
+
+// failRequest logs err and answers r with its public status and message.
+func failRequest(w http.ResponseWriter, r *http.Request, err error) {
+	logError(r, err)
+	status, msg := publicError(err)
+	writeError(w, status, msg)
+}
+
+// logError logs the detailed error behind a public one, server side only.
+func logError(r *http.Request, err error) {
+	log.Printf("[httpapi] %s %s: %v", r.Method, r.URL.Path, err)
+}
+
+// writeError answers with status and a JSON {"error": msg} body.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorLine{Error: msg})
+}
+
+// ndjsonWriter writes one JSON value per line and flushes after each.
+type ndjsonWriter struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w http.ResponseWriter) *ndjsonWriter {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	return &ndjsonWriter{w: w, rc: http.NewResponseController(w), enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonWriter) write(v any) error {
+	if err := n.enc.Encode(v); err != nil {
+		return err
+	}
+	if err := n.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
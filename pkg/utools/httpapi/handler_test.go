@@ -0,0 +1,203 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xCatch/xcatch/config"
+	"github.com/xCatch/xcatch/pkg/utools"
+)
+
+func newUpstreamClient(t *testing.T, handler http.HandlerFunc) *utools.Client {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	c, err := utools.NewClient(&config.Config{
+		BaseURL:   ts.URL,
+		APIKey:    "test-key",
+		Timeout:   5 * time.Second,
+		RateLimit: 100,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+func writeEnvelope(w http.ResponseWriter, data string) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"code":1,"data":` + data + `,"msg":"SUCCESS"}`))
+}
+
+func TestHandlerUserRoute(t *testing.T) {
+	client := newUpstreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/userByScreenNameV2" {
+			t.Fatalf("unexpected upstream path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("screenName") != "jack" {
+			t.Fatalf("unexpected screenName: %q", r.URL.Query().Get("screenName"))
+		}
+		writeEnvelope(w, `{"data":{"user":{"result":{"rest_id":"12","legacy":{"screen_name":"jack"}}}}}`)
+	})
+
+	rec := httptest.NewRecorder()
+	NewHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/user/jack", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 1 || !json.Valid([]byte(lines[0])) || !strings.Contains(lines[0], `"rest_id":"12"`) {
+		t.Fatalf("unexpected NDJSON body: %q", rec.Body.String())
+	}
+}
+
+func TestHandlerUserRouteNotFound(t *testing.T) {
+	client := newUpstreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, `{"data":{}}`)
+	})
+
+	rec := httptest.NewRecorder()
+	NewHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/user/nobody", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandlerUserRouteUpstreamError(t *testing.T) {
+	client := newUpstreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":400,"msg":"bad"}`))
+	})
+
+	rec := httptest.NewRecorder()
+	NewHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/user/jack", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rec.Code)
+	}
+}
+
+func TestHandlerTweetsRouteStreamsPages(t *testing.T) {
+	var hits int32
+	client := newUpstreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := strconv.Itoa(int(atomic.AddInt32(&hits, 1)))
+		writeEnvelope(w, `{"n":`+n+`,"next_cursor":"c`+n+`"}`)
+	})
+
+	rec := httptest.NewRecorder()
+	NewHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tweets/44?pages=2", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	sc := bufio.NewScanner(rec.Body)
+	var got []pageLine
+	for sc.Scan() {
+		var line pageLine
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", sc.Text(), err)
+		}
+		got = append(got, line)
+	}
+	if len(got) != 2 || got[0].Page != 1 || got[1].Page != 2 || got[1].NextCursor != "c2" {
+		t.Fatalf("unexpected pages: %+v", got)
+	}
+}
+
+func TestHandlerTweetsRouteStopsOnCancel(t *testing.T) {
+	var hits int32
+	ctx, cancel := context.WithCancel(context.Background())
+	client := newUpstreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		cancel()
+		writeEnvelope(w, `{"next_cursor":"more"}`)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/tweets/44?pages=5", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	NewHandler(client).ServeHTTP(rec, req)
+
+	if got := atomic.LoadInt32(&hits); got > 1 {
+		t.Fatalf("expected pagination to stop after cancel, upstream hits=%d", got)
+	}
+}
+
+func TestHandlerTweetsRouteStreamsTweets(t *testing.T) {
+	client := newUpstreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, `{"data":{"user":{"result":{"timeline_v2":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[`+
+			`{"entryId":"tweet-2","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"2","legacy":{"full_text":"second"}}}}}},`+
+			`{"entryId":"tweet-1","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"1","legacy":{"full_text":"first"}}}}}},`+
+			`{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"next"}}]}]}}}}}}`)
+	})
+
+	rec := httptest.NewRecorder()
+	NewHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tweets/44?format=tweets", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	sc := bufio.NewScanner(rec.Body)
+	var got []utools.TweetResult
+	for sc.Scan() {
+		var tweet utools.TweetResult
+		if err := json.Unmarshal(sc.Bytes(), &tweet); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", sc.Text(), err)
+		}
+		got = append(got, tweet)
+	}
+	if len(got) != 2 || got[0].RestID != "2" || got[1].GetText() != "first" {
+		t.Fatalf("unexpected tweets: %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	NewHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tweets/44?format=xml", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected content type %q for a 400", ct)
+	}
+}
+
+func TestHandlerErrorsDoNotLeakAPIKey(t *testing.T) {
+	// A closed upstream makes the client fail with a *url.Error, whose text
+	// holds the request URL and so the apiKey.
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+	client, err := utools.NewClient(&config.Config{
+		BaseURL:   ts.URL,
+		APIKey:    "secret-key",
+		Timeout:   time.Second,
+		RateLimit: 100,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	for _, target := range []string{"/v1/user/jack", "/v1/tweets/44"} {
+		rec := httptest.NewRecorder()
+		NewHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadGateway {
+			t.Fatalf("%s: expected 502, got %d", target, rec.Code)
+		}
+		if body := rec.Body.String(); strings.Contains(body, "secret-key") || strings.TrimSpace(body) != `{"error":"upstream error"}` {
+			t.Fatalf("%s: unexpected error body %q", target, body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("%s: unexpected content type %q", target, ct)
+		}
+	}
+}
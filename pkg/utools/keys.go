@@ -0,0 +1,130 @@
+package utools
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KeySelector chooses which API key of a multi-key client sends each
+// request. See WithAPIKeys.
+type KeySelector int
+
+const (
+	// KeyRoundRobin cycles through the keys in order.
+	KeyRoundRobin KeySelector = iota
+	// KeyMostRemaining picks the key with the highest x-rate-limit-remaining
+	// reported so far. Keys whose quota is not known yet count as having the
+	// most, less each time they are picked before a response reports it, so
+	// concurrent first requests spread over the keys and every key is tried
+	// before the headers decide; ties go to the key listed first.
+	KeyMostRemaining
+	// KeyLeastRecentlyUsed picks the key idle for the longest time.
+	KeyLeastRecentlyUsed
+)
+
+// WithAPIKeys spreads requests over keys, choosing the key of every attempt
+// with selector, in place of Config.APIKey. The x-rate-limit-remaining
+// header of each response is tracked per key; a 429 without the header
+// counts as no quota left. Empty keys are ignored, and with no keys left
+// the option is a no-op.
+func WithAPIKeys(selector KeySelector, keys ...string) Option {
+	return func(c *Client) {
+		pool := &keyPool{selector: selector}
+		for _, k := range keys {
+			if k != "" {
+				pool.keys = append(pool.keys, &keyState{key: k, remaining: -1})
+			}
+		}
+		if len(pool.keys) > 0 {
+			c.keys = pool
+			c.apiKey = pool.keys[0].key
+		}
+	}
+}
+
+// keyPool is the key set of a multi-key client. It is safe for concurrent
+// use.
+type keyPool struct {
+	mu       sync.Mutex
+	selector KeySelector
+	keys     []*keyState
+	next     int
+}
+
+// keyState tracks the quota of one key. remaining is -1 until a response
+// reports it; picks counts the attempts sent with the key meanwhile.
+type keyState struct {
+	key       string
+	remaining int
+	picks     int
+	lastUsed  time.Time
+}
+
+// pick returns the key for the next attempt and marks it used.
+func (p *keyPool) pick() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var best *keyState
+	switch p.selector {
+	case KeyMostRemaining:
+		for _, k := range p.keys {
+			if best == nil || k.quotaRank() > best.quotaRank() {
+				best = k
+			}
+		}
+	case KeyLeastRecentlyUsed:
+		for _, k := range p.keys {
+			if best == nil || k.lastUsed.Before(best.lastUsed) {
+				best = k
+			}
+		}
+	default:
+		best = p.keys[p.next%len(p.keys)]
+		p.next++
+	}
+	if best.remaining < 0 {
+		best.picks++
+	}
+	best.lastUsed = time.Now()
+	return best.key
+}
+
+// quotaRank orders keys by remaining quota. An unknown quota ranks above
+// any reported one, and the more so the fewer attempts already use the key.
+func (k *keyState) quotaRank() int {
+	if k.remaining < 0 {
+		return math.MaxInt - k.picks
+	}
+	return k.remaining
+}
+
+// observe records the quota a response to key reported.
+func (p *keyPool) observe(key string, status int, h http.Header) {
+	remaining, err := strconv.Atoi(h.Get("x-rate-limit-remaining"))
+	if err != nil {
+		if status != http.StatusTooManyRequests {
+			return
+		}
+		remaining = 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, k := range p.keys {
+		if k.key == key {
+			k.remaining = remaining
+			return
+		}
+	}
+}
+
+// pickAPIKey returns the key to send the next attempt with: the pool's
+// choice for a multi-key client, the configured key otherwise.
+func (c *Client) pickAPIKey() string {
+	if c.keys == nil {
+		return c.apiKey
+	}
+	return c.keys.pick()
+}
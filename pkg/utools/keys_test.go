@@ -0,0 +1,127 @@
+package utools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// keyServer answers every request with the x-rate-limit-remaining of the
+// request's apiKey and records the keys used.
+func keyServer(t *testing.T, remaining map[string]string, used *[]string) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("apiKey")
+		*used = append(*used, key)
+		w.Header().Set("x-rate-limit-remaining", remaining[key])
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestWithAPIKeysMostRemaining(t *testing.T) {
+	var used []string
+	ts := keyServer(t, map[string]string{"low": "3", "high": "450"}, &used)
+	client := newTestClient(t, ts.URL)
+	WithAPIKeys(KeyMostRemaining, "low", "high")(client)
+
+	for i := 0; i < 5; i++ {
+		var out json.RawMessage
+		if err := client.Get(context.Background(), "/userByScreenNameV2", nil, &out); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	// Both keys are tried once, then the one reporting more quota wins.
+	if got := strings.Join(used, ","); got != "low,high,high,high,high" {
+		t.Fatalf("unexpected key order: %s", got)
+	}
+}
+
+func TestWithAPIKeysRoundRobinAndLeastRecentlyUsed(t *testing.T) {
+	for _, tc := range []struct {
+		selector KeySelector
+		want     string
+	}{
+		{KeyRoundRobin, "a,b,c,a"},
+		{KeyLeastRecentlyUsed, "a,b,c,a"},
+	} {
+		var used []string
+		ts := keyServer(t, nil, &used)
+		client := newTestClient(t, ts.URL)
+		WithAPIKeys(tc.selector, "a", "", "b", "c")(client)
+		for i := 0; i < 4; i++ {
+			if _, err := client.GetRaw(context.Background(), "/userByScreenNameV2", nil); err != nil {
+				t.Fatalf("selector %d: request %d: %v", tc.selector, i, err)
+			}
+		}
+		if got := strings.Join(used, ","); got != tc.want {
+			t.Fatalf("selector %d: expected keys %s, got %s", tc.selector, tc.want, got)
+		}
+	}
+}
+
+func TestWithAPIKeysRateLimitedKeyIsAvoided(t *testing.T) {
+	var used []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("apiKey")
+		used = append(used, key)
+		if key == "busy" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+	WithBackoff(ConstantBackoff{Delay: 0})(client)
+	WithAPIKeys(KeyMostRemaining, "busy", "idle")(client)
+
+	var out json.RawMessage
+	if err := client.Get(context.Background(), "/userByScreenNameV2", nil, &out); err != nil {
+		t.Fatalf("expected the retry to succeed on the other key: %v", err)
+	}
+	if got := strings.Join(used, ","); got != "busy,idle" {
+		t.Fatalf("unexpected key order: %s", got)
+	}
+}
+
+func TestKeyPoolMostRemainingSpreadsConcurrentFirstPicks(t *testing.T) {
+	var client Client
+	WithAPIKeys(KeyMostRemaining, "a", "b", "c")(&client)
+	pool := client.keys
+
+	// No response has reported a quota yet, as with concurrent first requests.
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	picked := map[string]int{}
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := pool.pick()
+			mu.Lock()
+			picked[key]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(picked) != 3 {
+		t.Fatalf("expected the in-flight picks to use every key once, got %v", picked)
+	}
+
+	// A reported quota still ranks below one not known yet.
+	pool.observe("a", http.StatusOK, http.Header{"X-Rate-Limit-Remaining": {"400"}})
+	pool.observe("b", http.StatusOK, http.Header{"X-Rate-Limit-Remaining": {"9"}})
+	if got := pool.pick(); got != "c" {
+		t.Fatalf("expected the key of unknown quota, got %s", got)
+	}
+	pool.observe("c", http.StatusOK, http.Header{"X-Rate-Limit-Remaining": {"5"}})
+	if got := pool.pick(); got != "a" {
+		t.Fatalf("expected the key with the most quota, got %s", got)
+	}
+}
@@ -0,0 +1,78 @@
+package utools
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// endpointParamKeys declares, per endpoint, the preferred key of its ID param
+// followed by the aliases older deployments accepted. Unless
+// Config.StrictParamKeys is set, every key is sent so the request works
+// against both old and new gateways.
+var endpointParamKeys = map[string][]string{
+	"/tweetTimeline": {"tweetId", "tweet_id", "id"},
+	"/tweetSimple":   {"tweetId", "tweet_id", "tweetIds", "id"},
+	"/retweetersIds": {"tweetId", "tweet_id", "id"},
+	"/trends":        {"id", "woeid"},
+}
+
+// setIDParam stores value under the ID param key(s) registered for path,
+// or under key, the caller's own preferred key, when path has none
+// registered, so an unregistered endpoint still gets its ID.
+func (c *Client) setIDParam(params map[string]string, path, key, value string) {
+	keys := endpointParamKeys[path]
+	if len(keys) == 0 {
+		params[key] = value
+		return
+	}
+	if c.strictParamKeys {
+		keys = keys[:1]
+	}
+	for _, k := range keys {
+		params[k] = value
+	}
+}
+
+// endpointPathAliases declares, per endpoint, the other names upstream
+// deployments have served it under, tried in order after the preferred path
+// when it is missing. See getWithPathFallback.
+var endpointPathAliases = map[string][]string{
+	"/userArticlesTweets": {"/userArticlesTweetsV2", "/userArticleTweets"},
+	"/highlightsV2":       {"/highlights"},
+	"/similarUsersV2":     {"/similarUsers"},
+	"/trendsAvailable":    {"/availableTrends"},
+	"/trendsClosest":      {"/closestTrends"},
+}
+
+// endpointPaths returns path followed by its registered aliases.
+func endpointPaths(path string) []string {
+	return append([]string{path}, endpointPathAliases[path]...)
+}
+
+// shouldRetryWithNextEndpoint reports whether err suggests the endpoint is
+// not deployed under the requested name (see isEndpointNotDeployed) or the
+// gateway failed with a 5xx, so the next registered alias is worth trying.
+func shouldRetryWithNextEndpoint(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode >= 500 || isEndpointNotDeployed(err)
+}
+
+// isEndpointNotDeployed reports whether err says the endpoint does not
+// exist under the requested name: a 404, or the gateway's "No static
+// resource" body. Business errors such as "User not found" from a deployed
+// path do not count.
+func isEndpointNotDeployed(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode == http.StatusNotFound {
+		return true
+	}
+	msg := strings.ToLower(apiErr.Message + " " + apiErr.RawBody)
+	return strings.Contains(msg, "no static resource")
+}
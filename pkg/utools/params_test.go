@@ -0,0 +1,79 @@
+package utools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestIDParamAliases(t *testing.T) {
+	type tc struct {
+		name     string
+		strict   bool
+		call     func(c *Client) error
+		wantKeys []string
+		noKeys   []string
+	}
+
+	detail := func(c *Client) error {
+		_, err := c.GetTweetDetail(context.Background(), "456", "")
+		return err
+	}
+	trends := func(c *Client) error {
+		_, err := c.GetTrends(context.Background(), "1")
+		return err
+	}
+
+	cases := []tc{
+		{name: "GetTweetDetail legacy", call: detail, wantKeys: []string{"tweetId", "tweet_id", "id"}},
+		{name: "GetTweetDetail strict", strict: true, call: detail, wantKeys: []string{"tweetId"}, noKeys: []string{"tweet_id", "id"}},
+		{name: "GetTrends legacy", call: trends, wantKeys: []string{"id", "woeid"}},
+		{name: "GetTrends strict", strict: true, call: trends, wantKeys: []string{"id"}, noKeys: []string{"woeid"}},
+	}
+
+	for _, cse := range cases {
+		t.Run(cse.name, func(t *testing.T) {
+			var query url.Values
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Query()
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code":1,"data":{"ok":true},"msg":"SUCCESS"}`))
+			}))
+			defer ts.Close()
+
+			client := newTestClient(t, ts.URL)
+			client.strictParamKeys = cse.strict
+			if err := cse.call(client); err != nil {
+				t.Fatalf("call returned error: %v", err)
+			}
+			for _, k := range cse.wantKeys {
+				if query.Get(k) == "" {
+					t.Fatalf("expected %s in query, got %v", k, query)
+				}
+			}
+			for _, k := range cse.noKeys {
+				if query.Has(k) {
+					t.Fatalf("expected %s to be omitted, got %v", k, query)
+				}
+			}
+			// apiKey plus the ID keys only.
+			if got, want := len(query), len(cse.wantKeys)+1; got != want {
+				t.Fatalf("expected %d query params, got %d: %v", want, got, query)
+			}
+		})
+	}
+}
+
+func TestSetIDParamUnregisteredPath(t *testing.T) {
+	client := newTestClient(t, "http://example.com")
+	for _, strict := range []bool{false, true} {
+		client.strictParamKeys = strict
+		params := map[string]string{}
+		client.setIDParam(params, "/notRegistered", "tweetId", "1")
+		if len(params) != 1 || params["tweetId"] != "1" {
+			t.Fatalf("strict=%v: expected the supplied key for an unregistered path, got %v", strict, params)
+		}
+	}
+}
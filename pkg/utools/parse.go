@@ -0,0 +1,1301 @@
+package utools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// ============================================================
+// Typed parsers
+//
+// The upstream returns either the X GraphQL timeline shape (results nested
+// under "user_results" / "tweet_results" inside timeline entries) or the
+// legacy REST shape (flat objects with id_str / screen_name). The parsers
+// below accept both so callers do not need to know which one an endpoint
+// currently serves.
+// ============================================================
+
+// maxParseDepth bounds recursion when walking arbitrary response JSON.
+const maxParseDepth = 64
+
+// userNodePaths are the locations of the user node in single-profile responses.
+var userNodePaths = []string{
+	"data.user.result",
+	"data.user_result.result",
+	"data.user_results.result",
+	"user.result",
+	"user_results.result",
+	"result",
+}
+
+// ParseUserProfile extracts a single user from a profile response
+// (userByScreenNameV2, getUserByIdOrNameShow, ...). The returned user's
+// Status is AccountActive or AccountProtected. Unavailable accounts are
+// reported as ErrUserSuspended, ErrUserDeactivated or ErrUserNotFound.
+func ParseUserProfile(raw json.RawMessage) (*UserResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse user profile: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	node, ok := userNode(root)
+	if !ok {
+		return nil, unavailableUserError(root)
+	}
+	if node.Get("__typename").String() == "UserUnavailable" {
+		return nil, unavailableUserError(node)
+	}
+	u, ok := parseUserNode(node)
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// handleRedirectPaths are the fields through which a profile response for a
+// renamed handle may point to the new one, as a bare handle, "@handle" or a
+// profile URL.
+var handleRedirectPaths = []string{
+	"redirect_screen_name", "new_screen_name", "redirect_to", "redirect",
+	"data.redirect_screen_name", "data.new_screen_name", "data.redirect_to", "data.redirect",
+}
+
+// handleRedirect reports whether a profile response for the requested handle
+// belongs to a renamed account, returning its new handle. inline is true
+// when the response already carries that account (the upstream resolved the
+// old handle itself) rather than only pointing to it.
+func handleRedirect(raw json.RawMessage, requested string) (newHandle string, inline bool) {
+	requested = strings.TrimPrefix(requested, "@")
+	if requested == "" {
+		return "", false
+	}
+	root := gjson.ParseBytes(raw)
+	for _, path := range handleRedirectPaths {
+		if v := root.Get(path); v.Type == gjson.String {
+			if h := handleFromRedirect(v.Str); h != "" && !strings.EqualFold(h, requested) {
+				return h, false
+			}
+		}
+	}
+	if node, ok := userNode(root); ok {
+		if u, ok := parseUserNode(node); ok && u.ScreenName != "" && !strings.EqualFold(u.ScreenName, requested) {
+			return u.ScreenName, true
+		}
+	}
+	return "", false
+}
+
+// handleFromRedirect extracts the handle from "handle", "@handle" or a
+// profile URL such as "https://x.com/handle?s=20".
+func handleFromRedirect(v string) string {
+	v, _, _ = strings.Cut(strings.TrimSpace(v), "?")
+	v = strings.TrimRight(v, "/")
+	if i := strings.LastIndexByte(v, '/'); i >= 0 {
+		v = v[i+1:]
+	}
+	return strings.TrimPrefix(v, "@")
+}
+
+// unavailableUserError classifies why a profile response holds no user, from
+// a UserUnavailable node ({"reason":"Suspended", "message":...}) or the
+// legacy {"errors":[{"code":63,...}]} list. Anything unrecognised is
+// ErrUserNotFound.
+func unavailableUserError(node gjson.Result) error {
+	var texts []string
+	for _, p := range []string{"reason", "message", "unavailable_message.text"} {
+		texts = append(texts, node.Get(p).String())
+	}
+	for _, errNode := range []gjson.Result{node.Get("errors"), node.Get("data.errors")} {
+		for _, e := range errNode.Array() {
+			switch e.Get("code").Int() {
+			case 63: // "User has been suspended."
+				return ErrUserSuspended
+			case 50: // "User not found."
+				return ErrUserNotFound
+			}
+			texts = append(texts, e.Get("message").String())
+		}
+	}
+
+	text := strings.ToLower(strings.Join(texts, " "))
+	switch {
+	case strings.Contains(text, "suspend"):
+		return ErrUserSuspended
+	case strings.Contains(text, "deactivat"):
+		return ErrUserDeactivated
+	}
+	return ErrUserNotFound
+}
+
+// ParseUserProfileExpanded extracts the user from a profile response together
+// with the pinned tweet and recent tweets the V2 endpoint may inline (see
+// GetUserProfileExpanded). Missing extras leave PinnedTweet nil and
+// RecentTweets empty; only a missing user is an error (ErrUserNotFound).
+func ParseUserProfileExpanded(raw json.RawMessage) (*ExpandedProfile, error) {
+	user, err := ParseUserProfile(raw)
+	if err != nil {
+		return nil, err
+	}
+	root := gjson.ParseBytes(raw)
+	node, _ := userNode(root)
+	p := &ExpandedProfile{User: *user, RecentTweets: []TweetResult{}}
+
+	for _, v := range []gjson.Result{
+		node.Get("pinned_tweet_results.result"),
+		node.Get("pinned_tweet"),
+		root.Get("pinned_tweet"),
+		root.Get("data.pinned_tweet"),
+	} {
+		if t, ok := parseTweetNode(v); ok {
+			p.PinnedTweet = &t
+			break
+		}
+	}
+
+	for _, list := range []gjson.Result{
+		node.Get("recent_tweets"),
+		root.Get("recent_tweets"),
+		root.Get("data.recent_tweets"),
+	} {
+		if !list.IsArray() {
+			continue
+		}
+		list.ForEach(func(_, item gjson.Result) bool {
+			if r := item.Get("tweet_results.result"); r.Exists() {
+				item = r
+			}
+			if t, ok := parseTweetNode(item); ok {
+				p.RecentTweets = append(p.RecentTweets, t)
+			}
+			return true
+		})
+		break
+	}
+	return p, nil
+}
+
+// userNode locates the user in a single-profile response. ok is false when
+// the response holds no user node at all.
+func userNode(root gjson.Result) (gjson.Result, bool) {
+	for _, p := range userNodePaths {
+		if node := root.Get(p); node.IsObject() {
+			return node, true
+		}
+	}
+
+	// Legacy REST shape: the user object itself, optionally wrapped in
+	// "user" / "data", or a one-element array from the lookup endpoints.
+	for _, node := range []gjson.Result{root, root.Get("user"), root.Get("data"), root.Get("0")} {
+		if node.IsObject() && (node.Get("screen_name").Exists() || node.Get("legacy").Exists()) {
+			return node, true
+		}
+	}
+	return gjson.Result{}, false
+}
+
+// tweetNodePaths are the locations of the tweet node in single-tweet responses.
+var tweetNodePaths = []string{
+	"data.tweetResult.result",
+	"data.tweet_result.result",
+	"data.tweet_results.result",
+	"tweetResult.result",
+	"tweet_result.result",
+	"tweet_results.result",
+	"result",
+}
+
+// tweetNode locates the tweet in a single-tweet response (tweetSimple and
+// friends), unwrapping TweetWithVisibilityResults. ok is false when the
+// response holds no tweet or the tweet is unavailable.
+func tweetNode(root gjson.Result) (gjson.Result, bool) {
+	for _, p := range tweetNodePaths {
+		if node := root.Get(p); node.IsObject() {
+			if node.Get("__typename").String() == "TweetWithVisibilityResults" {
+				node = node.Get("tweet")
+			}
+			switch node.Get("__typename").String() {
+			case "TweetUnavailable", "TweetTombstone":
+				return gjson.Result{}, false
+			}
+			return node, node.IsObject()
+		}
+	}
+	// Legacy REST shape: the status object itself, optionally wrapped.
+	for _, node := range []gjson.Result{root, root.Get("tweet"), root.Get("data"), root.Get("0")} {
+		if node.IsObject() && (node.Get("id_str").Exists() || node.Get("legacy").Exists() || node.Get("rest_id").Exists()) {
+			return node, true
+		}
+	}
+	return gjson.Result{}, false
+}
+
+// ParseTweetsInOrder extracts the tweets of a batch response
+// (tweetResultsByRestIds), which come back in no particular order, and lines
+// them up with ids: result[i] is the tweet with ID ids[i], or nil when the
+// response does not hold it (deleted, protected or tombstoned). Repeated IDs
+// share the same tweet.
+func ParseTweetsInOrder(raw json.RawMessage, ids []string) ([]*TweetResult, error) {
+	tweets, err := ParseTweetTimeline(raw)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*TweetResult, len(tweets))
+	for i := range tweets {
+		if _, dup := byID[tweets[i].ID]; !dup {
+			byID[tweets[i].ID] = &tweets[i]
+		}
+	}
+	ordered := make([]*TweetResult, len(ids))
+	for i, id := range ids {
+		ordered[i] = byID[id]
+	}
+	return ordered, nil
+}
+
+// ParseTweetTimeline extracts the tweets of a timeline-style response
+// (user tweets, tweet detail, search, ...). Unavailable tweets and
+// promoted tweets (ads) are skipped; quoted and retweeted tweets are
+// attached to their parent rather than listed separately. Order follows the
+// response.
+func ParseTweetTimeline(raw json.RawMessage) ([]TweetResult, error) {
+	return parseTweetTimeline(raw, walkTweetResults, parseTweetNode, false)
+}
+
+// parseTweetTimeline implements ParseTweetTimeline, with walk locating the
+// tweet_results nodes and parse converting each of them. Ads are kept,
+// marked Promoted, when includeAds is set.
+func parseTweetTimeline(raw json.RawMessage, walk func(gjson.Result, func(gjson.Result)), parse func(gjson.Result) (TweetResult, bool), includeAds bool) ([]TweetResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse tweet timeline: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	tweets := []TweetResult{}
+
+	var promoted map[int]bool
+	if bytes.Contains(raw, []byte(`"`+promotedMetadataKey+`"`)) {
+		promoted = make(map[int]bool)
+		walkPromotedTweets(root, 0, promoted)
+	}
+	walk(root, func(node gjson.Result) {
+		t, ok := parse(node)
+		if !ok {
+			return
+		}
+		if promoted[node.Index] {
+			if !includeAds {
+				return
+			}
+			t.Promoted = true
+		}
+		tweets = append(tweets, t)
+	})
+	if len(tweets) > 0 {
+		return tweets, nil
+	}
+
+	// Batch lookups ({"data":{"tweetResult":[{"result":{...}}]}}), the legacy
+	// REST shape ({"tweets":[...]}, {"statuses":[...]}) or a bare array.
+	var list gjson.Result
+	for _, p := range []string{"data.tweetResult", "data.tweetResults", "tweetResult", "tweets", "statuses"} {
+		if list = root.Get(p); list.IsArray() {
+			break
+		}
+	}
+	if !list.IsArray() && root.IsArray() {
+		list = root
+	}
+	list.ForEach(func(_, item gjson.Result) bool {
+		if r := item.Get("result"); r.IsObject() {
+			item = r
+		}
+		if t, ok := parse(item); ok {
+			tweets = append(tweets, t)
+		}
+		return true
+	})
+	return tweets, nil
+}
+
+// promotedMetadataKey is the itemContent member that marks an ad.
+const promotedMetadataKey = "promotedMetadata"
+
+// walkPromotedTweets records in promoted the raw offsets of the tweet nodes
+// of ads: the tweet_results.result of an itemContent that also carries
+// promotedMetadata.
+func walkPromotedTweets(value gjson.Result, depth int, promoted map[int]bool) {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
+		return
+	}
+	if value.IsObject() && value.Get(promotedMetadataKey).Exists() {
+		if result := value.Get("tweet_results.result"); result.IsObject() {
+			promoted[result.Index] = true
+		}
+	}
+	value.ForEach(func(_, child gjson.Result) bool {
+		walkPromotedTweets(child, depth+1, promoted)
+		return true
+	})
+}
+
+// viewCountPaths are the places a tweet node carries its view count, newest
+// shape first.
+var viewCountPaths = []string{
+	"views.count",
+	"legacy.ext_views.count",
+	"ext_views.count",
+	"ext_views.r.ok.count",
+	"view_count",
+	"legacy.view_count",
+}
+
+// ParseTweetViews extracts the view count of the tweet in a single-tweet
+// response. The count may be a number or a numeric string. It returns
+// ErrViewsUnavailable when the tweet has no view count (protected, old or
+// unavailable tweets).
+func ParseTweetViews(raw json.RawMessage) (int64, error) {
+	if !json.Valid(raw) {
+		return 0, fmt.Errorf("utools: parse tweet views: invalid JSON")
+	}
+	node, ok := tweetNode(gjson.ParseBytes(raw))
+	if !ok {
+		return 0, ErrViewsUnavailable
+	}
+	for _, p := range viewCountPaths {
+		if n, ok := parseCount(node.Get(p)); ok {
+			return n, nil
+		}
+	}
+	return 0, ErrViewsUnavailable
+}
+
+// ParseTweetDetail splits one page of a tweetDetail / tweetTimeline response
+// into the focal tweet tweetID and its replies (the other tweets posted
+// after it, in response order), with the page's next cursor. When the focal
+// tweet is a tombstone, the result is still returned, with Tombstoned set,
+// together with an error matching ErrTweetNotFound: ErrTweetDeleted,
+// ErrTweetAuthorSuspended, ErrTweetBlocked or ErrTweetProtected when the
+// tombstone says why. A focal tweet that is simply absent gives
+// ErrTweetNotFound and no result.
+func ParseTweetDetail(raw json.RawMessage, tweetID string) (*TweetDetailResult, error) {
+	tweets, err := ParseTweetTimeline(raw)
+	if err != nil {
+		return nil, err
+	}
+	detail := &TweetDetailResult{Replies: []TweetResult{}}
+	detail.NextCursor, _ = extractCursors(string(raw))
+	found := false
+	for _, t := range tweets {
+		switch {
+		case t.ID == tweetID && !found:
+			detail.Tweet, found = t, true
+		case compareIDs(t.ID, tweetID) > 0:
+			detail.Replies = append(detail.Replies, t)
+		}
+	}
+	if found {
+		return detail, nil
+	}
+	if err := focalTombstone(gjson.ParseBytes(raw), tweetID); err != nil {
+		detail.Tombstoned = true
+		return detail, err
+	}
+	return nil, ErrTweetNotFound
+}
+
+// focalTombstone looks for tweetID's timeline entry ("tweet-<id>") holding a
+// TweetTombstone or TweetUnavailable result, and returns the error that
+// describes it, or nil when there is none.
+func focalTombstone(root gjson.Result, tweetID string) error {
+	var tombErr error
+	walkEntries(root, 0, func(entry gjson.Result) {
+		if tombErr != nil || entry.Get("entryId").String() != "tweet-"+tweetID {
+			return
+		}
+		node := entry.Get("content.itemContent.tweet_results.result")
+		switch node.Get("__typename").String() {
+		case "TweetTombstone", "TweetUnavailable":
+			tombErr = tombstoneError(firstString(node, "tombstone.text.text", "reason", "unavailable_reason"))
+		}
+	})
+	return tombErr
+}
+
+// tombstoneError maps a tombstone's text (or a TweetUnavailable reason) to
+// the matching ErrTweetNotFound refinement.
+func tombstoneError(text string) error {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "blocked"):
+		return ErrTweetBlocked
+	case strings.Contains(text, "suspended"):
+		return ErrTweetAuthorSuspended
+	case strings.Contains(text, "limits who can view") || strings.Contains(text, "protected"):
+		return ErrTweetProtected
+	case strings.Contains(text, "deleted") || strings.Contains(text, "no longer exists"):
+		return ErrTweetDeleted
+	}
+	return ErrTweetNotFound
+}
+
+// ParseTweetSimple extracts the tweet of a tweetSimple response. GraphQL
+// payloads are parsed like timeline tweets; the endpoint's flat brief shape is
+// read field by field, so numeric IDs, string-encoded counts and an "author"
+// object in place of "user" are accepted. It returns ErrTweetNotFound when
+// the response holds no available tweet.
+func ParseTweetSimple(raw json.RawMessage) (*TweetResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse tweet simple: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	if node, ok := tweetNode(root); ok {
+		if node.Get("legacy").IsObject() {
+			if t, ok := parseTweetNode(node); ok {
+				return &t, nil
+			}
+			return nil, ErrTweetNotFound
+		}
+		if t, ok := parseSimpleTweetNode(node); ok {
+			return &t, nil
+		}
+	}
+	// Brief payloads may carry only a numeric "id", which tweetNode does not
+	// recognise.
+	for _, node := range []gjson.Result{root, root.Get("tweet"), root.Get("data")} {
+		if t, ok := parseSimpleTweetNode(node); ok {
+			return &t, nil
+		}
+	}
+	return nil, ErrTweetNotFound
+}
+
+// simpleTweetCounts maps TweetResult count fields to the keys the flat
+// tweetSimple shape may use for them.
+var simpleTweetCounts = []struct {
+	keys []string
+	set  func(*TweetResult, int)
+}{
+	{[]string{"favorite_count", "like_count", "likes"}, func(t *TweetResult, n int) { t.FavoriteCount = n }},
+	{[]string{"retweet_count", "retweets"}, func(t *TweetResult, n int) { t.RetweetCount = n }},
+	{[]string{"reply_count", "replies"}, func(t *TweetResult, n int) { t.ReplyCount = n }},
+	{[]string{"quote_count", "quotes"}, func(t *TweetResult, n int) { t.QuoteCount = n }},
+	{[]string{"bookmark_count", "bookmarks"}, func(t *TweetResult, n int) { t.BookmarkCount = n }},
+}
+
+// parseSimpleTweetNode reads a flat tweet object without relying on exact
+// JSON types. ok is false when node has no ID or no text.
+func parseSimpleTweetNode(node gjson.Result) (TweetResult, bool) {
+	var t TweetResult
+	if !node.IsObject() {
+		return t, false
+	}
+	t.ID = firstString(node, "id_str", "rest_id", "id", "tweet_id")
+	t.RestID = t.ID
+	t.FullText = node.Get("full_text").String()
+	t.Text = node.Get("text").String()
+	if t.ID == "" || t.GetText() == "" {
+		return TweetResult{}, false
+	}
+
+	t.CreatedAt = node.Get("created_at").String()
+	t.Lang = node.Get("lang").String()
+	t.Source = node.Get("source").String()
+	t.ConversationIDStr = firstString(node, "conversation_id_str", "conversation_id")
+	t.InReplyToStatusID = firstString(node, "in_reply_to_status_id_str", "in_reply_to_status_id")
+	t.InReplyToUserID = firstString(node, "in_reply_to_user_id_str", "in_reply_to_user_id")
+	t.InReplyToScreenName = node.Get("in_reply_to_screen_name").String()
+	t.PossiblySensitive = node.Get("possibly_sensitive").Bool()
+	for _, c := range node.Get("withheld_in_countries").Array() {
+		t.WithheldInCountries = append(t.WithheldInCountries, c.String())
+	}
+	t.WithheldScope = node.Get("withheld_scope").String()
+	if p := node.Get("conversation_control.policy").String(); p != "" {
+		t.ConversationControl = &ConversationControl{Policy: p}
+	}
+	t.LimitedActions = node.Get("limited_actions").String()
+	for _, c := range simpleTweetCounts {
+		if n, ok := parseCount(firstNonEmpty(node, c.keys...)); ok {
+			c.set(&t, int(n))
+		}
+	}
+	for _, p := range append([]string{"views"}, viewCountPaths...) {
+		if n, ok := parseCount(node.Get(p)); ok {
+			t.ViewCount = strconv.FormatInt(n, 10)
+			break
+		}
+	}
+
+	for _, p := range []string{"user", "author", "core.user_results.result"} {
+		if u, ok := parseUserNode(node.Get(p)); ok {
+			t.User = &u
+			break
+		}
+	}
+	t.UserIDStr = firstString(node, "user_id_str", "author_id", "user_id")
+	if t.UserIDStr == "" && t.User != nil {
+		t.UserIDStr = t.User.RestID
+	}
+	return t, true
+}
+
+// parseCount reads a count that the API may encode either as a JSON number
+// or as a string (optionally with thousands separators).
+func parseCount(v gjson.Result) (int64, bool) {
+	switch v.Type {
+	case gjson.Number:
+		return v.Int(), true
+	case gjson.String:
+		n, err := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(v.String()), ",", ""), 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// ParseUserList extracts the users contained in a followers / followings /
+// members style response. Unavailable users are skipped. Order follows the
+// response.
+func ParseUserList(raw json.RawMessage) ([]UserResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse user list: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	users := []UserResult{}
+
+	walkResultNodes(root, "user_results", 0, func(node gjson.Result) {
+		if u, ok := parseUserNode(node); ok {
+			users = append(users, u)
+		}
+	})
+	if len(users) > 0 {
+		return users, nil
+	}
+
+	// Legacy REST shape: {"users":[{...}]} or a bare array of users.
+	list := root.Get("users")
+	if !list.IsArray() && root.IsArray() {
+		list = root
+	}
+	list.ForEach(func(_, item gjson.Result) bool {
+		if u, ok := parseUserNode(item); ok {
+			users = append(users, u)
+		}
+		return true
+	})
+	return users, nil
+}
+
+// ParseTypeahead parses a search box response: {"users":[...],
+// "topics":[{"topic"}], "hashtags":[{"hashtag"}], "events":[...]}, possibly
+// wrapped in "data". Hashtag suggestions are merged into Topics (with their
+// "#"); categories the response omits are empty slices.
+func ParseTypeahead(raw json.RawMessage) (*TypeaheadResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse typeahead: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	if data := root.Get("data"); data.IsObject() {
+		root = data
+	}
+
+	res := &TypeaheadResult{Users: []UserResult{}, Topics: []string{}, Events: []TypeaheadEvent{}}
+	root.Get("users").ForEach(func(_, item gjson.Result) bool {
+		if u, ok := parseUserNode(item); ok {
+			res.Users = append(res.Users, u)
+		}
+		return true
+	})
+	addTopic := func(topic string) {
+		if topic != "" && !slices.Contains(res.Topics, topic) {
+			res.Topics = append(res.Topics, topic)
+		}
+	}
+	root.Get("topics").ForEach(func(_, item gjson.Result) bool {
+		if item.Type == gjson.String {
+			addTopic(item.String())
+		} else {
+			addTopic(item.Get("topic").String())
+		}
+		return true
+	})
+	root.Get("hashtags").ForEach(func(_, item gjson.Result) bool {
+		tag := item.String()
+		if item.IsObject() {
+			tag = item.Get("hashtag").String()
+		}
+		if tag != "" && !strings.HasPrefix(tag, "#") {
+			tag = "#" + tag
+		}
+		addTopic(tag)
+		return true
+	})
+	root.Get("events").ForEach(func(_, item gjson.Result) bool {
+		ev := TypeaheadEvent{
+			Topic:          firstString(item, "topic", "title"),
+			URL:            item.Get("url").String(),
+			SupportingText: item.Get("supporting_text").String(),
+		}
+		if ev.Topic != "" || ev.URL != "" {
+			res.Events = append(res.Events, ev)
+		}
+		return true
+	})
+	return res, nil
+}
+
+// ParseLists extracts the lists of a lists response: the REST shape (a bare
+// array of lists, or one wrapped in "lists" or "data") or GraphQL timeline
+// items carrying a "list" object. Mode is lower-cased; CreatedAt is kept as
+// sent (a Twitter date or Unix milliseconds). A user without lists yields an
+// empty slice.
+func ParseLists(raw json.RawMessage) ([]ListResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse lists: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	lists := []ListResult{}
+	add := func(item gjson.Result) {
+		if l, ok := parseListNode(item); ok {
+			lists = append(lists, l)
+		}
+	}
+
+	list := root
+	for _, path := range []string{"lists", "data"} {
+		if !list.IsArray() && root.Get(path).IsArray() {
+			list = root.Get(path)
+		}
+	}
+	if list.IsArray() {
+		list.ForEach(func(_, item gjson.Result) bool {
+			add(item)
+			return true
+		})
+		return lists, nil
+	}
+	walkListNodes(root, 0, add)
+	return lists, nil
+}
+
+// walkListNodes calls fn for every object found under a "list" key, without
+// descending into matched nodes.
+func walkListNodes(value gjson.Result, depth int, fn func(gjson.Result)) {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
+		return
+	}
+	value.ForEach(func(k, child gjson.Result) bool {
+		if value.IsObject() && k.String() == "list" && child.IsObject() {
+			fn(child)
+			return true
+		}
+		walkListNodes(child, depth+1, fn)
+		return true
+	})
+}
+
+// parseListNode converts a single list node into a ListResult. It accepts
+// the REST shape (id_str, user) and the GraphQL one (id_string,
+// user_results). ok is false for nodes without an ID.
+func parseListNode(node gjson.Result) (ListResult, bool) {
+	l := ListResult{
+		ID:          firstString(node, "id_str", "id_string"),
+		Name:        node.Get("name").String(),
+		Slug:        node.Get("slug").String(),
+		Description: node.Get("description").String(),
+		Mode:        strings.ToLower(node.Get("mode").String()),
+		CreatedAt:   node.Get("created_at").String(),
+	}
+	if l.ID == "" {
+		if id := node.Get("id"); id.Type == gjson.Number {
+			l.ID = id.Raw
+		}
+	}
+	l.MemberCount, _ = parseCount(node.Get("member_count"))
+	l.SubscriberCount, _ = parseCount(node.Get("subscriber_count"))
+	owner := node.Get("user")
+	if !owner.IsObject() {
+		owner = node.Get("user_results.result")
+	}
+	if u, ok := parseUserNode(owner); ok {
+		l.Owner = &u
+	}
+	return l, l.ID != ""
+}
+
+// ParseCommunity extracts the community of a community info response: the
+// first {"__typename":"Community"} object (as in
+// data.communityResults.result), or the response itself when it is a bare
+// community. JoinPolicy is lower-cased; rules keep their upstream order.
+func ParseCommunity(raw json.RawMessage) (*CommunityResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse community: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	var node gjson.Result
+	walkTypename(root, "Community", 0, func(n gjson.Result) {
+		if !node.Exists() {
+			node = n
+		}
+	})
+	if !node.Exists() && root.Get("rest_id").Exists() {
+		node = root
+	}
+	if !node.Exists() {
+		return nil, fmt.Errorf("utools: parse community: no community in response")
+	}
+
+	c := &CommunityResult{
+		ID:           firstString(node, "rest_id", "id_str"),
+		Name:         node.Get("name").String(),
+		Description:  node.Get("description").String(),
+		CreatedAt:    node.Get("created_at").String(),
+		JoinPolicy:   strings.ToLower(node.Get("join_policy").String()),
+		PrimaryTopic: node.Get("primary_community_topic.topic_name").String(),
+		Rules:        []CommunityRule{},
+	}
+	c.MemberCount, _ = parseCount(node.Get("member_count"))
+	c.ModeratorCount, _ = parseCount(node.Get("moderator_count"))
+	node.Get("rules").ForEach(func(_, rule gjson.Result) bool {
+		if name := rule.Get("name").String(); name != "" {
+			c.Rules = append(c.Rules, CommunityRule{Name: name, Description: rule.Get("description").String()})
+		}
+		return true
+	})
+	if u, ok := parseUserNode(node.Get("admin_results.result")); ok {
+		c.Admin = &u
+	}
+	if u, ok := parseUserNode(node.Get("creator_results.result")); ok {
+		c.Creator = &u
+	}
+	return c, nil
+}
+
+// walkTypename calls fn for every object whose __typename is typename,
+// without descending into matched objects.
+func walkTypename(value gjson.Result, typename string, depth int, fn func(gjson.Result)) {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
+		return
+	}
+	if value.IsObject() && value.Get("__typename").String() == typename {
+		fn(value)
+		return
+	}
+	value.ForEach(func(_, child gjson.Result) bool {
+		walkTypename(child, typename, depth+1, fn)
+		return true
+	})
+}
+
+// ParseTrends extracts the trends of a trends response: the REST shape
+// ([{"trends":[...],"locations":[...]}] or {"trends":[...]}), a bare array of
+// trends, or GraphQL timeline items of type TimelineTrend. tweet_volume may
+// be null, a number or a numeric string; missing volumes are 0.
+func ParseTrends(raw json.RawMessage) ([]TrendResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse trends: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	trends := []TrendResult{}
+
+	list := root.Get("trends")
+	if !list.IsArray() {
+		list = root.Get("0.trends")
+	}
+	if !list.IsArray() && root.IsArray() && root.Get("0.name").Exists() {
+		list = root
+	}
+	if list.IsArray() {
+		list.ForEach(func(_, item gjson.Result) bool {
+			t := TrendResult{
+				Name:  item.Get("name").String(),
+				Query: item.Get("query").String(),
+				URL:   item.Get("url").String(),
+			}
+			if n, ok := parseCount(item.Get("tweet_volume")); ok {
+				t.TweetCount = int(n)
+			}
+			if t.Name != "" {
+				trends = append(trends, t)
+			}
+			return true
+		})
+		return trends, nil
+	}
+
+	walkTimelineTrends(root, 0, func(item gjson.Result) {
+		trends = append(trends, parseTimelineTrend(item))
+	})
+	return trends, nil
+}
+
+// newsListPaths are the locations of the story list in a flat news response;
+// a bare array is accepted too.
+var newsListPaths = []string{"news", "items", "articles", "data"}
+
+// ParseNews extracts the stories of a news response: a flat list of
+// {"title","summary","url","source","published_at"} objects (or common
+// aliases), or the GraphQL timeline items of type TimelineEventSummary,
+// wherever they are nested in modules.
+func ParseNews(raw json.RawMessage) ([]NewsItem, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse news: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	news := []NewsItem{}
+
+	list := root
+	for _, path := range newsListPaths {
+		if list.IsArray() {
+			break
+		}
+		list = root.Get(path)
+	}
+	if list.IsArray() {
+		list.ForEach(func(_, item gjson.Result) bool {
+			if n := parseNewsItem(item); n.Title != "" {
+				news = append(news, n)
+			}
+			return true
+		})
+		return news, nil
+	}
+
+	walkTimelineItems(root, 0, func(item gjson.Result) {
+		if timelineItemType(item) == "TimelineEventSummary" {
+			news = append(news, parseNewsItem(item))
+		}
+	})
+	return news, nil
+}
+
+// parseNewsItem converts a flat story or a TimelineEventSummary item.
+func parseNewsItem(item gjson.Result) NewsItem {
+	return NewsItem{
+		Title:       firstString(item, "title", "headline", "name"),
+		Summary:     firstString(item, "summary", "description", "supportingText", "supporting_text"),
+		URL:         firstString(item, "url.url", "url", "link"),
+		Source:      firstString(item, "source.name", "source", "domain", "attribution"),
+		PublishedAt: firstString(item, "published_at", "publishedAt", "timeString", "created_at"),
+	}
+}
+
+// ParseExplore parses an explore page. Trends collects every TimelineTrend
+// on the page, de-duplicated by name; Modules lists the page's timeline
+// modules (entries of type TimelineTimelineModule) with their header text
+// and their stories, trends and tweets. Modules without any of those are
+// skipped.
+func ParseExplore(raw json.RawMessage) (*ExploreResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse explore: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	res := &ExploreResult{Trends: []TrendResult{}, Modules: []ExploreModule{}}
+
+	seen := make(map[string]struct{})
+	walkTimelineTrends(root, 0, func(item gjson.Result) {
+		t := parseTimelineTrend(item)
+		if _, dup := seen[t.Name]; dup || t.Name == "" {
+			return
+		}
+		seen[t.Name] = struct{}{}
+		res.Trends = append(res.Trends, t)
+	})
+
+	walkEntries(root, 0, func(entry gjson.Result) {
+		content := entry.Get("content")
+		if firstString(content, "entryType", "__typename") != "TimelineTimelineModule" {
+			return
+		}
+		m := ExploreModule{
+			Title:  firstString(content, "header.text", "header.title", "displayType"),
+			News:   []NewsItem{},
+			Trends: []TrendResult{},
+			Tweets: []TweetResult{},
+		}
+		content.Get("items").ForEach(func(_, item gjson.Result) bool {
+			ic := item.Get("item.itemContent")
+			switch timelineItemType(ic) {
+			case "TimelineTrend":
+				m.Trends = append(m.Trends, parseTimelineTrend(ic))
+			case "TimelineEventSummary":
+				m.News = append(m.News, parseNewsItem(ic))
+			case "TimelineTweet":
+				if t, ok := parseTweetNode(ic.Get("tweet_results.result")); ok {
+					m.Tweets = append(m.Tweets, t)
+				}
+			}
+			return true
+		})
+		if len(m.News)+len(m.Trends)+len(m.Tweets) > 0 {
+			res.Modules = append(res.Modules, m)
+		}
+	})
+	return res, nil
+}
+
+// parseTimelineTrend converts a TimelineTrend item.
+func parseTimelineTrend(item gjson.Result) TrendResult {
+	t := TrendResult{
+		Name: item.Get("name").String(),
+		URL:  item.Get("trend_url.url").String(),
+	}
+	if n, ok := parseCount(item.Get("trend_metadata.tweet_count")); ok {
+		t.TweetCount = int(n)
+	}
+	return t
+}
+
+// timelineItemType returns the type of a timeline item's content.
+func timelineItemType(item gjson.Result) string {
+	return firstString(item, "itemType", "__typename")
+}
+
+// walkTimelineItems calls fn for every timeline item content object
+// ("itemContent") in value.
+func walkTimelineItems(value gjson.Result, depth int, fn func(gjson.Result)) {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
+		return
+	}
+	value.ForEach(func(k, child gjson.Result) bool {
+		if value.IsObject() && k.String() == "itemContent" && child.IsObject() {
+			fn(child)
+			return true
+		}
+		walkTimelineItems(child, depth+1, fn)
+		return true
+	})
+}
+
+// walkTimelineTrends calls fn for every {"__typename":"TimelineTrend"} object
+// in value.
+func walkTimelineTrends(value gjson.Result, depth int, fn func(gjson.Result)) {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
+		return
+	}
+	if value.IsObject() && value.Get("__typename").String() == "TimelineTrend" {
+		fn(value)
+		return
+	}
+	value.ForEach(func(_, child gjson.Result) bool {
+		walkTimelineTrends(child, depth+1, fn)
+		return true
+	})
+}
+
+// ParseTrendLocations parses a trends available / closest response: a bare
+// array of locations, or one wrapped in "locations" or "data". placeType may
+// be an object {"code","name"} or a bare code, and country an object
+// {"name","code"} or the flat country / countryCode pair.
+func ParseTrendLocations(raw json.RawMessage) ([]TrendLocation, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse trend locations: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	list := root
+	for _, path := range []string{"locations", "data"} {
+		if !list.IsArray() && root.Get(path).IsArray() {
+			list = root.Get(path)
+		}
+	}
+	if !list.IsArray() {
+		if !root.Get("woeid").Exists() {
+			return nil, fmt.Errorf("utools: parse trend locations: no locations found")
+		}
+		list = gjson.Parse("[" + root.Raw + "]")
+	}
+
+	locations := []TrendLocation{}
+	list.ForEach(func(_, item gjson.Result) bool {
+		loc := TrendLocation{
+			Name:        item.Get("name").String(),
+			Country:     item.Get("country").String(),
+			CountryCode: item.Get("countryCode").String(),
+			URL:         item.Get("url").String(),
+		}
+		loc.WOEID, _ = parseCount(item.Get("woeid"))
+		loc.ParentID, _ = parseCount(item.Get("parentid"))
+		if country := item.Get("country"); country.IsObject() {
+			loc.Country = country.Get("name").String()
+			loc.CountryCode = country.Get("code").String()
+		}
+		placeType := item.Get("placeType")
+		if !placeType.Exists() {
+			placeType = item.Get("place_type")
+		}
+		if placeType.IsObject() {
+			code, _ := parseCount(placeType.Get("code"))
+			loc.PlaceType = TrendPlaceType{Code: int(code), Name: placeType.Get("name").String()}
+		} else if code, ok := parseCount(placeType); ok {
+			loc.PlaceType.Code = int(code)
+		}
+		locations = append(locations, loc)
+		return true
+	})
+	return locations, nil
+}
+
+// usernameChangeListPaths are the locations of the change records in a
+// usernameChanges response; a bare array is accepted too.
+var usernameChangeListPaths = []string{"usernameChanges", "username_changes", "changes", "history", "data"}
+
+// usernameChangeTimeLayouts are the timestamp formats seen in change records.
+var usernameChangeTimeLayouts = []string{
+	time.RFC3339,
+	time.RubyDate, // Twitter's "Mon Jan 02 15:04:05 -0700 2006"
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseUsernameChanges parses a usernameChanges response into records sorted
+// oldest first. ChangedAt is normalized to RFC 3339 (UTC) when the upstream
+// value is a known date format or a Unix timestamp in seconds or
+// milliseconds; otherwise it is kept as-is and such records sort last. An
+// account without changes yields an empty slice.
+func ParseUsernameChanges(raw json.RawMessage) ([]UsernameChange, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse username changes: invalid JSON")
+	}
+	root := gjson.ParseBytes(raw)
+	list := root
+	for _, path := range usernameChangeListPaths {
+		if list.IsArray() {
+			break
+		}
+		list = root.Get(path)
+	}
+
+	type record struct {
+		change UsernameChange
+		at     time.Time
+	}
+	var records []record
+	list.ForEach(func(_, item gjson.Result) bool {
+		r := record{change: UsernameChange{
+			OldName: firstString(item, "old_name", "oldName", "from"),
+			NewName: firstString(item, "new_name", "newName", "to"),
+		}}
+		at := firstNonEmpty(item, "changed_at", "changedAt", "timestamp", "date")
+		r.at = parseChangeTime(at)
+		if r.at.IsZero() {
+			r.change.ChangedAt = at.String()
+		} else {
+			r.change.ChangedAt = r.at.UTC().Format(time.RFC3339)
+		}
+		records = append(records, r)
+		return true
+	})
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i].at, records[j].at
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+
+	changes := make([]UsernameChange, len(records))
+	for i, r := range records {
+		changes[i] = r.change
+	}
+	return changes, nil
+}
+
+// firstString returns the first non-empty string among item's keys.
+func firstString(item gjson.Result, keys ...string) string {
+	return firstNonEmpty(item, keys...).String()
+}
+
+// firstNonEmpty returns the first of item's keys holding a non-empty value.
+func firstNonEmpty(item gjson.Result, keys ...string) gjson.Result {
+	for _, k := range keys {
+		if v := item.Get(k); v.Exists() && v.String() != "" {
+			return v
+		}
+	}
+	return gjson.Result{}
+}
+
+// parseChangeTime parses a change timestamp: a Unix time in seconds or
+// milliseconds (number or numeric string) or one of
+// usernameChangeTimeLayouts. It returns the zero time when v is unrecognised.
+func parseChangeTime(v gjson.Result) time.Time {
+	if n, ok := parseCount(v); ok && n > 0 {
+		if n >= 1e12 {
+			return time.UnixMilli(n)
+		}
+		return time.Unix(n, 0)
+	}
+	s := strings.TrimSpace(v.String())
+	for _, layout := range usernameChangeTimeLayouts {
+		if ts, err := time.Parse(layout, s); err == nil {
+			return ts
+		}
+	}
+	return time.Time{}
+}
+
+// walkTweetResults calls fn for every tweet_results node in root.
+func walkTweetResults(root gjson.Result, fn func(gjson.Result)) {
+	walkResultNodes(root, "tweet_results", 0, fn)
+}
+
+// walkResultNodes calls fn for every object found under key as
+// {"<key>": {"result": {...}}} and does not descend into matched nodes,
+// so users nested inside tweets (or tweets inside quoted tweets) are not
+// reported twice.
+func walkResultNodes(value gjson.Result, key string, depth int, fn func(gjson.Result)) {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
+		return
+	}
+	value.ForEach(func(k, child gjson.Result) bool {
+		if value.IsObject() && k.String() == key {
+			if result := child.Get("result"); result.IsObject() {
+				fn(result)
+			}
+			return true
+		}
+		walkResultNodes(child, key, depth+1, fn)
+		return true
+	})
+}
+
+// parseUserNode converts a single user node into a UserResult. It accepts
+// the GraphQL shape ({"rest_id", "legacy": {...}, "core": {...}}) and the
+// flat legacy shape. ok is false for unavailable or empty nodes.
+func parseUserNode(node gjson.Result) (UserResult, bool) {
+	var u UserResult
+	if !node.IsObject() || node.Get("__typename").String() == "UserUnavailable" {
+		return u, false
+	}
+
+	legacy := node.Get("legacy")
+	if legacy.IsObject() {
+		if err := unmarshalJSON([]byte(legacy.Raw), &u); err != nil {
+			return u, false
+		}
+		u.RestID = node.Get("rest_id").String()
+		if v := node.Get("is_blue_verified"); v.Exists() {
+			u.IsBlueVerified = v.Bool()
+		}
+		// Newer GraphQL payloads move some fields out of legacy.
+		if v := node.Get("core.screen_name"); v.Exists() {
+			u.ScreenName = v.String()
+		}
+		if v := node.Get("core.name"); v.Exists() {
+			u.Name = v.String()
+		}
+		if v := node.Get("core.created_at"); v.Exists() {
+			u.CreatedAt = v.String()
+		}
+		if v := node.Get("avatar.image_url"); v.Exists() && u.ProfileImageURL == "" {
+			u.ProfileImageURL = v.String()
+		}
+		if v := node.Get("location.location"); v.Exists() && u.Location == "" {
+			u.Location = v.String()
+		}
+		if v := node.Get("privacy.protected"); v.Exists() {
+			u.Protected = v.Bool()
+		}
+		if v := node.Get("verification.verified"); v.Exists() {
+			u.Verified = v.Bool()
+		}
+		if v := node.Get("professional"); v.IsObject() {
+			var pro UserProfessional
+			if unmarshalJSON([]byte(v.Raw), &pro) == nil {
+				u.Professional = &pro
+			}
+		}
+		u.AffiliateLabel = node.Get("affiliates_highlighted_label.label.description").String()
+		// Newer payloads report the viewer relationship outside legacy.
+		rel := node.Get("relationship_perspectives")
+		if v := rel.Get("followed_by"); v.Exists() {
+			u.FollowedBy = v.Bool()
+		}
+		if v := rel.Get("following"); v.Exists() {
+			u.Following = v.Bool()
+		}
+		if v := rel.Get("blocking"); v.Exists() {
+			u.Blocking = v.Bool()
+		}
+	} else if err := unmarshalJSON([]byte(node.Raw), &u); err != nil {
+		return u, false
+	}
+
+	if u.RestID == "" {
+		u.RestID = u.ID
+	}
+	if u.ID == "" {
+		u.ID = u.RestID
+	}
+	u.ScreenName = strings.TrimPrefix(u.ScreenName, "@")
+	if u.Protected {
+		u.Status = AccountProtected
+	}
+	return u, u.RestID != "" || u.ScreenName != ""
+}
+
+// parseTweetNode converts a single tweet node into a TweetResult. It accepts
+// the GraphQL shape ({"rest_id", "legacy": {...}, "core": {...}}), unwrapping
+// TweetWithVisibilityResults, and the flat legacy shape. ok is false for
+// unavailable, tombstoned or empty nodes.
+func parseTweetNode(node gjson.Result) (TweetResult, bool) {
+	return parseTweetNodeDepth(node, 0)
+}
+
+func parseTweetNodeDepth(node gjson.Result, depth int) (TweetResult, bool) {
+	var t TweetResult
+	if depth > maxParseDepth {
+		return t, false
+	}
+	if node.Get("__typename").String() == "TweetWithVisibilityResults" {
+		node = node.Get("tweet")
+	}
+	if !node.IsObject() {
+		return t, false
+	}
+	switch node.Get("__typename").String() {
+	case "TweetUnavailable", "TweetTombstone":
+		return t, false
+	}
+
+	legacy := node.Get("legacy")
+	if legacy.IsObject() {
+		if err := unmarshalJSON([]byte(legacy.Raw), &t); err != nil {
+			return t, false
+		}
+		t.RestID = node.Get("rest_id").String()
+		if u, ok := parseUserNode(node.Get("core.user_results.result")); ok {
+			t.User = &u
+		}
+		// Long tweets carry their untruncated text in note_tweet.
+		if v := node.Get("note_tweet.note_tweet_results.result.text"); v.Exists() {
+			t.FullText = v.String()
+		}
+		if n, ok := parseCount(node.Get("views.count")); ok {
+			t.ViewCount = strconv.FormatInt(n, 10)
+		}
+		if v := node.Get("card"); v.IsObject() {
+			t.Card = json.RawMessage(v.Raw)
+		}
+		if q, ok := parseTweetNodeDepth(node.Get("quoted_status_result.result"), depth+1); ok {
+			t.QuotedStatus = &q
+		}
+		if rt, ok := parseTweetNodeDepth(legacy.Get("retweeted_status_result.result"), depth+1); ok {
+			t.RetweetedStatus = &rt
+		}
+	} else if err := unmarshalJSON([]byte(node.Raw), &t); err != nil {
+		return t, false
+	}
+
+	if t.RestID == "" {
+		t.RestID = t.ID
+	}
+	if t.ID == "" {
+		t.ID = t.RestID
+	}
+	return t, t.RestID != ""
+}
@@ -0,0 +1,724 @@
+package utools
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const userModuleFixture = `{
+	"data": {"connect_tab_timeline": {"timeline": {"instructions": [{
+		"type": "TimelineAddEntries",
+		"entries": [{
+			"entryId": "whoToFollow-1",
+			"content": {"items": [
+				{"item": {"itemContent": {"user_results": {"result": {
+					"__typename": "User",
+					"rest_id": "11",
+					"is_blue_verified": true,
+					"legacy": {"name": "Alice", "screen_name": "alice", "followers_count": 10}
+				}}}}},
+				{"item": {"itemContent": {"user_results": {"result": {
+					"__typename": "User",
+					"rest_id": "22",
+					"core": {"name": "Bob", "screen_name": "bob"},
+					"legacy": {"followers_count": 20}
+				}}}}},
+				{"item": {"itemContent": {"user_results": {"result": {
+					"__typename": "UserUnavailable"
+				}}}}}
+			]}
+		}, {
+			"entryId": "cursor-bottom-1",
+			"content": {"cursorType": "Bottom", "value": "next"}
+		}]
+	}]}}}
+}`
+
+func TestParseUserList_GraphQLModule(t *testing.T) {
+	users, err := ParseUserList(json.RawMessage(userModuleFixture))
+	if err != nil {
+		t.Fatalf("ParseUserList error: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d: %+v", len(users), users)
+	}
+	if users[0].RestID != "11" || users[0].ScreenName != "alice" || !users[0].IsBlueVerified || users[0].FollowersCount != 10 {
+		t.Fatalf("unexpected first user: %+v", users[0])
+	}
+	if users[1].RestID != "22" || users[1].ScreenName != "bob" || users[1].Name != "Bob" {
+		t.Fatalf("unexpected second user: %+v", users[1])
+	}
+}
+
+func TestParseUserList_LegacyShape(t *testing.T) {
+	raw := json.RawMessage(`{"users":[{"id_str":"7","screen_name":"carol","followers_count":3}],"next_cursor_str":"0"}`)
+	users, err := ParseUserList(raw)
+	if err != nil {
+		t.Fatalf("ParseUserList error: %v", err)
+	}
+	if len(users) != 1 || users[0].RestID != "7" || users[0].ScreenName != "carol" {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+}
+
+func TestParseUserList_InvalidJSON(t *testing.T) {
+	if _, err := ParseUserList(json.RawMessage(`{bad`)); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+}
+
+func TestParseUserProfile(t *testing.T) {
+	t.Run("GraphQL", func(t *testing.T) {
+		raw := json.RawMessage(`{"data":{"user":{"result":{"__typename":"User","rest_id":"44196397","legacy":{"name":"Elon Musk","screen_name":"elonmusk","followers_count":100}}}}}`)
+		u, err := ParseUserProfile(raw)
+		if err != nil {
+			t.Fatalf("ParseUserProfile error: %v", err)
+		}
+		if u.RestID != "44196397" || u.ScreenName != "elonmusk" || u.FollowersCount != 100 {
+			t.Fatalf("unexpected user: %+v", u)
+		}
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		u, err := ParseUserProfile(json.RawMessage(`{"id_str":"12","screen_name":"jack","name":"jack"}`))
+		if err != nil {
+			t.Fatalf("ParseUserProfile error: %v", err)
+		}
+		if u.RestID != "12" || u.ScreenName != "jack" {
+			t.Fatalf("unexpected user: %+v", u)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, raw := range []string{`{"data":{}}`, `{"data":{"user":{"result":{"__typename":"UserUnavailable"}}}}`} {
+			if _, err := ParseUserProfile(json.RawMessage(raw)); !errors.Is(err, ErrUserNotFound) {
+				t.Fatalf("expected ErrUserNotFound for %s, got %v", raw, err)
+			}
+		}
+	})
+}
+
+func TestParseTweetViews(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want int64
+	}{
+		{
+			name: "graphql views.count string",
+			raw:  `{"data":{"tweetResult":{"result":{"__typename":"Tweet","rest_id":"1","views":{"count":"12345","state":"EnabledWithCount"},"legacy":{"id_str":"1"}}}}}`,
+			want: 12345,
+		},
+		{
+			name: "visibility wrapper",
+			raw:  `{"data":{"tweetResult":{"result":{"__typename":"TweetWithVisibilityResults","tweet":{"rest_id":"1","views":{"count":"77"}}}}}}`,
+			want: 77,
+		},
+		{
+			name: "legacy ext_views",
+			raw:  `{"id_str":"1","ext_views":{"r":{"ok":{"count":"4,096"}}}}`,
+			want: 4096,
+		},
+		{
+			name: "flat view_count string",
+			raw:  `{"tweet":{"id_str":"1","view_count":"900"}}`,
+			want: 900,
+		},
+		{
+			name: "numeric view_count",
+			raw:  `{"id_str":"1","view_count":31}`,
+			want: 31,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseTweetViews(json.RawMessage(tc.raw))
+			if err != nil {
+				t.Fatalf("ParseTweetViews error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %d views, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTweetViews_Unavailable(t *testing.T) {
+	for name, raw := range map[string]string{
+		"views disabled":    `{"data":{"tweetResult":{"result":{"rest_id":"1","views":{"state":"Enabled"}}}}}`,
+		"tombstone":         `{"data":{"tweetResult":{"result":{"__typename":"TweetTombstone"}}}}`,
+		"empty view_count":  `{"id_str":"1","view_count":""}`,
+		"no tweet in reply": `{}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseTweetViews(json.RawMessage(raw)); !errors.Is(err, ErrViewsUnavailable) {
+				t.Fatalf("expected ErrViewsUnavailable, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseUserProfile_Professional(t *testing.T) {
+	business := json.RawMessage(`{"data":{"user":{"result":{"__typename":"User","rest_id":"783214","legacy":{"screen_name":"acme"},
+		"professional":{"rest_id":"1","professional_type":"Business","category":[{"id":958,"name":"Software Company","icon_name":""}]}}}}}`)
+	u, err := ParseUserProfile(business)
+	if err != nil {
+		t.Fatalf("ParseUserProfile error: %v", err)
+	}
+	if u.ProfessionalType() != "Business" || u.Category() != "Software Company" || !u.IsBusinessAccount() {
+		t.Fatalf("unexpected professional fields: type=%q category=%q business=%v", u.ProfessionalType(), u.Category(), u.IsBusinessAccount())
+	}
+
+	personal := json.RawMessage(`{"data":{"user":{"result":{"__typename":"User","rest_id":"12","legacy":{"screen_name":"jack"}}}}}`)
+	if u, err = ParseUserProfile(personal); err != nil {
+		t.Fatalf("ParseUserProfile error: %v", err)
+	}
+	if u.Professional != nil || u.ProfessionalType() != "" || u.Category() != "" || u.IsBusinessAccount() {
+		t.Fatalf("expected personal account defaults, got %+v", u.Professional)
+	}
+}
+
+func TestParseUserProfile_Relationship(t *testing.T) {
+	authed := json.RawMessage(`{"data":{"user":{"result":{"__typename":"User","rest_id":"12",
+		"core":{"screen_name":"jack"},"legacy":{"followers_count":5},
+		"relationship_perspectives":{"followed_by":true,"following":true,"blocking":false}}}}}`)
+	u, err := ParseUserProfile(authed)
+	if err != nil {
+		t.Fatalf("ParseUserProfile error: %v", err)
+	}
+	if !u.FollowedBy || !u.Following || u.Blocking {
+		t.Fatalf("unexpected relationship: followed_by=%v following=%v blocking=%v", u.FollowedBy, u.Following, u.Blocking)
+	}
+
+	legacy := json.RawMessage(`{"data":{"user":{"result":{"__typename":"User","rest_id":"13",
+		"legacy":{"screen_name":"blocked","blocking":true,"followed_by":false}}}}}`)
+	if u, err = ParseUserProfile(legacy); err != nil || !u.Blocking || u.FollowedBy || u.Following {
+		t.Fatalf("unexpected legacy relationship: %+v, %v", u, err)
+	}
+
+	anonymous := json.RawMessage(`{"data":{"user":{"result":{"__typename":"User","rest_id":"14","legacy":{"screen_name":"anon"}}}}}`)
+	if u, err = ParseUserProfile(anonymous); err != nil || u.FollowedBy || u.Following || u.Blocking {
+		t.Fatalf("expected no relationship without auth, got %+v, %v", u, err)
+	}
+}
+
+func TestParseUserProfile_Locale(t *testing.T) {
+	legacy := json.RawMessage(`{"id_str":"12","screen_name":"jack","time_zone":"Pacific Time (US & Canada)","utc_offset":-25200,"lang":"en"}`)
+	u, err := ParseUserProfile(legacy)
+	if err != nil {
+		t.Fatalf("ParseUserProfile error: %v", err)
+	}
+	if u.TimeZone != "Pacific Time (US & Canada)" || u.UTCOffset != -25200 || u.Lang != "en" {
+		t.Fatalf("unexpected locale fields: %q %d %q", u.TimeZone, u.UTCOffset, u.Lang)
+	}
+
+	modern := json.RawMessage(`{"data":{"user":{"result":{"__typename":"User","rest_id":"12",
+		"legacy":{"screen_name":"jack","time_zone":null,"utc_offset":null}}}}}`)
+	if u, err = ParseUserProfile(modern); err != nil {
+		t.Fatalf("ParseUserProfile error: %v", err)
+	}
+	if u.TimeZone != "" || u.UTCOffset != 0 || u.Lang != "" {
+		t.Fatalf("expected empty locale fields, got %q %d %q", u.TimeZone, u.UTCOffset, u.Lang)
+	}
+}
+
+func TestParseUserProfileExpanded(t *testing.T) {
+	raw := json.RawMessage(`{"data":{"user":{"result":{
+		"__typename": "User",
+		"rest_id": "12",
+		"core": {"screen_name": "jack", "name": "jack"},
+		"legacy": {"followers_count": 10, "pinned_tweet_ids_str": ["100"]},
+		"pinned_tweet_results": {"result": {
+			"__typename": "Tweet",
+			"rest_id": "100",
+			"core": {"user_results": {"result": {"rest_id": "12", "legacy": {"screen_name": "jack"}}}},
+			"views": {"count": "5000"},
+			"legacy": {"full_text": "pinned", "favorite_count": 3}
+		}},
+		"recent_tweets": [
+			{"tweet_results": {"result": {"rest_id": "201", "legacy": {"full_text": "newest"}}}},
+			{"tweet_results": {"result": {"__typename": "TweetTombstone"}}},
+			{"tweet_results": {"result": {"__typename": "TweetWithVisibilityResults", "tweet": {"rest_id": "200", "legacy": {"full_text": "older"}}}}}
+		]
+	}}}}`)
+
+	p, err := ParseUserProfileExpanded(raw)
+	if err != nil {
+		t.Fatalf("ParseUserProfileExpanded error: %v", err)
+	}
+	if p.User.ScreenName != "jack" || p.User.FollowersCount != 10 {
+		t.Fatalf("unexpected user: %+v", p.User)
+	}
+	if p.PinnedTweet == nil || p.PinnedTweet.RestID != "100" || p.PinnedTweet.GetText() != "pinned" {
+		t.Fatalf("unexpected pinned tweet: %+v", p.PinnedTweet)
+	}
+	if p.PinnedTweet.ViewCount != "5000" || p.PinnedTweet.User == nil || p.PinnedTweet.User.ScreenName != "jack" {
+		t.Fatalf("pinned tweet missing views or author: %+v", p.PinnedTweet)
+	}
+	if len(p.RecentTweets) != 2 || p.RecentTweets[0].ID != "201" || p.RecentTweets[1].ID != "200" {
+		t.Fatalf("unexpected recent tweets: %+v", p.RecentTweets)
+	}
+}
+
+func TestParseUserProfileExpanded_NoExtras(t *testing.T) {
+	p, err := ParseUserProfileExpanded(json.RawMessage(`{"id_str":"12","screen_name":"jack"}`))
+	if err != nil {
+		t.Fatalf("ParseUserProfileExpanded error: %v", err)
+	}
+	if p.User.RestID != "12" || p.PinnedTweet != nil || len(p.RecentTweets) != 0 {
+		t.Fatalf("unexpected profile: %+v", p)
+	}
+
+	if _, err := ParseUserProfileExpanded(json.RawMessage(`{"data":{"user":{"result":{"__typename":"UserUnavailable"}}}}`)); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestParseTweetTimeline_QuotedNotListedAndLegacyShape(t *testing.T) {
+	raw := json.RawMessage(`{"entries":[{"content":{"itemContent":{"tweet_results":{"result":{
+		"rest_id": "2",
+		"legacy": {"full_text": "quoting", "user_id_str": "9"},
+		"quoted_status_result": {"result": {"rest_id": "1", "legacy": {"full_text": "quoted"}}}
+	}}}}}]}`)
+	tweets, err := ParseTweetTimeline(raw)
+	if err != nil {
+		t.Fatalf("ParseTweetTimeline error: %v", err)
+	}
+	if len(tweets) != 1 || tweets[0].ID != "2" || tweets[0].AuthorID() != "9" {
+		t.Fatalf("unexpected tweets: %+v", tweets)
+	}
+	if tweets[0].QuotedStatus == nil || tweets[0].QuotedStatus.GetText() != "quoted" {
+		t.Fatalf("quoted tweet not attached: %+v", tweets[0].QuotedStatus)
+	}
+
+	tweets, err = ParseTweetTimeline(json.RawMessage(`[{"id_str":"5","text":"flat"}]`))
+	if err != nil || len(tweets) != 1 || tweets[0].RestID != "5" {
+		t.Fatalf("unexpected legacy parse: %+v, %v", tweets, err)
+	}
+}
+
+func TestParseTweetTimeline_SensitiveAndWithheld(t *testing.T) {
+	raw := json.RawMessage(`{"entries":[
+		{"content":{"itemContent":{"tweet_results":{"result":{"rest_id":"1","legacy":{"full_text":"nsfw","possibly_sensitive":true}}}}}},
+		{"content":{"itemContent":{"tweet_results":{"result":{"rest_id":"2","legacy":{"full_text":"blocked","withheld_in_countries":["DE","FR"],"withheld_scope":"status"}}}}}},
+		{"content":{"itemContent":{"tweet_results":{"result":{"rest_id":"3","legacy":{"full_text":"plain"}}}}}}
+	]}`)
+	for name, parse := range map[string]func(json.RawMessage) ([]TweetResult, error){
+		"ParseTweetTimeline": ParseTweetTimeline,
+		"TweetParser":        NewTweetParser().ParseTimeline,
+	} {
+		tweets, err := parse(raw)
+		if err != nil || len(tweets) != 3 {
+			t.Fatalf("%s: unexpected result: %+v, %v", name, tweets, err)
+		}
+		if !tweets[0].PossiblySensitive || tweets[0].WithheldInCountries != nil {
+			t.Fatalf("%s: expected a sensitive, not withheld tweet: %+v", name, tweets[0])
+		}
+		if tweets[1].PossiblySensitive || strings.Join(tweets[1].WithheldInCountries, ",") != "DE,FR" || tweets[1].WithheldScope != "status" {
+			t.Fatalf("%s: expected a tweet withheld in DE,FR: %+v", name, tweets[1])
+		}
+		if tweets[2].PossiblySensitive || tweets[2].WithheldInCountries != nil || tweets[2].WithheldScope != "" {
+			t.Fatalf("%s: expected default flags: %+v", name, tweets[2])
+		}
+	}
+
+	tw, err := ParseTweetSimple(json.RawMessage(`{"id":"4","text":"flat","possibly_sensitive":true,"withheld_in_countries":["XX"],"withheld_scope":"user"}`))
+	if err != nil || !tw.PossiblySensitive || strings.Join(tw.WithheldInCountries, ",") != "XX" || tw.WithheldScope != "user" {
+		t.Fatalf("unexpected flat tweet flags: %+v, %v", tw, err)
+	}
+}
+
+func TestParseTweetTimeline_ReplyRestriction(t *testing.T) {
+	raw := json.RawMessage(`{"entries":[
+		{"content":{"itemContent":{"tweet_results":{"result":{"rest_id":"1","legacy":{"full_text":"followers only",
+			"conversation_control":{"policy":"Community","conversation_owner_results":{"result":{"__typename":"User","legacy":{"screen_name":"jack"}}}},
+			"limited_actions":"limited_replies"}}}}}},
+		{"content":{"itemContent":{"tweet_results":{"result":{"rest_id":"2","legacy":{"full_text":"mentioned only","conversation_control":{"policy":"ByInvitation"}}}}}}},
+		{"content":{"itemContent":{"tweet_results":{"result":{"rest_id":"3","legacy":{"full_text":"open"}}}}}}
+	]}`)
+	for name, parse := range map[string]func(json.RawMessage) ([]TweetResult, error){
+		"ParseTweetTimeline": ParseTweetTimeline,
+		"TweetParser":        NewTweetParser().ParseTimeline,
+	} {
+		tweets, err := parse(raw)
+		if err != nil || len(tweets) != 3 {
+			t.Fatalf("%s: unexpected result: %+v, %v", name, tweets, err)
+		}
+		for i, want := range []string{"following", "mentioned", "everyone"} {
+			if got := tweets[i].ReplyRestriction(); got != want {
+				t.Fatalf("%s: tweet %s: expected reply restriction %q, got %q", name, tweets[i].ID, want, got)
+			}
+		}
+		if tweets[0].LimitedActions != "limited_replies" || tweets[2].ConversationControl != nil {
+			t.Fatalf("%s: unexpected restriction fields: %+v / %+v", name, tweets[0], tweets[2])
+		}
+	}
+
+	tw, err := ParseTweetSimple(json.RawMessage(`{"id":"4","text":"flat","conversation_control":{"policy":"Verified"}}`))
+	if err != nil || tw.ReplyRestriction() != "verified" {
+		t.Fatalf("unexpected flat tweet restriction: %+v, %v", tw, err)
+	}
+	if r := (&TweetResult{LimitedActions: "limited_replies"}).ReplyRestriction(); r != "limited" {
+		t.Fatalf("expected limited replies without a policy to be %q, got %q", "limited", r)
+	}
+}
+
+// tweetDetailFixture builds a tweetTimeline page whose focal entry holds
+// focal, followed by reply 200 and a bottom cursor.
+func tweetDetailFixture(focal string) json.RawMessage {
+	return json.RawMessage(`{"data":{"threaded_conversation_with_injections_v2":{"instructions":[{"type":"TimelineAddEntries","entries":[
+		{"entryId":"tweet-100","content":{"itemContent":{"tweet_results":{"result":` + focal + `}}}},
+		{"entryId":"conversationthread-200","content":{"items":[{"item":{"itemContent":{"tweet_results":{"result":{"rest_id":"200","legacy":{"full_text":"reply"}}}}}}]}},
+		{"entryId":"cursor-bottom-1","content":{"cursorType":"Bottom","value":"next"}}
+	]}]}}}`)
+}
+
+func TestParseTweetDetail(t *testing.T) {
+	detail, err := ParseTweetDetail(tweetDetailFixture(`{"rest_id":"100","legacy":{"full_text":"focal"}}`), "100")
+	if err != nil || detail.Tombstoned || detail.Tweet.ID != "100" || detail.NextCursor != "next" {
+		t.Fatalf("unexpected detail: %+v, %v", detail, err)
+	}
+	if len(detail.Replies) != 1 || detail.Replies[0].ID != "200" {
+		t.Fatalf("unexpected replies: %+v", detail.Replies)
+	}
+
+	if _, err := ParseTweetDetail(tweetDetailFixture(`{"rest_id":"100","legacy":{"full_text":"focal"}}`), "999"); err != ErrTweetNotFound {
+		t.Fatalf("expected ErrTweetNotFound for an absent focal tweet, got %v", err)
+	}
+}
+
+func TestParseTweetDetail_Tombstones(t *testing.T) {
+	for _, tc := range []struct {
+		name, focal string
+		want        error
+	}{
+		{"deleted", `{"__typename":"TweetTombstone","tombstone":{"text":{"text":"This Post was deleted by the Post author. Learn more"}}}`, ErrTweetDeleted},
+		{"blocked", `{"__typename":"TweetTombstone","tombstone":{"text":{"text":"You're unable to view this Post because you've been blocked by the Post author."}}}`, ErrTweetBlocked},
+		{"suspended", `{"__typename":"TweetTombstone","tombstone":{"text":{"text":"This Post is from a suspended account. Learn more"}}}`, ErrTweetAuthorSuspended},
+		{"protected", `{"__typename":"TweetTombstone","tombstone":{"text":{"text":"You're unable to view this Post because this account owner limits who can view their Posts."}}}`, ErrTweetProtected},
+		{"unavailable", `{"__typename":"TweetUnavailable","reason":"Unavailable"}`, ErrTweetNotFound},
+	} {
+		detail, err := ParseTweetDetail(tweetDetailFixture(tc.focal), "100")
+		if !errors.Is(err, tc.want) || !errors.Is(err, ErrTweetNotFound) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+		if detail == nil || !detail.Tombstoned || detail.Tweet.ID != "" {
+			t.Fatalf("%s: expected a tombstoned detail, got %+v", tc.name, detail)
+		}
+		if len(detail.Replies) != 1 || detail.Replies[0].ID != "200" || detail.NextCursor != "next" {
+			t.Fatalf("%s: expected the replies to survive the tombstone, got %+v", tc.name, detail)
+		}
+	}
+	if errors.Is(ErrTweetDeleted, ErrTweetBlocked) {
+		t.Fatal("deleted and blocked tombstones must be distinguishable")
+	}
+}
+
+func TestParseUserProfile_AccountStatus(t *testing.T) {
+	cases := []struct {
+		name    string
+		raw     string
+		status  AccountStatus
+		wantErr error
+	}{
+		{
+			name:   "active",
+			raw:    `{"data":{"user":{"result":{"__typename":"User","rest_id":"1","legacy":{"screen_name":"a"}}}}}`,
+			status: AccountActive,
+		},
+		{
+			name:   "protected graphql",
+			raw:    `{"data":{"user":{"result":{"__typename":"User","rest_id":"1","core":{"screen_name":"a"},"privacy":{"protected":true},"legacy":{}}}}}`,
+			status: AccountProtected,
+		},
+		{
+			name:   "protected legacy",
+			raw:    `{"id_str":"1","screen_name":"a","protected":true}`,
+			status: AccountProtected,
+		},
+		{
+			name:    "suspended graphql",
+			raw:     `{"data":{"user":{"result":{"__typename":"UserUnavailable","reason":"Suspended","message":"User is suspended"}}}}`,
+			status:  AccountSuspended,
+			wantErr: ErrUserSuspended,
+		},
+		{
+			name:    "suspended legacy errors",
+			raw:     `{"errors":[{"code":63,"message":"User has been suspended."}]}`,
+			status:  AccountSuspended,
+			wantErr: ErrUserSuspended,
+		},
+		{
+			name:    "deactivated",
+			raw:     `{"data":{"user":{"result":{"__typename":"UserUnavailable","unavailable_message":{"text":"This account has been deactivated."}}}}}`,
+			status:  AccountDeactivated,
+			wantErr: ErrUserDeactivated,
+		},
+		{
+			name:    "not found empty data",
+			raw:     `{"data":{}}`,
+			status:  AccountNotFound,
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:    "not found legacy errors",
+			raw:     `{"errors":[{"code":50,"message":"User not found."}]}`,
+			status:  AccountNotFound,
+			wantErr: ErrUserNotFound,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := ParseUserProfile(json.RawMessage(tc.raw))
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) || !errors.Is(err, ErrUserNotFound) {
+					t.Fatalf("expected %v (matching ErrUserNotFound), got %v", tc.wantErr, err)
+				}
+				if status, ok := AccountStatusOf(err); !ok || status != tc.status {
+					t.Fatalf("AccountStatusOf = %v, %v; want %v", status, ok, tc.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseUserProfile error: %v", err)
+			}
+			if u.Status != tc.status {
+				t.Fatalf("expected status %v, got %v", tc.status, u.Status)
+			}
+		})
+	}
+}
+
+func TestParseCommunity(t *testing.T) {
+	raw := json.RawMessage(`{"data":{"communityResults":{"result":{"__typename":"Community","rest_id":"1493446837214187523",
+		"name":"Build in Public","description":"Share what you build","member_count":"120500","moderator_count":12,
+		"created_at":1644938347000,"join_policy":"Open","invites_policy":"MemberInvitesAllowed",
+		"primary_community_topic":{"topic_id":"1","topic_name":"Technology"},
+		"rules":[
+			{"rest_id":"1","name":"Be kind","description":"No harassment."},
+			{"rest_id":"2","name":"Stay on topic"},
+			{"rest_id":"3","name":"No spam","description":"Self-promotion only in threads."}
+		],
+		"admin_results":{"result":{"__typename":"User","rest_id":"7","legacy":{"screen_name":"admin"}}},
+		"creator_results":{"result":{"__typename":"User","rest_id":"7","legacy":{"screen_name":"admin"}}}}}}}`)
+	c, err := ParseCommunity(raw)
+	if err != nil {
+		t.Fatalf("ParseCommunity error: %v", err)
+	}
+	if c.ID != "1493446837214187523" || c.Name != "Build in Public" || c.MemberCount != 120500 || c.ModeratorCount != 12 || c.CreatedAt != "1644938347000" {
+		t.Fatalf("unexpected community: %+v", c)
+	}
+	if c.JoinPolicy != "open" || c.PrimaryTopic != "Technology" {
+		t.Fatalf("unexpected join policy / topic: %q / %q", c.JoinPolicy, c.PrimaryTopic)
+	}
+	want := []CommunityRule{
+		{Name: "Be kind", Description: "No harassment."},
+		{Name: "Stay on topic"},
+		{Name: "No spam", Description: "Self-promotion only in threads."},
+	}
+	if !reflect.DeepEqual(c.Rules, want) {
+		t.Fatalf("unexpected rules: %+v", c.Rules)
+	}
+	if c.Admin == nil || c.Admin.ScreenName != "admin" || c.Creator == nil {
+		t.Fatalf("unexpected admin / creator: %+v / %+v", c.Admin, c.Creator)
+	}
+
+	// Optional sections may be missing entirely.
+	bare, err := ParseCommunity(json.RawMessage(`{"rest_id":"2","name":"Quiet","join_policy":"Restricted"}`))
+	if err != nil || bare.JoinPolicy != "restricted" || len(bare.Rules) != 0 || bare.Rules == nil || bare.PrimaryTopic != "" || bare.Admin != nil {
+		t.Fatalf("unexpected bare community: %+v, %v", bare, err)
+	}
+	if _, err := ParseCommunity(json.RawMessage(`{"data":{"communityResults":{"result":{"__typename":"CommunityUnavailable"}}}}`)); err == nil {
+		t.Fatal("expected an error for an unavailable community")
+	}
+}
+
+func TestParseTrendLocations(t *testing.T) {
+	raw := json.RawMessage(`[
+		{"country":"","countryCode":null,"name":"Worldwide","parentid":0,"placeType":{"code":19,"name":"Supername"},"url":"http://where.yahooapis.com/v1/place/1","woeid":1},
+		{"country":"Sweden","countryCode":"SE","name":"Sweden","parentid":1,"placeType":{"code":12,"name":"Country"},"url":"http://where.yahooapis.com/v1/place/23424954","woeid":23424954},
+		{"country":{"name":"Japan","code":"JP"},"name":"Tokyo","parentid":"23424856","place_type":7,"woeid":"1118370"}
+	]`)
+	locs, err := ParseTrendLocations(raw)
+	if err != nil {
+		t.Fatalf("ParseTrendLocations error: %v", err)
+	}
+	want := []TrendLocation{
+		{WOEID: 1, Name: "Worldwide", PlaceType: TrendPlaceType{Code: 19, Name: "Supername"}, URL: "http://where.yahooapis.com/v1/place/1"},
+		{WOEID: 23424954, Name: "Sweden", Country: "Sweden", CountryCode: "SE", ParentID: 1, PlaceType: TrendPlaceType{Code: 12, Name: "Country"}, URL: "http://where.yahooapis.com/v1/place/23424954"},
+		{WOEID: 1118370, Name: "Tokyo", Country: "Japan", CountryCode: "JP", ParentID: 23424856, PlaceType: TrendPlaceType{Code: 7}},
+	}
+	if len(locs) != len(want) {
+		t.Fatalf("expected %d locations, got %+v", len(want), locs)
+	}
+	for i := range want {
+		if locs[i] != want[i] {
+			t.Fatalf("location %d:\n got %+v\nwant %+v", i, locs[i], want[i])
+		}
+	}
+
+	// A list wrapped in "locations" is accepted too.
+	locs, err = ParseTrendLocations(json.RawMessage(`{"locations":[{"name":"Worldwide","woeid":1}]}`))
+	if err != nil || len(locs) != 1 || locs[0].WOEID != 1 {
+		t.Fatalf("unexpected wrapped result: %+v, %v", locs, err)
+	}
+	if _, err := ParseTrendLocations(json.RawMessage(`{"foo":1}`)); err == nil {
+		t.Fatal("expected error for response without locations")
+	}
+}
+
+func TestParseTrends(t *testing.T) {
+	cases := map[string]string{
+		"rest":    `[{"trends":[{"name":"#golang","url":"u1","query":"%23golang","tweet_volume":12500},{"name":"rust","url":"u2","query":"rust","tweet_volume":null}],"as_of":"2024-01-01T00:00:00Z"}]`,
+		"wrapped": `{"trends":[{"name":"#golang","url":"u1","query":"%23golang","tweet_volume":"12,500"},{"name":"rust","url":"u2","query":"rust"}]}`,
+		"bare":    `[{"name":"#golang","url":"u1","query":"%23golang","tweet_volume":12500},{"name":"rust","url":"u2","query":"rust"}]`,
+	}
+	want := []TrendResult{
+		{Name: "#golang", Query: "%23golang", URL: "u1", TweetCount: 12500},
+		{Name: "rust", Query: "rust", URL: "u2"},
+	}
+	for name, raw := range cases {
+		got, err := ParseTrends(json.RawMessage(raw))
+		if err != nil {
+			t.Fatalf("%s: ParseTrends error: %v", name, err)
+		}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Fatalf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+
+	got, err := ParseTrends(json.RawMessage(`{"timeline":{"instructions":[{"entries":[{"content":{"itemContent":{"__typename":"TimelineTrend","name":"Gophers","trend_url":{"url":"twitter://search?q=Gophers"},"trend_metadata":{"tweet_count":"3400"}}}}]}]}}`))
+	if err != nil || len(got) != 1 || got[0].Name != "Gophers" || got[0].TweetCount != 3400 || got[0].URL != "twitter://search?q=Gophers" {
+		t.Fatalf("unexpected GraphQL trends: %+v, %v", got, err)
+	}
+}
+
+func TestParseTweetsInOrder(t *testing.T) {
+	raw := json.RawMessage(`{"data":{"tweetResult":[
+		{"result":{"__typename":"Tweet","rest_id":"30","legacy":{"full_text":"third"}}},
+		{"result":{"__typename":"TweetTombstone"}},
+		{"result":{"__typename":"Tweet","rest_id":"10","legacy":{"full_text":"first"}}}
+	]}}`)
+	ids := []string{"10", "20", "30", "10"}
+	got, err := ParseTweetsInOrder(raw, ids)
+	if err != nil {
+		t.Fatalf("ParseTweetsInOrder error: %v", err)
+	}
+	if len(got) != len(ids) {
+		t.Fatalf("expected %d results, got %d", len(ids), len(got))
+	}
+	for i, id := range ids {
+		if id == "20" {
+			if got[i] != nil {
+				t.Fatalf("expected nil for missing tweet 20, got %+v", got[i])
+			}
+			continue
+		}
+		if got[i] == nil || got[i].ID != id {
+			t.Fatalf("result %d: expected tweet %s, got %+v", i, id, got[i])
+		}
+	}
+	if got[0].GetText() != "first" || got[2].GetText() != "third" {
+		t.Fatalf("unexpected texts: %q, %q", got[0].GetText(), got[2].GetText())
+	}
+}
+
+func TestParseLists_GraphQL(t *testing.T) {
+	raw := json.RawMessage(`{"data":{"timeline":{"instructions":[{"entries":[
+		{"content":{"itemContent":{"itemType":"TimelineTwitterList","list":{"id_string":"9","name":"Friends","mode":"Public","member_count":4,"subscriber_count":1,"created_at":1672671845000,
+			"user_results":{"result":{"__typename":"User","rest_id":"42","legacy":{"screen_name":"jack"}}}}}}},
+		{"content":{"itemContent":{"itemType":"TimelineTwitterList","list":{"id_string":"10","name":"Work","mode":"Private"}}}}
+	]}]}}}`)
+	lists, err := ParseLists(raw)
+	if err != nil {
+		t.Fatalf("ParseLists error: %v", err)
+	}
+	if len(lists) != 2 || lists[0].ID != "9" || lists[0].Mode != "public" || lists[0].MemberCount != 4 ||
+		lists[0].CreatedAt != "1672671845000" || lists[0].Owner == nil || lists[0].Owner.ScreenName != "jack" {
+		t.Fatalf("unexpected lists: %+v", lists)
+	}
+	if lists[1].ID != "10" || lists[1].Mode != "private" || lists[1].Owner != nil {
+		t.Fatalf("unexpected second list: %+v", lists[1])
+	}
+	if _, err := ParseLists(json.RawMessage(`{"lists":`)); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+}
+
+func TestParseNews(t *testing.T) {
+	flat := json.RawMessage(`{"news":[
+		{"title":"Go 1.23 released","summary":"Iterators land","url":"https://go.dev/blog/go1.23","source":"The Go Blog","published_at":"2024-08-13T00:00:00Z"},
+		{"headline":"Rust 2024","description":"New edition","link":"https://blog.rust-lang.org","source":{"name":"Rust Blog"},"publishedAt":"2025-02-20"},
+		{"summary":"untitled stories are skipped"}
+	]}`)
+	news, err := ParseNews(flat)
+	if err != nil {
+		t.Fatalf("ParseNews error: %v", err)
+	}
+	want := []NewsItem{
+		{Title: "Go 1.23 released", Summary: "Iterators land", URL: "https://go.dev/blog/go1.23", Source: "The Go Blog", PublishedAt: "2024-08-13T00:00:00Z"},
+		{Title: "Rust 2024", Summary: "New edition", URL: "https://blog.rust-lang.org", Source: "Rust Blog", PublishedAt: "2025-02-20"},
+	}
+	if !reflect.DeepEqual(news, want) {
+		t.Fatalf("unexpected flat news:\n got %+v\nwant %+v", news, want)
+	}
+
+	timeline := json.RawMessage(`{"data":{"timeline":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[
+		{"entryId":"news-module","content":{"entryType":"TimelineTimelineModule","items":[
+			{"item":{"itemContent":{"itemType":"TimelineEventSummary","title":"Markets rally","supportingText":"Stocks up 2%","timeString":"2 hours ago","url":{"url":"https://x.com/i/events/1"}}}},
+			{"item":{"itemContent":{"itemType":"TimelineTrend","name":"#NotNews"}}}
+		]}},
+		{"entryId":"news-2","content":{"entryType":"TimelineTimelineItem","itemContent":{"itemType":"TimelineEventSummary","title":"Storm warning","supportingText":"Weather"}}}
+	]}]}}}}`)
+	news, err = ParseNews(timeline)
+	if err != nil {
+		t.Fatalf("ParseNews error: %v", err)
+	}
+	if len(news) != 2 || news[0].Title != "Markets rally" || news[0].Summary != "Stocks up 2%" || news[0].URL != "https://x.com/i/events/1" ||
+		news[0].PublishedAt != "2 hours ago" || news[1].Title != "Storm warning" {
+		t.Fatalf("unexpected timeline news: %+v", news)
+	}
+}
+
+func TestParseExplore(t *testing.T) {
+	raw := json.RawMessage(`{"data":{"explore_page":{"body":{"initialTimeline":{"timeline":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[
+		{"entryId":"trends-1","content":{"entryType":"TimelineTimelineModule","header":{"text":"Trends for you"},"items":[
+			{"item":{"itemContent":{"itemType":"TimelineTrend","__typename":"TimelineTrend","name":"#golang","trend_url":{"url":"twitter://search?q=%23golang"},"trend_metadata":{"tweet_count":"12,500"}}}},
+			{"item":{"itemContent":{"itemType":"TimelineTrend","__typename":"TimelineTrend","name":"Gophers"}}}
+		]}},
+		{"entryId":"stories-1","content":{"entryType":"TimelineTimelineModule","header":{"text":"Today's News"},"items":[
+			{"item":{"itemContent":{"itemType":"TimelineEventSummary","title":"Go 1.23 released","supportingText":"Technology","url":{"url":"https://x.com/i/events/2"}}}}
+		]}},
+		{"entryId":"tweets-1","content":{"entryType":"TimelineTimelineModule","header":{"text":"Popular posts"},"items":[
+			{"item":{"itemContent":{"itemType":"TimelineTweet","tweet_results":{"result":{"__typename":"Tweet","rest_id":"42","legacy":{"full_text":"hello"}}}}}},
+			{"item":{"itemContent":{"itemType":"TimelineTrend","__typename":"TimelineTrend","name":"#golang"}}}
+		]}},
+		{"entryId":"who-to-follow","content":{"entryType":"TimelineTimelineModule","header":{"text":"Who to follow"},"items":[
+			{"item":{"itemContent":{"itemType":"TimelineUser","user_results":{"result":{"rest_id":"7"}}}}}
+		]}},
+		{"entryId":"cursor-bottom-1","content":{"entryType":"TimelineTimelineCursor","cursorType":"Bottom","value":"c1"}}
+	]}]}}}}}}}`)
+	res, err := ParseExplore(raw)
+	if err != nil {
+		t.Fatalf("ParseExplore error: %v", err)
+	}
+	if len(res.Trends) != 2 || res.Trends[0].Name != "#golang" || res.Trends[0].TweetCount != 12500 || res.Trends[1].Name != "Gophers" {
+		t.Fatalf("unexpected trends: %+v", res.Trends)
+	}
+	if len(res.Modules) != 3 {
+		t.Fatalf("expected 3 modules with content, got %+v", res.Modules)
+	}
+	trends, stories, posts := res.Modules[0], res.Modules[1], res.Modules[2]
+	if trends.Title != "Trends for you" || len(trends.Trends) != 2 || len(trends.News) != 0 {
+		t.Fatalf("unexpected trends module: %+v", trends)
+	}
+	if stories.Title != "Today's News" || len(stories.News) != 1 || stories.News[0].Title != "Go 1.23 released" || stories.News[0].URL != "https://x.com/i/events/2" {
+		t.Fatalf("unexpected news module: %+v", stories)
+	}
+	if posts.Title != "Popular posts" || len(posts.Tweets) != 1 || posts.Tweets[0].ID != "42" || len(posts.Trends) != 1 {
+		t.Fatalf("unexpected mixed module: %+v", posts)
+	}
+}
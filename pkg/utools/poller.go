@@ -0,0 +1,71 @@
+package utools
+
+import (
+	"context"
+	"sync"
+)
+
+// HomeTimelinePoller turns the home timeline into a "new since last check"
+// feed: each Poll returns only the tweets newer than every tweet returned
+// before. It follows the timeline's Top cursor when the upstream hands one
+// out and otherwise re-reads the first page. Like GetHomeTimeline, it
+// requires auth_token. A poller is safe for concurrent use; polls are
+// serialized.
+type HomeTimelinePoller struct {
+	client *Client
+
+	mu        sync.Mutex
+	sinceID   string // highest tweet ID returned so far
+	topCursor string // Top cursor of the last page, "" to read the first page
+}
+
+// NewHomeTimelinePoller creates a poller whose first Poll returns the first
+// page of the home timeline.
+func (c *Client) NewHomeTimelinePoller() *HomeTimelinePoller {
+	return &HomeTimelinePoller{client: c}
+}
+
+// Poll fetches the home timeline and returns the tweets posted since the
+// previous Poll, oldest first. An empty slice means nothing new. On error
+// the poller's state is unchanged, so the next Poll retries from the same
+// point.
+func (p *HomeTimelinePoller) Poll(ctx context.Context) ([]TweetResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	raw, err := p.client.GetHomeTimeline(ctx, p.topCursor)
+	if err != nil {
+		return nil, err
+	}
+	tweets, err := ParseTweetTimeline(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := []TweetResult{}
+	seen := make(map[string]struct{}, len(tweets))
+	for _, t := range tweets {
+		if _, dup := seen[t.ID]; dup || (p.sinceID != "" && compareIDs(t.ID, p.sinceID) <= 0) {
+			continue
+		}
+		seen[t.ID] = struct{}{}
+		fresh = append(fresh, t)
+	}
+	sortTweetsChronologically(fresh)
+
+	if n := len(fresh); n > 0 {
+		p.sinceID = fresh[n-1].ID
+	}
+	if _, top := extractCursors(string(raw)); top != "" {
+		p.topCursor = top
+	}
+	return fresh, nil
+}
+
+// SinceID returns the highest tweet ID returned so far, "" before the first
+// tweet.
+func (p *HomeTimelinePoller) SinceID() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sinceID
+}
@@ -0,0 +1,61 @@
+package utools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHomeTimelinePoller(t *testing.T) {
+	page := func(top string, ids ...string) string {
+		var entries []string
+		for _, id := range ids {
+			entries = append(entries, `{"entryId":"tweet-`+id+`","content":{"itemContent":{"tweet_results":{"result":{"rest_id":"`+id+`","legacy":{"full_text":"t`+id+`"}}}}}}`)
+		}
+		entries = append(entries, `{"entryId":"cursor-top","content":{"cursorType":"Top","value":"`+top+`"}}`)
+		return `{"data":{"home":{"home_timeline_urt":{"instructions":[{"entries":[` + strings.Join(entries, ",") + `]}]}}}}`
+	}
+	pages := map[string]string{
+		"":   page("t1", "103", "102", "101"),
+		"t1": page("t2", "105", "104", "103"), // overlaps with the first poll
+		"t2": page("t3"),
+	}
+	var cursors []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/homeTimeline" || r.URL.Query().Get("auth_token") != "test-auth" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		cursors = append(cursors, r.URL.Query().Get("cursor"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[r.URL.Query().Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	if _, err := client.NewHomeTimelinePoller().Poll(context.Background()); !errors.Is(err, ErrAuthTokenRequired) {
+		t.Fatalf("expected ErrAuthTokenRequired, got %v", err)
+	}
+	client.authToken = "test-auth"
+
+	p := client.NewHomeTimelinePoller()
+	var polls []string
+	for i := 0; i < 3; i++ {
+		tweets, err := p.Poll(context.Background())
+		if err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+		polls = append(polls, tweetIDs(tweets))
+	}
+	if got := strings.Join(polls, " | "); got != "101,102,103 | 104,105 | " {
+		t.Fatalf("unexpected polls: %q", got)
+	}
+	if got := strings.Join(cursors, ","); got != ",t1,t2" {
+		t.Fatalf("expected polls to follow the Top cursor, got %q", got)
+	}
+	if p.SinceID() != "105" {
+		t.Fatalf("SinceID = %q, want 105", p.SinceID())
+	}
+}
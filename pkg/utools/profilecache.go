@@ -0,0 +1,155 @@
+package utools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithProfileCache caches the profiles returned by GetUserProfile,
+// GetUserProfileRaw and GetUserProfileByID for ttl, so repeated lookups of
+// the same account do not cost a request. Handles are keyed normalized
+// (trimmed, lower-cased, "@" stripped), so "@Elon" and "elon" share an
+// entry, which is also filed under the account's current handle. With
+// aliasIDs set, entries are filed under the account's rest_id and handle
+// alike, so ID and handle lookups of the same account share one entry.
+// Only successful lookups are cached, and not-found ones for
+// Config.NegativeCacheTTL; each call gets its own copy of the UserResult
+// and of the raw payload.
+// ttl <= 0 leaves caching of profiles off.
+func WithProfileCache(ttl time.Duration, aliasIDs bool) Option {
+	return func(c *Client) {
+		var negativeTTL time.Duration
+		if c.profiles != nil {
+			negativeTTL = c.profiles.negativeTTL
+		}
+		c.profiles = newProfileCache(ttl, negativeTTL, aliasIDs)
+	}
+}
+
+// newProfileCache returns a profile cache, or nil if both TTLs are off.
+func newProfileCache(ttl, negativeTTL time.Duration, aliasIDs bool) *profileCache {
+	if ttl <= 0 && negativeTTL <= 0 {
+		return nil
+	}
+	return &profileCache{
+		ttl:         max(ttl, 0),
+		negativeTTL: max(negativeTTL, 0),
+		aliasIDs:    aliasIDs,
+		entries:     make(map[string]*profileEntry),
+		now:         time.Now,
+	}
+}
+
+// maxProfileCacheEntries bounds a profile cache. When it is full, expired
+// entries are dropped, and if that is not enough, all of them.
+const maxProfileCacheEntries = 10000
+
+// profileCache is the cache set up by WithProfileCache. It is safe for
+// concurrent use, and its methods are no-ops on a nil cache.
+type profileCache struct {
+	mu          sync.Mutex
+	ttl         time.Duration // 0 = profiles are not cached
+	negativeTTL time.Duration // 0 = not-found lookups are not cached
+	aliasIDs    bool
+	entries     map[string]*profileEntry // by profileHandleKey / profileIDKey
+	now         func() time.Time         // time.Now; replaced in tests
+}
+
+// profileEntry is a cached profile, shared by all of its keys, or a cached
+// not-found lookup, which has err set.
+type profileEntry struct {
+	user    UserResult
+	raw     json.RawMessage
+	err     error
+	expires time.Time
+}
+
+// normalizeScreenName returns the form of a handle used as cache key:
+// trimmed, without a leading "@" and lower-cased, as handles are
+// case-insensitive.
+func normalizeScreenName(screenName string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(screenName), "@"))
+}
+
+// profileHandleKey and profileIDKey return the cache keys of a handle and
+// a rest_id, which never collide.
+func profileHandleKey(screenName string) string { return "@" + normalizeScreenName(screenName) }
+func profileIDKey(userID string) string         { return "#" + strings.TrimSpace(userID) }
+
+// get returns a copy of the profile cached under key, with its payload, or
+// the error of a cached not-found lookup. ok reports whether key was cached.
+func (p *profileCache) get(key string) (user *UserResult, raw json.RawMessage, ok bool, err error) {
+	if p == nil {
+		return nil, nil, false, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[key]
+	if !ok {
+		return nil, nil, false, nil
+	}
+	if !p.now().Before(e.expires) {
+		delete(p.entries, key)
+		return nil, nil, false, nil
+	}
+	if e.err != nil {
+		return nil, bytes.Clone(e.raw), true, e.err
+	}
+	u := e.user
+	return &u, bytes.Clone(e.raw), true, nil
+}
+
+// put caches the outcome of a lookup under key. A profile is also filed
+// under its current handle for handle lookups and, with aliasIDs, under its
+// rest_id and handle whatever the lookup. A not-found error (ErrUserNotFound
+// or a refinement) is cached under key alone, for negativeTTL; other errors
+// are not cached.
+func (p *profileCache) put(key string, user *UserResult, raw json.RawMessage, err error) {
+	if p == nil {
+		return
+	}
+	switch {
+	case err != nil:
+		if p.negativeTTL > 0 && errors.Is(err, ErrUserNotFound) {
+			p.store([]string{key}, &profileEntry{raw: raw, err: err}, p.negativeTTL)
+		}
+		return
+	case user == nil || p.ttl <= 0:
+		return
+	}
+	keys := []string{key}
+	if user.ScreenName != "" && (strings.HasPrefix(key, "@") || p.aliasIDs) {
+		keys = append(keys, profileHandleKey(user.ScreenName))
+	}
+	if user.RestID != "" && p.aliasIDs {
+		keys = append(keys, profileIDKey(user.RestID))
+	}
+	p.store(keys, &profileEntry{user: *user, raw: raw}, p.ttl)
+}
+
+// store files e under keys, expiring after ttl. e.raw is copied, so the
+// caller's slice can be reused.
+func (p *profileCache) store(keys []string, e *profileEntry, ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if len(p.entries)+len(keys) > maxProfileCacheEntries {
+		for k, old := range p.entries {
+			if !now.Before(old.expires) {
+				delete(p.entries, k)
+			}
+		}
+		if len(p.entries)+len(keys) > maxProfileCacheEntries {
+			clear(p.entries)
+		}
+	}
+	e.raw = bytes.Clone(e.raw)
+	e.expires = now.Add(ttl)
+	for _, k := range keys {
+		p.entries[k] = e
+	}
+}
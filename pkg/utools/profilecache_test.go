@@ -0,0 +1,194 @@
+package utools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xCatch/xcatch/config"
+)
+
+// profileServer answers profile lookups by handle and by ID for the account
+// 44 (@Elon) and counts the requests per path.
+func profileServer(t *testing.T, hits map[string]int) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		if r.URL.Query().Get("screenName") == "missing" {
+			_, _ = w.Write([]byte(`{"code":1,"data":{"data":{}},"msg":"SUCCESS"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":{"data":{"user":{"result":{"__typename":"User","rest_id":"44",` +
+			`"core":{"screen_name":"Elon","name":"Elon"},"legacy":{"followers_count":7}}}}},"msg":"SUCCESS"}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// distinctEntries counts the cache entries, each shared by all of its keys.
+func distinctEntries(p *profileCache) int {
+	seen := make(map[*profileEntry]bool)
+	for _, e := range p.entries {
+		seen[e] = true
+	}
+	return len(seen)
+}
+
+func TestWithProfileCache_NormalizedHandles(t *testing.T) {
+	hits := map[string]int{}
+	client := newTestClient(t, profileServer(t, hits).URL)
+	WithProfileCache(time.Minute, false)(client)
+	ctx := context.Background()
+
+	for _, handle := range []string{"@Elon", " elon", "ELON"} {
+		user, err := client.GetUserProfile(ctx, handle)
+		if err != nil {
+			t.Fatalf("%q: %v", handle, err)
+		}
+		if user.RestID != "44" || user.FollowersCount != 7 {
+			t.Fatalf("%q: unexpected user %+v", handle, user)
+		}
+		user.FollowersCount = 0 // callers get copies
+	}
+	if n := hits["/api/base/apitools/userByScreenNameV2"]; n != 1 {
+		t.Fatalf("expected one request for the three spellings, got %d", n)
+	}
+	if n := distinctEntries(client.profiles); n != 1 {
+		t.Fatalf("expected one cache entry, got %d", n)
+	}
+
+	// Without aliasIDs, an ID lookup has its own entry.
+	if _, err := client.GetUserProfileByID(ctx, "44"); err != nil {
+		t.Fatalf("GetUserProfileByID error: %v", err)
+	}
+	if _, err := client.GetUserProfileByID(ctx, "44"); err != nil {
+		t.Fatalf("GetUserProfileByID error: %v", err)
+	}
+	if n := hits["/api/base/apitools/uerByIdRestIdV2"]; n != 1 {
+		t.Fatalf("expected one ID request, got %d", n)
+	}
+	if n := distinctEntries(client.profiles); n != 2 {
+		t.Fatalf("expected separate handle and ID entries, got %d", n)
+	}
+
+	// Failed lookups are not cached.
+	for range 2 {
+		if _, err := client.GetUserProfile(ctx, "missing"); err == nil {
+			t.Fatal("expected an error for a missing user")
+		}
+	}
+	if n := hits["/api/base/apitools/userByScreenNameV2"]; n != 3 {
+		t.Fatalf("expected failed lookups to be retried upstream, got %d requests", n)
+	}
+}
+
+func TestWithProfileCache_IDAliasAndExpiry(t *testing.T) {
+	hits := map[string]int{}
+	client := newTestClient(t, profileServer(t, hits).URL)
+	WithProfileCache(time.Minute, true)(client)
+	now := time.Now()
+	client.profiles.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := client.GetUserProfileByID(ctx, "44"); err != nil {
+		t.Fatalf("GetUserProfileByID error: %v", err)
+	}
+	user, err := client.GetUserProfile(ctx, "@elon")
+	if err != nil || user.RestID != "44" {
+		t.Fatalf("unexpected handle lookup: %+v, %v", user, err)
+	}
+	if hits["/api/base/apitools/userByScreenNameV2"] != 0 || distinctEntries(client.profiles) != 1 {
+		t.Fatalf("expected the handle lookup to hit the ID entry, got %v", hits)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := client.GetUserProfile(ctx, "elon"); err != nil {
+		t.Fatalf("GetUserProfile error: %v", err)
+	}
+	if n := hits["/api/base/apitools/userByScreenNameV2"]; n != 1 {
+		t.Fatalf("expected an expired entry to be refetched, got %d requests", n)
+	}
+}
+
+func TestNegativeCacheTTL(t *testing.T) {
+	hits := map[string]int{}
+	cfg := &config.Config{
+		BaseURL:          profileServer(t, hits).URL,
+		APIKey:           "test-key",
+		Timeout:          5 * time.Second,
+		RateLimit:        100,
+		NegativeCacheTTL: time.Minute,
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	now := time.Now()
+	client.profiles.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, handle := range []string{"missing", "@Missing"} {
+		if _, err := client.GetUserProfile(ctx, handle); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("%q: expected ErrUserNotFound, got %v", handle, err)
+		}
+	}
+	if n := hits["/api/base/apitools/userByScreenNameV2"]; n != 1 {
+		t.Fatalf("expected the second not-found lookup to skip the request, got %d requests", n)
+	}
+
+	// Found profiles are not cached without WithProfileCache.
+	for range 2 {
+		if _, err := client.GetUserProfile(ctx, "elon"); err != nil {
+			t.Fatalf("GetUserProfile error: %v", err)
+		}
+	}
+	if n := hits["/api/base/apitools/userByScreenNameV2"]; n != 3 {
+		t.Fatalf("expected found profiles to be refetched, got %d requests", n)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := client.GetUserProfile(ctx, "missing"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+	if n := hits["/api/base/apitools/userByScreenNameV2"]; n != 4 {
+		t.Fatalf("expected an expired not-found entry to be refetched, got %d requests", n)
+	}
+
+	// WithProfileCache keeps the negative TTL.
+	WithProfileCache(time.Hour, false)(client)
+	if client.profiles == nil || client.profiles.negativeTTL != time.Minute {
+		t.Fatalf("expected WithProfileCache to keep the negative TTL, got %+v", client.profiles)
+	}
+}
+
+func TestProfileCache_CopiesRaw(t *testing.T) {
+	p := newProfileCache(time.Minute, time.Minute, false)
+	for _, tc := range []struct {
+		key string
+		err error
+	}{
+		{"@elon", nil},
+		{"@missing", ErrUserNotFound},
+	} {
+		raw := json.RawMessage(`{"id":1}`)
+		var user *UserResult
+		if tc.err == nil {
+			user = &UserResult{RestID: "44", ScreenName: "elon"}
+		}
+		p.put(tc.key, user, raw, tc.err)
+		raw[2] = 'X' // the caller reuses its buffer
+
+		_, got, ok, _ := p.get(tc.key)
+		if !ok || string(got) != `{"id":1}` {
+			t.Fatalf("%s: expected the payload as put, got %q (cached %v)", tc.key, got, ok)
+		}
+		got[2] = 'Y' // callers get copies
+		if _, got, _, _ = p.get(tc.key); string(got) != `{"id":1}` {
+			t.Fatalf("%s: expected the cached payload to be unchanged, got %q", tc.key, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package utools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RequestOption customizes the calls made with a context. Options are
+// attached with WithRequestOptions and honoured by every Client method, so
+// the method signatures stay unchanged.
+type RequestOption func(*requestOptions) error
+
+// requestOptions is the per-call configuration carried by a context.
+type requestOptions struct {
+	failFastOnRateLimit   bool
+	failFastOnNotDeployed bool // set by getWithPathFallback
+	maxRetries            *int // nil = the client's Config.MaxRetries
+
+	err error // first option error, returned by the call
+}
+
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns a copy of ctx whose calls use opts, on top of
+// the options ctx already carries. An invalid option makes those calls fail
+// with its error before any request is sent.
+func WithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	ro := requestOptionsFrom(ctx)
+	for _, opt := range opts {
+		if err := opt(&ro); err != nil && ro.err == nil {
+			ro.err = err
+		}
+	}
+	return context.WithValue(ctx, requestOptionsKey{}, ro)
+}
+
+// requestOptionsFrom returns the options attached to ctx, or the zero value.
+func requestOptionsFrom(ctx context.Context) requestOptions {
+	ro, _ := ctx.Value(requestOptionsKey{}).(requestOptions)
+	return ro
+}
+
+// WithFailFastOnRateLimit makes a rate-limited call (HTTP 429 or code 88)
+// return its *APIError at once instead of retrying, for interactive paths
+// that would rather show "try again later" than wait out the backoff.
+// Other retryable errors are still retried.
+func WithFailFastOnRateLimit() RequestOption {
+	return func(ro *requestOptions) error {
+		ro.failFastOnRateLimit = true
+		return nil
+	}
+}
+
+// failFastOnNotDeployed makes a call for an endpoint that is not deployed
+// under the requested name (see isEndpointNotDeployed) return at once, so
+// getWithPathFallback moves on to the next alias without retrying.
+func failFastOnNotDeployed() RequestOption {
+	return func(ro *requestOptions) error {
+		ro.failFastOnNotDeployed = true
+		return nil
+	}
+}
+
+// WithMaxRetries overrides Config.MaxRetries for the call: 0 makes a single
+// attempt, e.g. for an expensive search during a known outage, while a cheap
+// lookup can retry more than the client default. n must not be negative.
+func WithMaxRetries(n int) RequestOption {
+	return func(ro *requestOptions) error {
+		if n < 0 {
+			return fmt.Errorf("utools: WithMaxRetries: negative retry count %d", n)
+		}
+		ro.maxRetries = &n
+		return nil
+	}
+}
+
+// retriesFor returns the number of retries allowed for a call made with ro.
+func (c *Client) retriesFor(ro requestOptions) int {
+	if ro.maxRetries != nil {
+		return *ro.maxRetries
+	}
+	return c.maxRetries
+}
+
+// isRateLimitError reports whether err is a rate-limit *APIError.
+func isRateLimitError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsRateLimited()
+}
@@ -0,0 +1,79 @@
+package utools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithFailFastOnRateLimit(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"code":88,"msg":"Rate limit exceeded"}`))
+	}))
+	defer ts.Close()
+	c := newTestClient(t, ts.URL)
+	WithBackoff(ConstantBackoff{Delay: time.Millisecond})(c)
+
+	ctx := WithRequestOptions(context.Background(), WithFailFastOnRateLimit())
+	err := c.Get(ctx, "/tweetSimple", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsRateLimited() {
+		t.Fatalf("expected a rate-limited APIError, got %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected a fail-fast request to make 1 attempt, got %d", got)
+	}
+
+	hits.Store(0)
+	if err := c.Get(context.Background(), "/tweetSimple", nil, nil); !errors.As(err, &apiErr) {
+		t.Fatalf("expected a rate-limited APIError, got %v", err)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Fatalf("expected a normal request to retry twice (3 attempts), got %d", got)
+	}
+}
+
+func TestWithMaxRetries(t *testing.T) {
+	// The server fails every attempt but each third one.
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1)%3 != 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"code":88,"msg":"Rate limit exceeded"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":"{}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	c := newTestClient(t, ts.URL)
+	WithBackoff(ConstantBackoff{Delay: time.Millisecond})(c)
+
+	ctx := WithRequestOptions(context.Background(), WithMaxRetries(0))
+	if err := c.Get(ctx, "/tweetSimple", nil, nil); err == nil {
+		t.Fatal("expected the single attempt to fail")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected WithMaxRetries(0) to make exactly 1 attempt, got %d", got)
+	}
+
+	hits.Store(0)
+	if err := c.Get(context.Background(), "/tweetSimple", nil, nil); err != nil {
+		t.Fatalf("expected the default retries to reach the third attempt: %v", err)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Fatalf("expected the default to make 3 attempts, got %d", got)
+	}
+
+	hits.Store(0)
+	ctx = WithRequestOptions(context.Background(), WithMaxRetries(-1))
+	if err := c.Get(ctx, "/tweetSimple", nil, nil); err == nil || hits.Load() != 0 {
+		t.Fatalf("expected a negative count to fail before any request, got %v after %d requests", err, hits.Load())
+	}
+}
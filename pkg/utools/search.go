@@ -3,6 +3,10 @@ package utools
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // SearchOptions contains optional query parameters for advanced search.
@@ -24,6 +28,122 @@ type SearchOptions struct {
 	Until      string
 }
 
+// ParseAdvancedQuery converts an X advanced-search query such as
+// `from:elonmusk min_faves:100 since:2024-01-01 "exact words" #go rust`
+// into structured SearchOptions. Recognised operators:
+//
+//	from:, to:, since:, until:, min_faves: (Likes), min_retweets: (Retweets),
+//	min_replies: (Replies), filter:images / filter:media (Type Photos),
+//	filter:videos (Type Videos), "quoted phrase" (Phrase), #tag (Tag),
+//	@user (Mentioning), -word (None)
+//
+// Hashtags, mentions and excluded words accumulate space-separated. A field
+// that can hold one value keeps the first occurrence; later ones, unknown
+// operators and free text are returned verbatim in keywords, which X still
+// interprets when passed as the search words.
+func ParseAdvancedQuery(q string) (keywords string, opts SearchOptions) {
+	var rest, tags, mentions, none []string
+
+	// setOnce stores v in *field unless it is already set, in which case the
+	// token stays in the keywords.
+	setOnce := func(field *string, v, tok string) {
+		if *field == "" && v != "" {
+			*field = v
+			return
+		}
+		rest = append(rest, tok)
+	}
+
+	for _, tok := range tokenizeQuery(q) {
+		if len(tok) >= 2 && tok[0] == '"' && tok[len(tok)-1] == '"' {
+			setOnce(&opts.Phrase, tok[1:len(tok)-1], tok)
+			continue
+		}
+		if op, v, ok := strings.Cut(tok, ":"); ok && v != "" {
+			switch strings.ToLower(op) {
+			case "from":
+				setOnce(&opts.From, strings.TrimPrefix(v, "@"), tok)
+				continue
+			case "to":
+				setOnce(&opts.To, strings.TrimPrefix(v, "@"), tok)
+				continue
+			case "since":
+				setOnce(&opts.Since, v, tok)
+				continue
+			case "until":
+				setOnce(&opts.Until, v, tok)
+				continue
+			case "min_faves":
+				setOnce(&opts.Likes, v, tok)
+				continue
+			case "min_retweets":
+				setOnce(&opts.Retweets, v, tok)
+				continue
+			case "min_replies":
+				setOnce(&opts.Replies, v, tok)
+				continue
+			case "filter":
+				switch strings.ToLower(v) {
+				case "images", "media":
+					setOnce(&opts.Type, "Photos", tok)
+					continue
+				case "videos", "native_video":
+					setOnce(&opts.Type, "Videos", tok)
+					continue
+				}
+			}
+		}
+		switch {
+		case len(tok) > 1 && tok[0] == '#':
+			tags = append(tags, tok[1:])
+		case len(tok) > 1 && tok[0] == '@':
+			mentions = append(mentions, tok[1:])
+		case len(tok) > 1 && tok[0] == '-' && !strings.Contains(tok, ":"):
+			none = append(none, tok[1:])
+		default:
+			rest = append(rest, tok)
+		}
+	}
+
+	opts.Tag = strings.Join(tags, " ")
+	opts.Mentioning = strings.Join(mentions, " ")
+	opts.None = strings.Join(none, " ")
+	return strings.Join(rest, " "), opts
+}
+
+// tokenizeQuery splits q on whitespace, keeping double-quoted phrases
+// (including their quotes) together. An unterminated quote runs to the end.
+func tokenizeQuery(q string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuote := false
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range q {
+		switch {
+		case r == '"':
+			if !inQuote {
+				flush()
+			}
+			cur.WriteRune(r)
+			if inQuote {
+				flush()
+			}
+			inQuote = !inQuote
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
 // ============================================================
 // Search APIs
 // ============================================================
@@ -101,20 +221,90 @@ func (c *Client) SearchBox(ctx context.Context, query string) (json.RawMessage,
 	return result, err
 }
 
+// SearchBoxParsed performs a search box query and returns the suggestions
+// parsed into users, topics and events.
+func (c *Client) SearchBoxParsed(ctx context.Context, query string) (*TypeaheadResult, error) {
+	raw, err := c.SearchBox(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTypeahead(raw)
+}
+
 // GetTrends retrieves trending topics for a given location.
 // woeid is the "Where On Earth ID" (e.g. "1" for worldwide, "23424977" for US).
 func (c *Client) GetTrends(ctx context.Context, woeid string) (json.RawMessage, error) {
 	params := map[string]string{}
 	if woeid != "" {
-		// Official generated API uses "id"; keep "woeid" as a compatibility alias.
-		params["id"] = woeid
-		params["woeid"] = woeid
+		// Official generated API uses "id"; "woeid" is a compatibility alias.
+		c.setIDParam(params, "/trends", "id", woeid)
 	}
 	var result json.RawMessage
 	err := c.Get(ctx, "/trends", params, &result)
 	return result, err
 }
 
+// GetTrendsForLocations retrieves and parses the trends of several
+// locations concurrently, one GetTrends call per distinct WOEID, all sharing
+// the client's rate limiter. The map holds the locations that succeeded; the
+// others are reported in a *MultiError keyed by WOEID, so one failing
+// location does not discard the rest.
+func (c *Client) GetTrendsForLocations(ctx context.Context, woeids []string) (map[string][]TrendResult, error) {
+	results := make(map[string][]TrendResult, len(woeids))
+	errs := &MultiError{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]struct{}, len(woeids))
+	for _, woeid := range woeids {
+		if _, dup := seen[woeid]; dup {
+			continue
+		}
+		seen[woeid] = struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			raw, err := c.GetTrends(ctx, woeid)
+			var trends []TrendResult
+			if err == nil {
+				trends, err = ParseTrends(raw)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs.Add(woeid, err)
+				return
+			}
+			results[woeid] = trends
+		}()
+	}
+	wg.Wait()
+	return results, errs.ErrOrNil()
+}
+
+// GetAvailableTrendLocations retrieves the locations GetTrends has trends
+// for. Use a location's WOEID as the GetTrends argument.
+func (c *Client) GetAvailableTrendLocations(ctx context.Context) ([]TrendLocation, error) {
+	var result json.RawMessage
+	if err := c.getWithPathFallback(ctx, endpointPaths("/trendsAvailable"), map[string]string{}, &result); err != nil {
+		return nil, err
+	}
+	return ParseTrendLocations(result)
+}
+
+// GetClosestTrendLocation retrieves the trend locations closest to the given
+// coordinates (usually one).
+func (c *Client) GetClosestTrendLocation(ctx context.Context, lat, long float64) ([]TrendLocation, error) {
+	params := map[string]string{
+		"lat":  strconv.FormatFloat(lat, 'f', -1, 64),
+		"long": strconv.FormatFloat(long, 'f', -1, 64),
+	}
+	var result json.RawMessage
+	if err := c.getWithPathFallback(ctx, endpointPaths("/trendsClosest"), params, &result); err != nil {
+		return nil, err
+	}
+	return ParseTrendLocations(result)
+}
+
 // GetTrending retrieves the current trending topics.
 func (c *Client) GetTrending(ctx context.Context) (json.RawMessage, error) {
 	params := map[string]string{}
@@ -123,6 +313,55 @@ func (c *Client) GetTrending(ctx context.Context) (json.RawMessage, error) {
 	return result, err
 }
 
+// GetTrendingForYou retrieves the trending topics personalized for the
+// authenticated user.
+// Requires auth_token to be set in the client config.
+func (c *Client) GetTrendingForYou(ctx context.Context) (json.RawMessage, error) {
+	if c.authToken == "" {
+		return nil, ErrAuthTokenRequired
+	}
+
+	params := map[string]string{}
+	params["auth_token"] = c.authToken
+	if c.ct0 != "" {
+		params["ct0"] = c.ct0
+	}
+	var result json.RawMessage
+	err := c.Get(ctx, "/trending", params, &result)
+	return result, err
+}
+
+// TrendingOptions selects the trends returned by GetTrendingParsed. The zero
+// value asks for the global trending topics.
+type TrendingOptions struct {
+	// WOEID scopes the trends to a location (see GetTrends).
+	WOEID string
+	// ForYou asks for the trends personalized for the authenticated user
+	// (see GetTrendingForYou); it cannot be combined with WOEID.
+	ForYou bool
+}
+
+// GetTrendingParsed retrieves and parses trending topics, dispatching on
+// opts to GetTrendingForYou, GetTrends or GetTrending.
+func (c *Client) GetTrendingParsed(ctx context.Context, opts TrendingOptions) ([]TrendResult, error) {
+	var raw json.RawMessage
+	var err error
+	switch {
+	case opts.ForYou && opts.WOEID != "":
+		return nil, errors.New("utools: trending: ForYou and WOEID are mutually exclusive")
+	case opts.ForYou:
+		raw, err = c.GetTrendingForYou(ctx)
+	case opts.WOEID != "":
+		raw, err = c.GetTrends(ctx, opts.WOEID)
+	default:
+		raw, err = c.GetTrending(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrends(raw)
+}
+
 // GetNews retrieves news content from Twitter.
 func (c *Client) GetNews(ctx context.Context) (json.RawMessage, error) {
 	params := map[string]string{}
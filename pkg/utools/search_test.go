@@ -3,8 +3,12 @@ package utools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -162,3 +166,185 @@ func TestSearchEndpoints_RequestMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAdvancedQuery_Operators(t *testing.T) {
+	keywords, opts := ParseAdvancedQuery("from:elonmusk to:@nasa min_faves:100 min_retweets:5 min_replies:2 since:2024-01-01 until:2024-02-01 filter:videos")
+	if keywords != "" {
+		t.Fatalf("expected no free text, got %q", keywords)
+	}
+	want := SearchOptions{
+		Type:     "Videos",
+		From:     "elonmusk",
+		To:       "nasa",
+		Likes:    "100",
+		Retweets: "5",
+		Replies:  "2",
+		Since:    "2024-01-01",
+		Until:    "2024-02-01",
+	}
+	if opts != want {
+		t.Fatalf("unexpected options:\n got %+v\nwant %+v", opts, want)
+	}
+}
+
+func TestParseAdvancedQuery_PhrasesTagsAndFreeText(t *testing.T) {
+	keywords, opts := ParseAdvancedQuery(`rust "memory safety" #golang @gopher -java lang:en "second phrase" #systems from:a from:b`)
+	if opts.Phrase != "memory safety" {
+		t.Fatalf("expected first quoted phrase, got %q", opts.Phrase)
+	}
+	if opts.Tag != "golang systems" || opts.Mentioning != "gopher" || opts.None != "java" || opts.From != "a" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	if keywords != `rust lang:en "second phrase" from:b` {
+		t.Fatalf("unexpected keywords: %q", keywords)
+	}
+}
+
+func TestParseAdvancedQuery_FreeTextOnly(t *testing.T) {
+	keywords, opts := ParseAdvancedQuery("  bitcoin   halving ")
+	if keywords != "bitcoin halving" || opts != (SearchOptions{}) {
+		t.Fatalf("unexpected result: %q %+v", keywords, opts)
+	}
+}
+
+func TestTrendLocations_RequestMapping(t *testing.T) {
+	var gotPath, gotLat, gotLong string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotLat, gotLong = r.URL.Query().Get("lat"), r.URL.Query().Get("long")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":[{"name":"San Francisco","woeid":2487956,"placeType":{"code":7,"name":"Town"}}],"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	locs, err := c.GetClosestTrendLocation(context.Background(), 37.781157, -122.400612)
+	if err != nil {
+		t.Fatalf("GetClosestTrendLocation error: %v", err)
+	}
+	if gotPath != "/api/base/apitools/trendsClosest" || gotLat != "37.781157" || gotLong != "-122.400612" {
+		t.Fatalf("unexpected request: path=%s lat=%q long=%q", gotPath, gotLat, gotLong)
+	}
+	if len(locs) != 1 || locs[0].WOEID != 2487956 {
+		t.Fatalf("unexpected locations: %+v", locs)
+	}
+
+	if _, err := c.GetAvailableTrendLocations(context.Background()); err != nil {
+		t.Fatalf("GetAvailableTrendLocations error: %v", err)
+	}
+	if gotPath != "/api/base/apitools/trendsAvailable" || gotLat != "" {
+		t.Fatalf("unexpected request: path=%s lat=%q", gotPath, gotLat)
+	}
+}
+
+func TestGetTrendsForLocations_PartialFailure(t *testing.T) {
+	var hits sync.Map
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		n, _ := hits.LoadOrStore(id, new(int32))
+		atomic.AddInt32(n.(*int32), 1)
+		if id == "404404" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"msg":"invalid woeid"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":[{"trends":[{"name":"#trend` + id + `","url":"http://twitter.com/search?q=x","query":"x","tweet_volume":null}],"locations":[{"name":"loc","woeid":` + id + `}]}],"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	got, err := c.GetTrendsForLocations(context.Background(), []string{"1", "404404", "23424977", "1"})
+
+	var multi *MultiError
+	if !errors.As(err, &multi) || multi.Len() != 1 || multi.Get("404404") == nil {
+		t.Fatalf("expected a MultiError for 404404 only, got %v", err)
+	}
+	if len(got) != 2 || got["1"][0].Name != "#trend1" || got["23424977"][0].Name != "#trend23424977" {
+		t.Fatalf("unexpected trends: %+v", got)
+	}
+	if _, ok := got["404404"]; ok {
+		t.Fatal("failed location must not appear in the result map")
+	}
+	if n, _ := hits.Load("1"); atomic.LoadInt32(n.(*int32)) != 1 {
+		t.Fatalf("duplicate WOEID should be fetched once, got %d", atomic.LoadInt32(n.(*int32)))
+	}
+}
+
+func TestSearchBoxParsed(t *testing.T) {
+	const body = `{"num_results":5,"query":"go",
+		"users":[{"id_str":"1","screen_name":"golang","name":"Go","verified":true},{"id_str":"2","screen_name":"gophercon","name":"GopherCon"}],
+		"topics":[{"topic":"golang","rounded_score":0},{"topic":"go generics"}],
+		"hashtags":[{"hashtag":"#golang"},{"hashtag":"gopher"}],
+		"events":[{"topic":"Go 1.23 released","url":"https://go.dev/blog","supporting_text":"Trending in Technology"}],
+		"lists":[],"oneclick":[]}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/searchBox" || r.URL.Query().Get("words") != "go" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	res, err := client.SearchBoxParsed(context.Background(), "go")
+	if err != nil {
+		t.Fatalf("SearchBoxParsed error: %v", err)
+	}
+	if len(res.Users) != 2 || res.Users[0].RestID != "1" || res.Users[0].ScreenName != "golang" || !res.Users[0].Verified || res.Users[1].ScreenName != "gophercon" {
+		t.Fatalf("unexpected users: %+v", res.Users)
+	}
+	if got := strings.Join(res.Topics, ","); got != "golang,go generics,#golang,#gopher" {
+		t.Fatalf("unexpected topics: %s", got)
+	}
+	if len(res.Events) != 1 || res.Events[0].Topic != "Go 1.23 released" || res.Events[0].URL != "https://go.dev/blog" || res.Events[0].SupportingText != "Trending in Technology" {
+		t.Fatalf("unexpected events: %+v", res.Events)
+	}
+
+	empty, err := ParseTypeahead(json.RawMessage(`{"num_results":0,"users":[],"topics":[]}`))
+	if err != nil || empty.Users == nil || empty.Topics == nil || empty.Events == nil {
+		t.Fatalf("expected empty, non-nil categories, got %+v, %v", empty, err)
+	}
+}
+
+func TestGetTrendingForYou_AuthRequired(t *testing.T) {
+	client := newTestClient(t, "http://127.0.0.1:0")
+	if _, err := client.GetTrendingForYou(context.Background()); !errors.Is(err, ErrAuthTokenRequired) {
+		t.Fatalf("expected ErrAuthTokenRequired, got %v", err)
+	}
+	if _, err := client.GetTrendingParsed(context.Background(), TrendingOptions{ForYou: true}); !errors.Is(err, ErrAuthTokenRequired) {
+		t.Fatalf("expected ErrAuthTokenRequired from GetTrendingParsed, got %v", err)
+	}
+	if _, err := client.GetTrendingParsed(context.Background(), TrendingOptions{ForYou: true, WOEID: "1"}); err == nil {
+		t.Fatal("expected ForYou with WOEID to be rejected")
+	}
+}
+
+func TestGetTrendingParsed_RequestMapping(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		requests = append(requests, r.URL.Path+" auth_token="+q.Get("auth_token")+" ct0="+q.Get("ct0")+" id="+q.Get("id"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":[{"trends":[{"name":"#go","url":"http://twitter.com/search?q=go","query":"go"}]}],"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	client.authToken = "auth-token"
+	client.ct0 = "ct0-token"
+	for _, opts := range []TrendingOptions{{ForYou: true}, {WOEID: "23424977"}, {}} {
+		trends, err := client.GetTrendingParsed(context.Background(), opts)
+		if err != nil || len(trends) != 1 || trends[0].Name != "#go" {
+			t.Fatalf("%+v: unexpected trends: %+v, %v", opts, trends, err)
+		}
+	}
+	want := []string{
+		"/api/base/apitools/trending auth_token=auth-token ct0=ct0-token id=",
+		"/api/base/apitools/trends auth_token= ct0= id=23424977",
+		"/api/base/apitools/trending auth_token= ct0= id=",
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected requests:\n%s", strings.Join(requests, "\n"))
+	}
+}
@@ -3,6 +3,8 @@ package utools
 import (
 	"context"
 	"encoding/json"
+	"strings"
+	"sync"
 )
 
 // ============================================================
@@ -37,6 +39,89 @@ func (c *Client) GetFollowings(ctx context.Context, userID string, cursor string
 	return result, err
 }
 
+// GetFollowersAll pages through a user's followers (up to maxPages pages,
+// 0 = unlimited) and returns them parsed and de-duplicated by rest_id, in
+// the order X lists them, most recent follower first: a user repeated on a
+// later page keeps its first position. If a page fails, the followers
+// collected so far are returned, in the same order, with the error.
+func (c *Client) GetFollowersAll(ctx context.Context, userID string, maxPages int) ([]UserResult, error) {
+	users, _, err := c.GetFollowersAllWithMeta(ctx, userID, maxPages)
+	return users, err
+}
+
+// GetFollowersAllWithMeta is GetFollowersAll, also returning how the
+// followers were gathered.
+func (c *Client) GetFollowersAllWithMeta(ctx context.Context, userID string, maxPages int) ([]UserResult, CollectMeta, error) {
+	it := c.NewPageIterator("/followersListV2", map[string]string{
+		"userId": userID,
+	}, maxPages)
+	return collectUsers(ctx, it)
+}
+
+// GetFollowingsAll pages through the accounts a user follows like
+// GetFollowersAll, in the order X lists them, most recently followed first.
+func (c *Client) GetFollowingsAll(ctx context.Context, userID string, maxPages int) ([]UserResult, error) {
+	users, _, err := c.GetFollowingsAllWithMeta(ctx, userID, maxPages)
+	return users, err
+}
+
+// GetFollowingsAllWithMeta is GetFollowingsAll, also returning how the
+// accounts were gathered.
+func (c *Client) GetFollowingsAllWithMeta(ctx context.Context, userID string, maxPages int) ([]UserResult, CollectMeta, error) {
+	it := c.NewPageIterator("/followingsListV2", map[string]string{
+		"userId": userID,
+	}, maxPages)
+	return collectUsers(ctx, it)
+}
+
+// GetUserWithSample retrieves a profile by screen name together with the
+// first sampleSize followers and followings (parsed), e.g. for quick bot
+// triage. The two samples are fetched concurrently once the profile has
+// resolved the user ID, and never span more than the first page of each
+// list; sampleSize <= 0 keeps the whole page. If the profile lookup fails
+// nothing else is fetched; if a sample fails, the rest is returned together
+// with a *MultiError keyed "followers" / "followings".
+func (c *Client) GetUserWithSample(ctx context.Context, screenName string, sampleSize int) (*UserSample, error) {
+	user, err := c.GetUserProfile(ctx, screenName)
+	if err != nil {
+		return nil, err
+	}
+	sample := &UserSample{User: user, Followers: []UserResult{}, Followings: []UserResult{}}
+
+	fetch := func(get func(context.Context, string, string) (json.RawMessage, error), dst *[]UserResult) error {
+		raw, err := get(ctx, user.RestID, "")
+		if err != nil {
+			return err
+		}
+		users, err := ParseUserList(raw)
+		if err != nil {
+			return err
+		}
+		if sampleSize > 0 && len(users) > sampleSize {
+			users = users[:sampleSize]
+		}
+		*dst = users
+		return nil
+	}
+	var wg sync.WaitGroup
+	var followersErr, followingsErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		followersErr = fetch(c.GetFollowers, &sample.Followers)
+	}()
+	go func() {
+		defer wg.Done()
+		followingsErr = fetch(c.GetFollowings, &sample.Followings)
+	}()
+	wg.Wait()
+
+	errs := &MultiError{}
+	errs.Add("followers", followersErr)
+	errs.Add("followings", followingsErr)
+	return sample, errs.ErrOrNil()
+}
+
 // GetFollowerIDs retrieves the follower IDs for a user.
 // cursor can be empty for the first page.
 func (c *Client) GetFollowerIDs(ctx context.Context, userID string, cursor string) (json.RawMessage, error) {
@@ -104,6 +189,46 @@ func (c *Client) GetBlueVerifiedFollowers(ctx context.Context, userID string, cu
 	return result, err
 }
 
+// GetUserRecommendations retrieves the "who to follow" recommendations shown
+// for a user. The response can be parsed with ParseUserList.
+// Requires auth_token to be set in the client config.
+func (c *Client) GetUserRecommendations(ctx context.Context, userID string) (json.RawMessage, error) {
+	if c.authToken == "" {
+		return nil, ErrAuthTokenRequired
+	}
+
+	params := map[string]string{
+		"userId":     userID,
+		"auth_token": c.authToken,
+	}
+	if c.ct0 != "" {
+		params["ct0"] = c.ct0
+	}
+	var result json.RawMessage
+	err := c.Get(ctx, "/userRecommendations", params, &result)
+	return result, err
+}
+
+// GetSimilarUsers retrieves the accounts X considers similar to a user.
+// The response can be parsed with ParseUserList.
+// Requires auth_token to be set in the client config.
+func (c *Client) GetSimilarUsers(ctx context.Context, userID string) (json.RawMessage, error) {
+	if c.authToken == "" {
+		return nil, ErrAuthTokenRequired
+	}
+
+	params := map[string]string{
+		"userId":     userID,
+		"auth_token": c.authToken,
+	}
+	if c.ct0 != "" {
+		params["ct0"] = c.ct0
+	}
+	var result json.RawMessage
+	err := c.getWithPathFallback(ctx, endpointPaths("/similarUsersV2"), params, &result)
+	return result, err
+}
+
 // ============================================================
 // List APIs
 // ============================================================
@@ -122,6 +247,28 @@ func (c *Client) GetListByUser(ctx context.Context, userID, screenName string) (
 	return result, err
 }
 
+// GetListByUserParsed retrieves the lists a user owns or subscribes to,
+// parsed. Owned is set on the lists whose owner matches userID or
+// screenName. A user without lists yields an empty slice.
+func (c *Client) GetListByUserParsed(ctx context.Context, userID, screenName string) ([]ListResult, error) {
+	raw, err := c.GetListByUser(ctx, userID, screenName)
+	if err != nil {
+		return nil, err
+	}
+	lists, err := ParseLists(raw)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range lists {
+		if l.Owner == nil {
+			continue
+		}
+		lists[i].Owned = (userID != "" && l.Owner.RestID == userID) ||
+			(screenName != "" && strings.EqualFold(l.Owner.ScreenName, strings.TrimPrefix(screenName, "@")))
+	}
+	return lists, nil
+}
+
 // GetListMembers retrieves members of a Twitter list (V2 endpoint).
 // cursor can be empty for the first page.
 func (c *Client) GetListMembers(ctx context.Context, listID string, cursor string) (json.RawMessage, error) {
@@ -136,6 +283,23 @@ func (c *Client) GetListMembers(ctx context.Context, listID string, cursor strin
 	return result, err
 }
 
+// GetListMembersAll pages through a list's members (up to maxPages pages,
+// 0 = unlimited) and returns them parsed and de-duplicated by rest_id.
+// If a page fails, the members collected so far are returned with the error.
+func (c *Client) GetListMembersAll(ctx context.Context, listID string, maxPages int) ([]UserResult, error) {
+	users, _, err := c.GetListMembersAllWithMeta(ctx, listID, maxPages)
+	return users, err
+}
+
+// GetListMembersAllWithMeta is GetListMembersAll, also returning how the
+// members were gathered.
+func (c *Client) GetListMembersAllWithMeta(ctx context.Context, listID string, maxPages int) ([]UserResult, CollectMeta, error) {
+	it := c.NewPageIterator("/listMembersByListIdV2", map[string]string{
+		"listId": listID,
+	}, maxPages)
+	return collectUsers(ctx, it)
+}
+
 // GetListTimeline retrieves the latest tweets from a Twitter list (V2 endpoint).
 // cursor can be empty for the first page.
 func (c *Client) GetListTimeline(ctx context.Context, listID string, cursor string) (json.RawMessage, error) {
@@ -150,6 +314,27 @@ func (c *Client) GetListTimeline(ctx context.Context, listID string, cursor stri
 	return result, err
 }
 
+// GetListTimelineAll pages through a list's tweets (up to maxPages pages,
+// 0 = unlimited) and returns them parsed, de-duplicated by tweet ID and in
+// chronological order (oldest first). A tweet repeated across pages keeps
+// the engagement counts of its latest copy. If a page fails, the tweets
+// collected so far are returned, in the same order, with the error.
+func (c *Client) GetListTimelineAll(ctx context.Context, listID string, maxPages int) ([]TweetResult, error) {
+	tweets, _, err := c.GetListTimelineAllWithMeta(ctx, listID, maxPages)
+	return tweets, err
+}
+
+// GetListTimelineAllWithMeta is GetListTimelineAll, also returning how the
+// tweets were gathered.
+func (c *Client) GetListTimelineAllWithMeta(ctx context.Context, listID string, maxPages int) ([]TweetResult, CollectMeta, error) {
+	it := c.NewPageIterator("/listLatestTweetsTimeline", map[string]string{
+		"listId": listID,
+	}, maxPages)
+	tweets, meta, err := collectTweets(ctx, it)
+	sortTweetsChronologically(tweets)
+	return tweets, meta, err
+}
+
 // ============================================================
 // Communities APIs
 // ============================================================
@@ -174,6 +359,16 @@ func (c *Client) GetCommunityInfo(ctx context.Context, communityID string) (json
 	return result, err
 }
 
+// GetCommunityInfoParsed retrieves a community's metadata parsed into a
+// CommunityResult: rules, join policy, primary topic and admin.
+func (c *Client) GetCommunityInfoParsed(ctx context.Context, communityID string) (*CommunityResult, error) {
+	raw, err := c.GetCommunityInfo(ctx, communityID)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCommunity(raw)
+}
+
 // GetCommunityTweets retrieves tweets from a community timeline.
 // cursor can be empty for the first page.
 func (c *Client) GetCommunityTweets(ctx context.Context, communityID string, cursor string) (json.RawMessage, error) {
@@ -188,6 +383,26 @@ func (c *Client) GetCommunityTweets(ctx context.Context, communityID string, cur
 	return result, err
 }
 
+// GetCommunityTweetsAll pages through a community's tweets (up to maxPages
+// pages, 0 = unlimited) and returns them parsed, de-duplicated by tweet ID
+// and in chronological order (oldest first). If a page fails, the tweets
+// collected so far are returned, in the same order, with the error.
+func (c *Client) GetCommunityTweetsAll(ctx context.Context, communityID string, maxPages int) ([]TweetResult, error) {
+	tweets, _, err := c.GetCommunityTweetsAllWithMeta(ctx, communityID, maxPages)
+	return tweets, err
+}
+
+// GetCommunityTweetsAllWithMeta is GetCommunityTweetsAll, also returning how
+// the tweets were gathered.
+func (c *Client) GetCommunityTweetsAllWithMeta(ctx context.Context, communityID string, maxPages int) ([]TweetResult, CollectMeta, error) {
+	it := c.NewPageIterator("/communitiesTweetsTimelineV2", map[string]string{
+		"communityId": communityID,
+	}, maxPages)
+	tweets, meta, err := collectTweets(ctx, it)
+	sortTweetsChronologically(tweets)
+	return tweets, meta, err
+}
+
 // GetCommunityMembers retrieves members of a community.
 // cursor can be empty for the first page.
 func (c *Client) GetCommunityMembers(ctx context.Context, communityID string, cursor string) (json.RawMessage, error) {
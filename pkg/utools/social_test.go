@@ -0,0 +1,464 @@
+package utools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetUserRecommendations_AuthRequired(t *testing.T) {
+	client := newTestClient(t, "http://127.0.0.1:0")
+	if _, err := client.GetUserRecommendations(context.Background(), "123"); !errors.Is(err, ErrAuthTokenRequired) {
+		t.Fatalf("expected ErrAuthTokenRequired, got %v", err)
+	}
+}
+
+func TestGetUserRecommendations_RequestMapping(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/userRecommendations" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("userId") != "123" {
+			t.Fatalf("expected userId=123, got %q", q.Get("userId"))
+		}
+		if q.Get("auth_token") != "auth-token" || q.Get("ct0") != "ct0-token" {
+			t.Fatalf("expected auth params, got auth_token=%q ct0=%q", q.Get("auth_token"), q.Get("ct0"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":{"ok":true},"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	client.authToken = "auth-token"
+	client.ct0 = "ct0-token"
+	if _, err := client.GetUserRecommendations(context.Background(), "123"); err != nil {
+		t.Fatalf("GetUserRecommendations error: %v", err)
+	}
+}
+
+// userPageFixture builds a GraphQL members page with the given user IDs and
+// an optional bottom cursor.
+func userPageFixture(cursor string, ids ...string) string {
+	var entries []string
+	for _, id := range ids {
+		entries = append(entries, `{"entryId":"user-`+id+`","content":{"itemContent":{"user_results":{"result":{"__typename":"User","rest_id":"`+id+`","legacy":{"screen_name":"u`+id+`"}}}}}}`)
+	}
+	if cursor != "" {
+		entries = append(entries, `{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"`+cursor+`"}}`)
+	}
+	return `{"data":{"list":{"members_timeline":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[` + strings.Join(entries, ",") + `]}]}}}}}`
+}
+
+func TestGetListMembersAll(t *testing.T) {
+	pages := map[string]string{
+		"":   userPageFixture("c1", "1", "2"),
+		"c1": userPageFixture("c2", "2", "3"),
+		"c2": userPageFixture("c3", "4"),
+	}
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path != "/api/base/apitools/listMembersByListIdV2" || r.URL.Query().Get("listId") != "L1" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		body, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			t.Fatalf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+
+	t.Run("dedupe across pages", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		users, err := client.GetListMembersAll(context.Background(), "L1", 3)
+		if err != nil {
+			t.Fatalf("GetListMembersAll error: %v", err)
+		}
+		if got := userIDs(users); got != "1,2,3,4" {
+			t.Fatalf("expected deduped members 1,2,3,4, got %s", got)
+		}
+	})
+
+	t.Run("page limit", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		users, err := client.GetListMembersAll(context.Background(), "L1", 2)
+		if err != nil {
+			t.Fatalf("GetListMembersAll error: %v", err)
+		}
+		if got := userIDs(users); got != "1,2,3" {
+			t.Fatalf("expected members 1,2,3 within 2 pages, got %s", got)
+		}
+		if got := atomic.LoadInt32(&hits); got != 2 {
+			t.Fatalf("expected 2 requests, got %d", got)
+		}
+	})
+}
+
+func TestGetListMembersAll_PartialOnError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("cursor") == "" {
+			_, _ = w.Write([]byte(`{"code":1,"data":` + userPageFixture("c1", "1", "2") + `,"msg":"SUCCESS"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":400,"msg":"bad cursor"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	users, err := client.GetListMembersAll(context.Background(), "L1", 0)
+	if err == nil {
+		t.Fatal("expected error from second page")
+	}
+	if got := userIDs(users); got != "1,2" {
+		t.Fatalf("expected partial members 1,2, got %s", got)
+	}
+}
+
+func userIDs(users []UserResult) string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.RestID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestGetSimilarUsers_AuthRequired(t *testing.T) {
+	client := newTestClient(t, "http://127.0.0.1:0")
+	if _, err := client.GetSimilarUsers(context.Background(), "123"); !errors.Is(err, ErrAuthTokenRequired) {
+		t.Fatalf("expected ErrAuthTokenRequired, got %v", err)
+	}
+}
+
+func TestGetSimilarUsers_FallsBackOnMissingEndpoint(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/api/base/apitools/similarUsersV2" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":404,"msg":"not found"}`))
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("userId") != "123" || q.Get("auth_token") != "auth-token" {
+			t.Fatalf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + userPageFixture("", "7", "8") + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	client.authToken = "auth-token"
+	raw, err := client.GetSimilarUsers(context.Background(), "123")
+	if err != nil {
+		t.Fatalf("GetSimilarUsers error: %v", err)
+	}
+	if got := strings.Join(paths, ","); got != "/api/base/apitools/similarUsersV2,/api/base/apitools/similarUsers" {
+		t.Fatalf("unexpected path sequence: %s", got)
+	}
+
+	users, err := ParseUserList(raw)
+	if err != nil {
+		t.Fatalf("ParseUserList error: %v", err)
+	}
+	if got := userIDs(users); got != "7,8" {
+		t.Fatalf("expected similar users 7,8, got %s", got)
+	}
+}
+
+func TestGetSimilarUsers_StopsOnNonNotFoundError(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":400,"msg":"bad request"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	client.authToken = "auth-token"
+	if _, err := client.GetSimilarUsers(context.Background(), "123"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Fatalf("expected no fallback after a 400, got %d requests", got)
+	}
+}
+
+func TestGetFollowersAndFollowingsAll_KeepUpstreamOrder(t *testing.T) {
+	// Most recent first, so IDs are not sorted; 10 is repeated on page two.
+	pages := map[string]string{
+		"":   userPageFixture("c1", "30", "10", "20"),
+		"c1": userPageFixture("", "10", "5", "40"),
+	}
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Query().Get("userId") != "44" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[r.URL.Query().Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	for name, get := range map[string]func(context.Context, string, int) ([]UserResult, error){
+		"followersListV2":  client.GetFollowersAll,
+		"followingsListV2": client.GetFollowingsAll,
+	} {
+		paths = nil
+		users, err := get(context.Background(), "44", 0)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := userIDs(users); got != "30,10,20,5,40" {
+			t.Fatalf("%s: expected upstream order 30,10,20,5,40, got %s", name, got)
+		}
+		if len(paths) != 2 || paths[0] != "/api/base/apitools/"+name {
+			t.Fatalf("%s: unexpected requests %v", name, paths)
+		}
+	}
+}
+
+// tweetPageFixture builds a GraphQL timeline page with the given tweet IDs
+// and an optional bottom cursor.
+func tweetPageFixture(cursor string, ids ...string) string {
+	var entries []string
+	for _, id := range ids {
+		entries = append(entries, `{"entryId":"tweet-`+id+`","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"`+id+`","legacy":{"full_text":"t`+id+`"}}}}}}`)
+	}
+	if cursor != "" {
+		entries = append(entries, `{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"`+cursor+`"}}`)
+	}
+	return `{"data":{"list":{"tweets_timeline":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[` + strings.Join(entries, ",") + `]}]}}}}}`
+}
+
+func tweetIDs(tweets []TweetResult) string {
+	ids := make([]string, len(tweets))
+	for i, t := range tweets {
+		ids[i] = t.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestGetListTimelineAll(t *testing.T) {
+	// Latest-first pages, with 1003 repeated across the page boundary.
+	pages := map[string]string{
+		"":   tweetPageFixture("c1", "1005", "1004", "1003"),
+		"c1": tweetPageFixture("c2", "1003", "999", "1002"),
+		"c2": tweetPageFixture("", "998"),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/listLatestTweetsTimeline" || r.URL.Query().Get("listId") != "L1" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		body, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"msg":"bad cursor"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	tweets, err := client.GetListTimelineAll(context.Background(), "L1", 0)
+	if err != nil {
+		t.Fatalf("GetListTimelineAll error: %v", err)
+	}
+	if got := tweetIDs(tweets); got != "998,999,1002,1003,1004,1005" {
+		t.Fatalf("expected deduped chronological tweets, got %s", got)
+	}
+
+	// A failing page still yields the tweets gathered before it.
+	delete(pages, "c2")
+	tweets, err = client.GetListTimelineAll(context.Background(), "L1", 0)
+	if err == nil {
+		t.Fatal("expected error from third page")
+	}
+	if got := tweetIDs(tweets); got != "999,1002,1003,1004,1005" {
+		t.Fatalf("expected partial chronological tweets, got %s", got)
+	}
+}
+
+func TestGetListTimelineAll_KeepsLatestMetrics(t *testing.T) {
+	tweet := func(id string, likes int, views string) string {
+		return `{"entryId":"tweet-` + id + `","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"` + id +
+			`","views":{"count":"` + views + `"},"legacy":{"full_text":"t` + id + `","favorite_count":` + strconv.Itoa(likes) + `}}}}}}`
+	}
+	page := func(cursor string, entries ...string) string {
+		if cursor != "" {
+			entries = append(entries, `{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"`+cursor+`"}}`)
+		}
+		return `{"data":{"list":{"tweets_timeline":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[` + strings.Join(entries, ",") + `]}]}}}}}`
+	}
+	// 1002 overlaps both pages; the second page was fetched later.
+	pages := map[string]string{
+		"":   page("c1", tweet("1003", 1, "10"), tweet("1002", 5, "50")),
+		"c1": page("", tweet("1002", 7, "80"), tweet("1001", 2, "20")),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[r.URL.Query().Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	tweets, err := client.GetListTimelineAll(context.Background(), "L1", 0)
+	if err != nil {
+		t.Fatalf("GetListTimelineAll error: %v", err)
+	}
+	if got := tweetIDs(tweets); got != "1001,1002,1003" {
+		t.Fatalf("expected deduped chronological tweets, got %s", got)
+	}
+	if got := tweets[1]; got.FavoriteCount != 7 || got.ViewCount != "80" || got.GetText() != "t1002" {
+		t.Fatalf("expected the repeated tweet to carry the newer metrics, got %+v", got)
+	}
+}
+
+func TestGetCommunityTweetsAll(t *testing.T) {
+	pages := map[string]string{
+		"":   tweetPageFixture("c1", "2003", "2001"),
+		"c1": tweetPageFixture("c2", "2001", "2002", "1999"),
+		"c2": tweetPageFixture("", "2000"),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/communitiesTweetsTimelineV2" || r.URL.Query().Get("communityId") != "C1" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		body, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"msg":"bad cursor"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	tweets, err := client.GetCommunityTweetsAll(context.Background(), "C1", 0)
+	if err != nil {
+		t.Fatalf("GetCommunityTweetsAll error: %v", err)
+	}
+	if got := tweetIDs(tweets); got != "1999,2000,2001,2002,2003" {
+		t.Fatalf("expected deduped chronological tweets, got %s", got)
+	}
+
+	delete(pages, "c2")
+	tweets, err = client.GetCommunityTweetsAll(context.Background(), "C1", 0)
+	if err == nil {
+		t.Fatal("expected error from third page")
+	}
+	if got := tweetIDs(tweets); got != "1999,2001,2002,2003" {
+		t.Fatalf("expected partial chronological tweets, got %s", got)
+	}
+}
+
+func TestGetUserWithSample(t *testing.T) {
+	followingsFail := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch r.URL.Path {
+		case "/api/base/apitools/userByScreenNameV2":
+			body = `{"data":{"user":{"result":{"__typename":"User","rest_id":"42","legacy":{"screen_name":"jack"}}}}}`
+		case "/api/base/apitools/followersListV2":
+			body = userPageFixture("more", "1", "2", "3")
+		case "/api/base/apitools/followingsListV2":
+			if followingsFail {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":400,"msg":"nope"}`))
+				return
+			}
+			body = userPageFixture("", "7")
+		default:
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		if strings.Contains(r.URL.Path, "List") && (r.URL.Query().Get("userId") != "42" || r.URL.Query().Has("cursor")) {
+			t.Fatalf("expected a first-page sample for user 42, got %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	sample, err := client.GetUserWithSample(context.Background(), "jack", 2)
+	if err != nil {
+		t.Fatalf("GetUserWithSample error: %v", err)
+	}
+	if sample.User.RestID != "42" || userIDs(sample.Followers) != "1,2" || userIDs(sample.Followings) != "7" {
+		t.Fatalf("unexpected sample: user=%+v followers=%s followings=%s", sample.User, userIDs(sample.Followers), userIDs(sample.Followings))
+	}
+
+	followingsFail = true
+	sample, err = client.GetUserWithSample(context.Background(), "jack", 0)
+	var multi *MultiError
+	if !errors.As(err, &multi) || multi.Get("followings") == nil || multi.Len() != 1 {
+		t.Fatalf("expected a MultiError for followings, got %v", err)
+	}
+	if sample == nil || sample.User == nil || userIDs(sample.Followers) != "1,2,3" || len(sample.Followings) != 0 {
+		t.Fatalf("expected partial sample, got %+v", sample)
+	}
+}
+
+func TestGetListByUserParsed(t *testing.T) {
+	body := `[
+		{"id_str":"101","name":"Go","slug":"go","description":"Gophers","mode":"public","member_count":12,"subscriber_count":"3",
+		 "created_at":"Mon Jan 02 15:04:05 +0000 2023","user":{"id_str":"42","screen_name":"Jack"}},
+		{"id_str":"202","name":"News","slug":"news","description":"","mode":"private","member_count":250,"subscriber_count":0,
+		 "created_at":"Tue Feb 03 10:00:00 +0000 2023","user":{"id_str":"7","screen_name":"someone"}}
+	]`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/getListByUserIdOrScreenName" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		data := body
+		if r.URL.Query().Get("screenName") == "nolists" {
+			data = `[]`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + data + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	lists, err := client.GetListByUserParsed(context.Background(), "", "jack")
+	if err != nil {
+		t.Fatalf("GetListByUserParsed error: %v", err)
+	}
+	if len(lists) != 2 {
+		t.Fatalf("expected 2 lists, got %d: %+v", len(lists), lists)
+	}
+	got := lists[0]
+	if got.ID != "101" || got.Name != "Go" || got.Slug != "go" || got.Description != "Gophers" || got.Mode != "public" ||
+		got.MemberCount != 12 || got.SubscriberCount != 3 || got.CreatedAt != "Mon Jan 02 15:04:05 +0000 2023" {
+		t.Fatalf("unexpected first list: %+v", got)
+	}
+	if got.Owner == nil || got.Owner.RestID != "42" || !got.Owned {
+		t.Fatalf("expected the first list to be owned by @jack, got owner %+v owned=%v", got.Owner, got.Owned)
+	}
+	if lists[1].ID != "202" || lists[1].Mode != "private" || lists[1].MemberCount != 250 || lists[1].Owned {
+		t.Fatalf("expected the second list to be a subscription, got %+v", lists[1])
+	}
+
+	lists, err = client.GetListByUserParsed(context.Background(), "", "nolists")
+	if err != nil || lists == nil || len(lists) != 0 {
+		t.Fatalf("expected an empty slice, got %v, %v", lists, err)
+	}
+}
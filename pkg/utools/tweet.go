@@ -3,8 +3,13 @@ package utools
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
 )
 
 // ============================================================
@@ -25,6 +30,188 @@ func (c *Client) GetUserTweets(ctx context.Context, userID string, cursor string
 	return result, err
 }
 
+// GetUserTweetsExpanded retrieves one page of a user's tweets (/userTweetsV2)
+// parsed with ParseTweetTimeline, then expands their retweets with
+// ExpandRetweets (at most maxFetch lookups, 0 = unlimited). If an expansion
+// lookup fails, the tweets are returned, with the affected retweets left as
+// parsed, together with the error.
+func (c *Client) GetUserTweetsExpanded(ctx context.Context, userID, cursor string, maxFetch int) ([]TweetResult, error) {
+	raw, err := c.GetUserTweets(ctx, userID, cursor)
+	if err != nil {
+		return nil, err
+	}
+	tweets, err := ParseTweetTimeline(raw)
+	if err != nil {
+		return nil, err
+	}
+	return tweets, c.ExpandRetweets(ctx, tweets, maxFetch)
+}
+
+// CountUserTweetsSince counts the tweets a user posted after since, paging
+// through /userTweetsV2 (up to maxPages pages, 0 = unlimited) only until the
+// first older tweet. Pinned tweets, which head the timeline whatever their
+// age, neither count nor stop the scan. Only each tweet's ID and created_at
+// are read. If a page fails, the count so far is returned with the error.
+func (c *Client) CountUserTweetsSince(ctx context.Context, userID string, since time.Time, maxPages int) (int, error) {
+	it := c.NewPageIterator("/userTweetsV2", map[string]string{
+		"userId": userID,
+	}, maxPages)
+
+	count := 0
+	seen := make(map[string]struct{})
+	for it.HasMore() {
+		page, err := it.Next(ctx)
+		if err != nil {
+			return count, err
+		}
+		if page == nil {
+			break
+		}
+		root := gjson.ParseBytes(page.RawData)
+		pinned := make(map[string]bool)
+		walkPinEntries(root, 0, func(entry gjson.Result) {
+			walkTweetResults(entry, func(node gjson.Result) {
+				id, _ := tweetNodeIDAndTime(node)
+				pinned[id] = true
+			})
+		})
+
+		reachedOlder := false
+		walkTweetResults(root, func(node gjson.Result) {
+			id, created := tweetNodeIDAndTime(node)
+			if reachedOlder || id == "" || created.IsZero() || pinned[id] {
+				return
+			}
+			if _, dup := seen[id]; dup {
+				return
+			}
+			seen[id] = struct{}{}
+			if !created.After(since) {
+				reachedOlder = true
+				return
+			}
+			count++
+		})
+		if reachedOlder {
+			break
+		}
+	}
+	return count, nil
+}
+
+// tweetNodeIDAndTime reads just the ID and creation time of a tweet node; the
+// time is zero when created_at is missing or unparseable.
+func tweetNodeIDAndTime(node gjson.Result) (string, time.Time) {
+	if node.Get("__typename").String() == "TweetWithVisibilityResults" {
+		node = node.Get("tweet")
+	}
+	id := firstString(node, "rest_id", "legacy.id_str", "id_str")
+	created := strings.TrimSpace(firstString(node, "legacy.created_at", "created_at"))
+	for _, layout := range createdAtLayouts {
+		if t, err := time.Parse(layout, created); err == nil {
+			return id, t
+		}
+	}
+	return id, time.Time{}
+}
+
+// walkPinEntries calls fn for the entry of every TimelinePinEntry
+// instruction in value.
+func walkPinEntries(value gjson.Result, depth int, fn func(gjson.Result)) {
+	if depth > maxParseDepth || (!value.IsObject() && !value.IsArray()) {
+		return
+	}
+	if value.IsObject() && value.Get("type").String() == "TimelinePinEntry" {
+		fn(value.Get("entry"))
+		return
+	}
+	value.ForEach(func(_, child gjson.Result) bool {
+		walkPinEntries(child, depth+1, fn)
+		return true
+	})
+}
+
+// retweetLookupBatch is the number of originals looked up per
+// /tweetResultsByRestIds request by ExpandRetweets, and
+// retweetLookupConcurrency the number of lookups run at once.
+const (
+	retweetLookupBatch       = maxBatch
+	retweetLookupConcurrency = 4
+)
+
+// ExpandRetweets replaces, in place, the RetweetedStatus of every retweet in
+// tweets whose inline original is missing or incomplete (no text or author,
+// or text cut short with "…") with the complete original, looked up in
+// batches via GetTweetsByIDs. At most maxFetch originals are looked up, 0 =
+// unlimited; the rest, and originals that no longer exist, keep their inline
+// data. Failed lookups are reported in a *MultiError keyed by original tweet
+// ID.
+func (c *Client) ExpandRetweets(ctx context.Context, tweets []TweetResult, maxFetch int) error {
+	var ids []string
+	wanted := make(map[string]bool)
+	for i := range tweets {
+		id := retweetToExpand(&tweets[i])
+		if id == "" || wanted[id] {
+			continue
+		}
+		if maxFetch > 0 && len(ids) == maxFetch {
+			break
+		}
+		wanted[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		errs     MultiError
+		sem      = make(chan struct{}, retweetLookupConcurrency)
+		original = make(map[string]TweetResult, len(ids))
+	)
+	for batch := range slices.Chunk(ids, retweetLookupBatch) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			found, _, err := c.GetTweetsByIDsParsed(ctx, batch)
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range batch {
+				errs.Add(id, err)
+			}
+			for _, t := range found {
+				original[t.ID] = t
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range tweets {
+		if t, ok := original[retweetToExpand(&tweets[i])]; ok {
+			tweets[i].RetweetedStatus = &t
+		}
+	}
+	return errs.ErrOrNil()
+}
+
+// retweetToExpand returns the ID of t's original when t is a retweet whose
+// inline original is missing or incomplete, and "" otherwise.
+func retweetToExpand(t *TweetResult) string {
+	rt := t.RetweetedStatus
+	if rt == nil {
+		return t.RetweetedStatusID
+	}
+	text := strings.TrimSpace(rt.GetText())
+	if text == "" || rt.User == nil || strings.HasSuffix(text, "…") {
+		return rt.ID
+	}
+	return ""
+}
+
 // GetUserTimeline retrieves the user timeline (same data as UserTweetsV2).
 // cursor can be empty for the first page.
 func (c *Client) GetUserTimeline(ctx context.Context, userID string, cursor string) (json.RawMessage, error) {
@@ -39,37 +226,380 @@ func (c *Client) GetUserTimeline(ctx context.Context, userID string, cursor stri
 	return result, err
 }
 
+// GetUserMedia retrieves one page of a user's media timeline: the tweets
+// with photos, videos or GIFs posted by the user. cursor can be empty for
+// the first page.
+func (c *Client) GetUserMedia(ctx context.Context, userID string, cursor string) (json.RawMessage, error) {
+	params := map[string]string{
+		"userId": userID,
+	}
+	if cursor != "" {
+		params["cursor"] = cursor
+	}
+	var result json.RawMessage
+	err := c.Get(ctx, "/userMedia", params, &result)
+	return result, err
+}
+
+// GetUserMediaTweets pages through a user's media timeline (/userMedia, up
+// to maxPages pages, 0 = unlimited) and returns the user's own tweets that
+// carry media, de-duplicated by ID and in chronological order (oldest
+// first). Retweets are skipped. When mediaCount is positive, typically the
+// profile's UserResult.MediaCount, paging stops as soon as the media items
+// collected reach it, which bounds crawls of prolific accounts; as the count
+// may be stale, paging also stops when the cursor runs out. If a page fails,
+// the tweets collected so far are returned, in the same order, with the
+// error.
+func (c *Client) GetUserMediaTweets(ctx context.Context, userID string, maxPages, mediaCount int) ([]TweetResult, error) {
+	tweets, _, err := c.GetUserMediaTweetsWithMeta(ctx, userID, maxPages, mediaCount)
+	return tweets, err
+}
+
+// GetUserMediaTweetsWithMeta is GetUserMediaTweets, also returning how the
+// tweets were gathered.
+func (c *Client) GetUserMediaTweetsWithMeta(ctx context.Context, userID string, maxPages, mediaCount int) ([]TweetResult, CollectMeta, error) {
+	it := c.NewPageIterator("/userMedia", map[string]string{
+		"userId": userID,
+	}, maxPages)
+
+	tweets := []TweetResult{}
+	seen := make(map[string]struct{})
+	items := 0
+	var meta CollectMeta
+	var err error
+	for it.HasMore() && (mediaCount <= 0 || items < mediaCount) {
+		var page *PageResult
+		if page, err = it.Next(ctx); err != nil || page == nil {
+			break
+		}
+		meta.observePage(page)
+		var parsed []TweetResult
+		if parsed, err = ParseTweetTimeline(page.RawData); err != nil {
+			err = fmt.Errorf("page %d: %w", it.PageCount(), err)
+			break
+		}
+		for _, t := range parsed {
+			media := tweetMedia(&t)
+			if _, dup := seen[t.ID]; dup || len(media) == 0 || t.RetweetedStatus != nil {
+				continue
+			}
+			if author := t.AuthorID(); author != "" && author != userID {
+				continue
+			}
+			seen[t.ID] = struct{}{}
+			tweets = append(tweets, t)
+			items += len(media)
+		}
+	}
+	meta.Items = len(tweets)
+	sortTweetsChronologically(tweets)
+	return tweets, meta, err
+}
+
+// GetUserMediaAll pages through a user's media timeline like
+// GetUserMediaTweets (up to maxPages pages, 0 = unlimited) and returns every
+// media item as a MediaDownload, oldest tweet first, de-duplicated by media
+// ID so media re-attached to several tweets is listed once. If a page fails,
+// the items collected so far are returned with the error.
+func (c *Client) GetUserMediaAll(ctx context.Context, userID string, maxPages int) ([]MediaDownload, error) {
+	tweets, err := c.GetUserMediaTweets(ctx, userID, maxPages, 0)
+	downloads := []MediaDownload{}
+	seen := make(map[string]struct{})
+	for i := range tweets {
+		for _, d := range tweets[i].MediaURLs() {
+			if _, dup := seen[d.MediaID]; dup && d.MediaID != "" {
+				continue
+			}
+			seen[d.MediaID] = struct{}{}
+			downloads = append(downloads, d)
+		}
+	}
+	return downloads, err
+}
+
+// tweetMedia returns the media attached to t, preferring extended_entities,
+// which lists every item of a multi-photo tweet.
+func tweetMedia(t *TweetResult) []MediaEntity {
+	if t.ExtendedEntities != nil && len(t.ExtendedEntities.Media) > 0 {
+		return t.ExtendedEntities.Media
+	}
+	if t.Entities != nil {
+		return t.Entities.Media
+	}
+	return nil
+}
+
 // GetTweetDetail retrieves a tweet's full details including its reply thread.
-// cursor can be empty for the first page of replies.
+// cursor can be empty for the first page of replies. Pages are cached by
+// tweet and cursor when Config.EndpointCacheTTL sets a TTL for
+// "/tweetTimeline".
 func (c *Client) GetTweetDetail(ctx context.Context, tweetID string, cursor string) (json.RawMessage, error) {
-	params := map[string]string{
-		"tweetId":  tweetID,
-		"tweet_id": tweetID,
-		"id":       tweetID,
+	key := strings.TrimSpace(tweetID) + "\x00" + cursor
+	if raw, ok := c.responses.get("/tweetTimeline", key); ok {
+		return raw, nil
 	}
+	params := map[string]string{}
+	c.setIDParam(params, "/tweetTimeline", "tweetId", tweetID)
 	if cursor != "" {
 		params["cursor"] = cursor
 	}
 	var result json.RawMessage
 	err := c.Get(ctx, "/tweetTimeline", params, &result)
+	if err == nil {
+		c.responses.put("/tweetTimeline", key, result)
+	}
 	return result, err
 }
 
+// ReplySort selects the order of the replies returned by GetTweetDetailAll.
+type ReplySort int
+
+const (
+	// SortDefault keeps the upstream's order (ranked by relevance).
+	SortDefault ReplySort = iota
+	// SortRecent orders replies newest first. Requested server-side
+	// (rankingMode=Recency) and enforced client-side by creation time.
+	SortRecent
+	// SortTop orders replies by likes, most first. Requested server-side
+	// (rankingMode=Likes) and enforced client-side by favorite_count.
+	SortTop
+	// SortControversial orders replies by their reply-to-like ratio, highest
+	// first. The upstream has no such ranking, so this is client-side only.
+	SortControversial
+)
+
+// replyRankingModes maps the sorts the upstream can rank by to the
+// rankingMode param of /tweetTimeline.
+var replyRankingModes = map[ReplySort]string{
+	SortRecent: "Recency",
+	SortTop:    "Likes",
+}
+
+// GetTweetDetailAll pages through a tweet's conversation (up to maxPages
+// pages, 0 = unlimited) and returns the tweet with its replies, the tweets
+// of the conversation posted after it, de-duplicated and ordered by sort.
+// Sorting server-side only decides which replies come first when maxPages
+// cuts the conversation short; the collected replies are always sorted
+// client-side as well, since the gateway may ignore the ranking param.
+// NextCursor is the last page's cursor, empty once the conversation is
+// exhausted, so a crawl cut short by maxPages can resume from it with
+// GetTweetDetail. If a page fails, the replies collected so far are
+// returned, sorted, with the error.
+func (c *Client) GetTweetDetailAll(ctx context.Context, tweetID string, maxPages int, sort ReplySort) (*TweetDetailResult, error) {
+	params := map[string]string{}
+	c.setIDParam(params, "/tweetTimeline", "tweetId", tweetID)
+	if mode, ok := replyRankingModes[sort]; ok {
+		params["rankingMode"] = mode
+	}
+	it := c.NewPageIterator("/tweetTimeline", params, maxPages)
+
+	detail := &TweetDetailResult{Replies: []TweetResult{}}
+	var focal *TweetResult
+	var tweets []TweetResult
+	seen := make(map[string]struct{})
+	var err, tombErr error
+	for it.HasMore() {
+		var page *PageResult
+		if page, err = it.Next(ctx); err != nil || page == nil {
+			break
+		}
+		detail.NextCursor = page.NextCursor
+		if focal == nil && tombErr == nil {
+			tombErr = focalTombstone(gjson.ParseBytes(page.RawData), tweetID)
+		}
+		var parsed []TweetResult
+		if parsed, err = ParseTweetTimeline(page.RawData); err != nil {
+			err = fmt.Errorf("page %d: %w", it.PageCount(), err)
+			break
+		}
+		for _, t := range parsed {
+			if _, dup := seen[t.ID]; dup {
+				continue
+			}
+			seen[t.ID] = struct{}{}
+			if t.ID == tweetID {
+				focal = &t
+				continue
+			}
+			tweets = append(tweets, t)
+		}
+	}
+	if focal == nil && err == nil {
+		if tombErr == nil {
+			return nil, ErrTweetNotFound
+		}
+		detail.Tombstoned, err = true, tombErr
+	}
+	if focal != nil {
+		detail.Tweet = *focal
+	}
+	for _, t := range tweets {
+		if compareIDs(t.ID, tweetID) > 0 {
+			detail.Replies = append(detail.Replies, t)
+		}
+	}
+	sortReplies(detail.Replies, sort)
+	return detail, err
+}
+
+// sortReplies orders replies by sort; SortDefault leaves them as they are.
+// Ties keep their upstream order.
+func sortReplies(replies []TweetResult, sort ReplySort) {
+	var less func(a, b *TweetResult) bool
+	switch sort {
+	case SortRecent:
+		less = func(a, b *TweetResult) bool { return compareIDs(a.ID, b.ID) > 0 }
+	case SortTop:
+		less = func(a, b *TweetResult) bool { return a.FavoriteCount > b.FavoriteCount }
+	case SortControversial:
+		ratio := func(t *TweetResult) float64 { return float64(t.ReplyCount) / float64(t.FavoriteCount+1) }
+		less = func(a, b *TweetResult) bool { return ratio(a) > ratio(b) }
+	default:
+		return
+	}
+	slices.SortStableFunc(replies, func(a, b TweetResult) int {
+		switch {
+		case less(&a, &b):
+			return -1
+		case less(&b, &a):
+			return 1
+		}
+		return 0
+	})
+}
+
+// selfThreadMaxPages bounds how many detail pages GetSelfThread reads.
+const selfThreadMaxPages = 20
+
+// GetSelfThread returns the self-thread containing tweetID: the conversation
+// root and the chain of its author's replies to their own tweets, oldest
+// first. Replies by other accounts, and the author's replies to them, are
+// left out. tweetID may be any tweet in the conversation.
+func (c *Client) GetSelfThread(ctx context.Context, tweetID string) ([]TweetResult, error) {
+	raw, err := c.GetTweetSimple(ctx, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := tweetNode(gjson.ParseBytes(raw))
+	if !ok {
+		return nil, ErrTweetNotFound
+	}
+	focal, ok := parseTweetNode(node)
+	if !ok {
+		return nil, ErrTweetNotFound
+	}
+	convID := focal.ConversationIDStr
+	if convID == "" {
+		convID = focal.ID
+	}
+
+	params := map[string]string{}
+	c.setIDParam(params, "/tweetTimeline", "tweetId", convID)
+	it := c.NewPageIterator("/tweetTimeline", params, selfThreadMaxPages)
+	byID := map[string]TweetResult{focal.ID: focal}
+	for it.HasMore() {
+		page, err := it.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			break
+		}
+		tweets, err := ParseTweetTimeline(page.RawData)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tweets {
+			byID[t.ID] = t
+		}
+	}
+
+	root, ok := byID[convID]
+	if !ok {
+		// Root deleted or withheld: follow the chain from the focal author.
+		root = focal
+	}
+	author := root.AuthorID()
+
+	candidates := make([]TweetResult, 0, len(byID))
+	for _, t := range byID {
+		if t.AuthorID() == author && (t.ConversationIDStr == convID || t.ID == convID) {
+			candidates = append(candidates, t)
+		}
+	}
+	// Chronological order puts every parent before its replies.
+	sortTweetsChronologically(candidates)
+
+	thread := []TweetResult{}
+	inThread := map[string]bool{}
+	for _, t := range candidates {
+		if t.ID == root.ID || (t.InReplyToUserID == author && inThread[t.InReplyToStatusID]) {
+			thread = append(thread, t)
+			inThread[t.ID] = true
+		}
+	}
+	return thread, nil
+}
+
+// compareIDs orders numeric string IDs without parsing them.
+func compareIDs(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
 // GetTweetSimple retrieves brief information about a tweet.
 func (c *Client) GetTweetSimple(ctx context.Context, tweetID string) (json.RawMessage, error) {
-	params := map[string]string{
-		"tweetId":  tweetID,
-		"tweet_id": tweetID,
-		"tweetIds": tweetID,
-		"id":       tweetID,
-	}
+	params := map[string]string{}
+	c.setIDParam(params, "/tweetSimple", "tweetId", tweetID)
 	var result json.RawMessage
 	err := c.Get(ctx, "/tweetSimple", params, &result)
 	return result, err
 }
 
-// GetTweetsByIDs retrieves multiple tweets by their IDs in batch.
+// GetTweetSimpleParsed retrieves brief information about a tweet and parses
+// it with ParseTweetSimple: a lightweight typed fetch of a single tweet.
+func (c *Client) GetTweetSimpleParsed(ctx context.Context, tweetID string) (*TweetResult, error) {
+	raw, err := c.GetTweetSimple(ctx, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTweetSimple(raw)
+}
+
+// GetTweetViews fetches a tweet via GetTweetSimple and returns its view
+// count. It returns ErrViewsUnavailable when the tweet carries no view count.
+func (c *Client) GetTweetViews(ctx context.Context, tweetID string) (int64, error) {
+	views, _, err := c.GetTweetViewsRaw(ctx, tweetID)
+	return views, err
+}
+
+// GetTweetViewsRaw is GetTweetViews that also returns the unmodified
+// tweetSimple payload. The payload is returned even when the view count is
+// unavailable; it is nil only when the request itself failed.
+func (c *Client) GetTweetViewsRaw(ctx context.Context, tweetID string) (int64, json.RawMessage, error) {
+	raw, err := c.GetTweetSimple(ctx, tweetID)
+	if err != nil {
+		return 0, nil, err
+	}
+	views, err := ParseTweetViews(raw)
+	return views, raw, err
+}
+
+// maxBatch is the most tweet IDs /tweetResultsByRestIds answers per
+// request; the upstream silently drops the rest.
+const maxBatch = 100
+
+// GetTweetsByIDs retrieves multiple tweets by their IDs in batch, at most
+// maxBatch (100) per call. More IDs fail with ErrTooManyIDs before any
+// request is sent; split them with slices.Chunk(ids, 100) and look each
+// chunk up separately.
 func (c *Client) GetTweetsByIDs(ctx context.Context, tweetIDs []string) (json.RawMessage, error) {
+	if len(tweetIDs) > maxBatch {
+		return nil, fmt.Errorf("%w: %d tweet IDs, at most %d per call (split them with slices.Chunk(ids, %d))",
+			ErrTooManyIDs, len(tweetIDs), maxBatch, maxBatch)
+	}
 	params := map[string]string{
 		"tweetIds": strings.Join(tweetIDs, ","),
 	}
@@ -78,6 +608,48 @@ func (c *Client) GetTweetsByIDs(ctx context.Context, tweetIDs []string) (json.Ra
 	return result, err
 }
 
+// GetTweetsByIDsParsed looks up tweets in batch and returns the ones that came
+// back, in the order requested, plus the requested IDs that did not
+// (deleted, protected or tombstoned tweets). Duplicate IDs are looked up once.
+func (c *Client) GetTweetsByIDsParsed(ctx context.Context, ids []string) (found []TweetResult, missing []string, err error) {
+	unique := make([]string, 0, len(ids))
+	requested := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id != "" && !requested[id] {
+			requested[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return []TweetResult{}, nil, nil
+	}
+
+	tweets, err := c.GetTweetsByIDsOrdered(ctx, unique)
+	if err != nil {
+		return nil, nil, err
+	}
+	found = make([]TweetResult, 0, len(tweets))
+	for i, t := range tweets {
+		if t != nil {
+			found = append(found, *t)
+		} else {
+			missing = append(missing, unique[i])
+		}
+	}
+	return found, missing, nil
+}
+
+// GetTweetsByIDsOrdered looks up tweets in batch and returns them aligned
+// with ids: result[i] is the tweet with ID ids[i], or nil when it did not
+// come back. See ParseTweetsInOrder.
+func (c *Client) GetTweetsByIDsOrdered(ctx context.Context, ids []string) ([]*TweetResult, error) {
+	raw, err := c.GetTweetsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTweetsInOrder(raw, ids)
+}
+
 // GetUserReplies retrieves reply tweets posted by a user.
 // cursor can be empty for the first page.
 func (c *Client) GetUserReplies(ctx context.Context, userID string, cursor string) (json.RawMessage, error) {
@@ -131,7 +703,7 @@ func (c *Client) GetUserHighlights(ctx context.Context, userID string, cursor st
 		params["cursor"] = cursor
 	}
 	var result json.RawMessage
-	err := c.Get(ctx, "/highlightsV2", params, &result)
+	err := c.getWithPathFallback(ctx, endpointPaths("/highlightsV2"), params, &result)
 	return result, err
 }
 
@@ -146,37 +718,11 @@ func (c *Client) GetUserArticlesTweets(ctx context.Context, userID string, curso
 	}
 
 	// Upstream has changed this endpoint name in some deployments.
-	// Try known candidates before failing.
-	paths := []string{"/userArticlesTweets", "/userArticlesTweetsV2", "/userArticleTweets"}
 	var result json.RawMessage
-	var lastErr error
-	for i, path := range paths {
-		err := c.Get(ctx, path, params, &result)
-		if err == nil {
-			return result, nil
-		}
-		lastErr = err
-		if i == len(paths)-1 || !shouldRetryWithNextTweetEndpoint(err) {
-			return nil, err
-		}
+	if err := c.getWithPathFallback(ctx, endpointPaths("/userArticlesTweets"), params, &result); err != nil {
+		return nil, err
 	}
-	return nil, lastErr
-}
-
-func shouldRetryWithNextTweetEndpoint(err error) bool {
-	var apiErr *APIError
-	if !errors.As(err, &apiErr) {
-		return false
-	}
-	if apiErr.StatusCode >= 500 {
-		return true
-	}
-
-	msg := strings.ToLower(apiErr.Message + " " + apiErr.RawBody)
-	if strings.Contains(msg, "no static resource") || strings.Contains(msg, "not found") {
-		return true
-	}
-	return false
+	return result, nil
 }
 
 // GetHomeTimeline retrieves the authenticated user's home timeline.
@@ -239,15 +785,30 @@ func (c *Client) GetRetweeters(ctx context.Context, tweetID string, cursor strin
 	return result, err
 }
 
+// GetRetweetersAll pages through the users who retweeted a tweet (up to
+// maxPages pages, 0 = unlimited) and returns them parsed and de-duplicated by
+// rest_id. If a page fails, the users collected so far are returned with the
+// error.
+func (c *Client) GetRetweetersAll(ctx context.Context, tweetID string, maxPages int) ([]UserResult, error) {
+	users, _, err := c.GetRetweetersAllWithMeta(ctx, tweetID, maxPages)
+	return users, err
+}
+
+// GetRetweetersAllWithMeta is GetRetweetersAll, also returning how the users
+// were gathered.
+func (c *Client) GetRetweetersAllWithMeta(ctx context.Context, tweetID string, maxPages int) ([]UserResult, CollectMeta, error) {
+	it := c.NewPageIterator("/retweetersV2", map[string]string{
+		"tweetId": tweetID,
+	}, maxPages)
+	return collectUsers(ctx, it)
+}
+
 // GetRetweetersIDs retrieves retweeter IDs for a tweet.
 // Uses the official deprecated Get Tweet endpoint (retweetersIds).
 // cursor can be empty for the first page.
 func (c *Client) GetRetweetersIDs(ctx context.Context, tweetID string, cursor string) (json.RawMessage, error) {
-	params := map[string]string{
-		"tweetId":  tweetID,
-		"tweet_id": tweetID,
-		"id":       tweetID,
-	}
+	params := map[string]string{}
+	c.setIDParam(params, "/retweetersIds", "tweetId", tweetID)
 	if cursor != "" {
 		params["cursor"] = cursor
 	}
@@ -278,6 +839,32 @@ func (c *Client) GetFavoriters(ctx context.Context, tweetID string, cursor strin
 	return result, err
 }
 
+// GetFavoritersAll pages through the users who liked a tweet (up to maxPages
+// pages, 0 = unlimited) and returns them parsed and de-duplicated by rest_id.
+// If a page fails, the users collected so far are returned with the error.
+// Requires auth_token to be set in the client config.
+func (c *Client) GetFavoritersAll(ctx context.Context, tweetID string, maxPages int) ([]UserResult, error) {
+	users, _, err := c.GetFavoritersAllWithMeta(ctx, tweetID, maxPages)
+	return users, err
+}
+
+// GetFavoritersAllWithMeta is GetFavoritersAll, also returning how the users
+// were gathered.
+func (c *Client) GetFavoritersAllWithMeta(ctx context.Context, tweetID string, maxPages int) ([]UserResult, CollectMeta, error) {
+	if c.authToken == "" {
+		return nil, CollectMeta{}, ErrAuthTokenRequired
+	}
+
+	params := map[string]string{
+		"tweetId":    tweetID,
+		"auth_token": c.authToken,
+	}
+	if c.ct0 != "" {
+		params["ct0"] = c.ct0
+	}
+	return collectUsers(ctx, c.NewPageIterator("/favoritersV2", params, maxPages))
+}
+
 // GetQuotes retrieves quote tweets for a given tweet (V2 endpoint).
 // cursor can be empty for the first page.
 func (c *Client) GetQuotes(ctx context.Context, tweetID string, cursor string) (json.RawMessage, error) {
@@ -4,9 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/xCatch/xcatch/config"
 )
 
 func TestTweetEndpoints_RequestMapping(t *testing.T) {
@@ -284,3 +292,720 @@ func TestTweetTimelines_PassesAuthTokenAndCT0(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTweetViews(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/tweetSimple" || r.URL.Query().Get("tweetId") != "456" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":"{\"data\":{\"tweetResult\":{\"result\":{\"rest_id\":\"456\",\"views\":{\"count\":\"1024\"}}}}}","msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	views, err := client.GetTweetViews(context.Background(), "456")
+	if err != nil {
+		t.Fatalf("GetTweetViews error: %v", err)
+	}
+	if views != 1024 {
+		t.Fatalf("expected 1024 views, got %d", views)
+	}
+}
+
+// threadTweetFixture builds a GraphQL tweet node in conversation 100.
+func threadTweetFixture(id, authorID, replyTo, replyToUser string) string {
+	return `{"__typename":"Tweet","rest_id":"` + id + `",` +
+		`"core":{"user_results":{"result":{"rest_id":"` + authorID + `","legacy":{"screen_name":"u` + authorID + `"}}}},` +
+		`"legacy":{"full_text":"tweet ` + id + `","conversation_id_str":"100","user_id_str":"` + authorID + `",` +
+		`"in_reply_to_status_id_str":"` + replyTo + `","in_reply_to_user_id_str":"` + replyToUser + `"}}`
+}
+
+func threadEntry(tweet string) string {
+	return `{"entryId":"conversationthread-x","content":{"items":[{"item":{"itemContent":{"tweet_results":{"result":` + tweet + `}}}}]}}`
+}
+
+func TestGetSelfThread(t *testing.T) {
+	// Author 1 writes 100 -> 102 -> 103. Others reply in between, and the
+	// author's answer to someone else (105) is not part of the self-thread.
+	page1 := `{"data":{"threaded_conversation_with_injections_v2":{"instructions":[{"type":"TimelineAddEntries","entries":[` +
+		threadEntry(threadTweetFixture("100", "1", "", "")) + `,` +
+		threadEntry(threadTweetFixture("101", "2", "100", "1")) + `,` +
+		threadEntry(threadTweetFixture("102", "1", "100", "1")) + `,` +
+		`{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"p2"}}` +
+		`]}]}}}`
+	page2 := `{"data":{"threaded_conversation_with_injections_v2":{"instructions":[{"type":"TimelineAddEntries","entries":[` +
+		threadEntry(threadTweetFixture("104", "3", "102", "1")) + `,` +
+		threadEntry(threadTweetFixture("105", "1", "101", "2")) + `,` +
+		threadEntry(threadTweetFixture("103", "1", "102", "1")) +
+		`]}]}}}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var data string
+		switch r.URL.Path {
+		case "/api/base/apitools/tweetSimple":
+			if q.Get("tweetId") != "102" {
+				t.Fatalf("unexpected focal tweet: %s", r.URL.RawQuery)
+			}
+			data = `{"data":{"tweetResult":{"result":` + threadTweetFixture("102", "1", "100", "1") + `}}}`
+		case "/api/base/apitools/tweetTimeline":
+			if q.Get("tweetId") != "100" {
+				t.Fatalf("expected detail of conversation root, got %s", r.URL.RawQuery)
+			}
+			switch q.Get("cursor") {
+			case "":
+				data = page1
+			case "p2":
+				data = page2
+			default:
+				t.Fatalf("unexpected cursor %q", q.Get("cursor"))
+			}
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + data + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	thread, err := client.GetSelfThread(context.Background(), "102")
+	if err != nil {
+		t.Fatalf("GetSelfThread error: %v", err)
+	}
+	var ids []string
+	for _, tw := range thread {
+		ids = append(ids, tw.ID)
+	}
+	if got := strings.Join(ids, ","); got != "100,102,103" {
+		t.Fatalf("expected self-thread 100,102,103, got %s", got)
+	}
+	if thread[2].GetText() != "tweet 103" {
+		t.Fatalf("unexpected text: %q", thread[2].GetText())
+	}
+}
+
+func TestGetFavoritersAll_AuthRequired(t *testing.T) {
+	client := newTestClient(t, "http://127.0.0.1:0")
+	if _, err := client.GetFavoritersAll(context.Background(), "456", 0); !errors.Is(err, ErrAuthTokenRequired) {
+		t.Fatalf("expected ErrAuthTokenRequired, got %v", err)
+	}
+}
+
+func TestGetFavoritersAll_PaginatesAndDedupes(t *testing.T) {
+	pages := map[string]string{
+		"":   userPageFixture("c1", "1", "2"),
+		"c1": userPageFixture("c2", "2", "3"),
+		"c2": userPageFixture("", "3", "4"),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api/base/apitools/favoritersV2" || q.Get("tweetId") != "456" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		if q.Get("auth_token") != "auth-token" || q.Get("ct0") != "ct0-token" {
+			t.Fatalf("expected auth params on every page, got %s", r.URL.RawQuery)
+		}
+		body, ok := pages[q.Get("cursor")]
+		if !ok {
+			t.Fatalf("unexpected cursor %q", q.Get("cursor"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	client.authToken = "auth-token"
+	client.ct0 = "ct0-token"
+	users, err := client.GetFavoritersAll(context.Background(), "456", 0)
+	if err != nil {
+		t.Fatalf("GetFavoritersAll error: %v", err)
+	}
+	if got := userIDs(users); got != "1,2,3,4" {
+		t.Fatalf("expected deduped likers 1,2,3,4, got %s", got)
+	}
+}
+
+func TestGetUserMediaTweets_StopsAtMediaCount(t *testing.T) {
+	// mediaPage builds a timeline page; ids ending in "m" carry two photos
+	// by user 42, "r" marks a retweet with media, the rest are text-only.
+	mediaPage := func(cursor string, ids ...string) string {
+		var entries []string
+		for _, id := range ids {
+			legacy := `"full_text":"t","user_id_str":"42"`
+			switch {
+			case strings.HasSuffix(id, "m"):
+				legacy += `,"extended_entities":{"media":[{"id_str":"` + id + `a"},{"id_str":"` + id + `b"}]}`
+			case strings.HasSuffix(id, "r"):
+				legacy += `,"extended_entities":{"media":[{"id_str":"x"}]},"retweeted_status_result":{"result":{"rest_id":"9","legacy":{"user_id_str":"7"}}}`
+			}
+			id = strings.TrimRight(id, "mr")
+			entries = append(entries, `{"entryId":"tweet-`+id+`","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"`+id+`","legacy":{`+legacy+`}}}}}}`)
+		}
+		entries = append(entries, `{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"`+cursor+`"}}`)
+		return `{"data":{"user":{"result":{"timeline":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[` + strings.Join(entries, ",") + `]}]}}}}}}`
+	}
+	pages := map[string]string{
+		"":   mediaPage("c1", "106m", "105", "104r"),
+		"c1": mediaPage("c2", "103m", "102"),
+		"c2": mediaPage("c3", "101m"),
+	}
+	var fetched []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api/base/apitools/userMedia" || q.Get("userId") != "42" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		fetched = append(fetched, q.Get("cursor"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[q.Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	tweets, err := client.GetUserMediaTweets(context.Background(), "42", 0, 4)
+	if err != nil {
+		t.Fatalf("GetUserMediaTweets error: %v", err)
+	}
+	if got := tweetIDs(tweets); got != "103,106" {
+		t.Fatalf("expected media tweets 103,106, got %s", got)
+	}
+	if strings.Join(fetched, ",") != ",c1" {
+		t.Fatalf("expected paging to stop after page 2 once media_count was reached, fetched cursors %q", fetched)
+	}
+
+	// A stale (too high) count stops on cursor exhaustion instead.
+	pages["c3"] = mediaPage("", "100")
+	pages["c2"] = mediaPage("c3", "101m")
+	fetched = nil
+	tweets, err = client.GetUserMediaTweets(context.Background(), "42", 0, 100)
+	if err != nil || tweetIDs(tweets) != "101,103,106" || len(fetched) != 4 {
+		t.Fatalf("expected all media tweets over 4 pages, got %s after %q, %v", tweetIDs(tweets), fetched, err)
+	}
+}
+
+func TestGetUserMediaAll(t *testing.T) {
+	entry := func(id, createdAt, media string) string {
+		return `{"entryId":"tweet-` + id + `","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"` + id +
+			`","legacy":{"full_text":"t","user_id_str":"42","created_at":"` + createdAt + `","extended_entities":{"media":[` + media + `]}}}}}}}`
+	}
+	page := func(cursor string, entries ...string) string {
+		if cursor != "" {
+			entries = append(entries, `{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"`+cursor+`"}}`)
+		}
+		return `{"data":{"user":{"result":{"timeline":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[` + strings.Join(entries, ",") + `]}]}}}}}}`
+	}
+	photo := func(id string) string {
+		return `{"id_str":"` + id + `","type":"photo","media_url_https":"https://pbs.twimg.com/media/` + id + `.jpg"}`
+	}
+	video := `{"id_str":"v1","type":"video","media_url_https":"https://pbs.twimg.com/thumb.jpg","video_info":{"variants":[
+		{"content_type":"application/x-mpegURL","url":"https://video.twimg.com/v.m3u8"},
+		{"content_type":"video/mp4","bitrate":832000,"url":"https://video.twimg.com/low.mp4"},
+		{"content_type":"video/mp4","bitrate":2176000,"url":"https://video.twimg.com/high.mp4"}]}}`
+	pages := map[string]string{
+		"": page("c1",
+			entry("103", "Wed Mar 03 00:00:00 +0000 2021", photo("p3")),
+			entry("102", "Tue Mar 02 00:00:00 +0000 2021", video+","+photo("p2"))),
+		// p2 is re-attached to tweet 101 on the next page.
+		"c1": page("", entry("101", "Mon Mar 01 00:00:00 +0000 2021", photo("p1")+","+photo("p2"))),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/userMedia" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[r.URL.Query().Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	downloads, err := newTestClient(t, ts.URL).GetUserMediaAll(context.Background(), "42", 0)
+	if err != nil {
+		t.Fatalf("GetUserMediaAll error: %v", err)
+	}
+	var got []string
+	for _, d := range downloads {
+		got = append(got, d.TweetID+"/"+d.MediaID+"/"+d.Type+"/"+d.URL)
+	}
+	want := []string{
+		"101/p1/photo/https://pbs.twimg.com/media/p1.jpg",
+		"101/p2/photo/https://pbs.twimg.com/media/p2.jpg",
+		"102/v1/video/https://video.twimg.com/high.mp4",
+		"103/p3/photo/https://pbs.twimg.com/media/p3.jpg",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected downloads:\n%s", strings.Join(got, "\n"))
+	}
+	if downloads[2].CreatedAt != "Tue Mar 02 00:00:00 +0000 2021" {
+		t.Fatalf("expected the source tweet's created_at, got %q", downloads[2].CreatedAt)
+	}
+}
+
+func TestGetTweetDetailAll_ReplySort(t *testing.T) {
+	// Conversation 100 <- focal 200 <- replies 301..304 (likes, replies).
+	entry := func(id string, likes, replies int) string {
+		return fmt.Sprintf(`{"entryId":"tweet-%s","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"%s",`+
+			`"legacy":{"full_text":"t","conversation_id_str":"100","favorite_count":%d,"reply_count":%d}}}}}}`, id, id, likes, replies)
+	}
+	pages := map[string]string{
+		"": `{"data":{"threaded_conversation_with_injections_v2":{"instructions":[{"type":"TimelineAddEntries","entries":[` +
+			entry("100", 50, 9) + `,` + entry("200", 40, 4) + `,` + entry("302", 1, 8) + `,` + entry("301", 30, 2) +
+			`,{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"c1"}}]}]}}}`,
+		"c1": `{"data":{"threaded_conversation_with_injections_v2":{"instructions":[{"type":"TimelineAddEntries","entries":[` +
+			entry("304", 10, 0) + `,` + entry("303", 5, 5) + `,` + entry("301", 30, 2) + `]}]}}}`,
+	}
+	var ranking []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api/base/apitools/tweetTimeline" || q.Get("tweetId") != "200" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		ranking = append(ranking, q.Get("rankingMode"))
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[q.Get("cursor")] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	cases := []struct {
+		sort    ReplySort
+		ranking string
+		want    string
+	}{
+		{SortDefault, "", "302,301,304,303"},
+		{SortRecent, "Recency", "304,303,302,301"},
+		{SortTop, "Likes", "301,304,303,302"},
+		{SortControversial, "", "302,303,301,304"},
+	}
+	for _, tc := range cases {
+		ranking = nil
+		detail, err := client.GetTweetDetailAll(context.Background(), "200", 0, tc.sort)
+		if err != nil {
+			t.Fatalf("sort %d: GetTweetDetailAll error: %v", tc.sort, err)
+		}
+		if detail.Tweet.ID != "200" || detail.NextCursor != "" {
+			t.Fatalf("sort %d: unexpected focal tweet %q / cursor %q", tc.sort, detail.Tweet.ID, detail.NextCursor)
+		}
+		if got := tweetIDs(detail.Replies); got != tc.want {
+			t.Fatalf("sort %d: expected replies %s, got %s", tc.sort, tc.want, got)
+		}
+		if len(ranking) != 2 || ranking[0] != tc.ranking {
+			t.Fatalf("sort %d: expected rankingMode %q on each page, got %q", tc.sort, tc.ranking, ranking)
+		}
+	}
+
+	detail, err := client.GetTweetDetailAll(context.Background(), "200", 1, SortRecent)
+	if err != nil || tweetIDs(detail.Replies) != "302,301" || detail.NextCursor != "c1" {
+		t.Fatalf("expected the first page only with its next cursor, got %+v, %v", detail, err)
+	}
+}
+
+func TestGetTweetDetailAll_Tombstoned(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":1,"data":` + string(tweetDetailFixture(
+			`{"__typename":"TweetTombstone","tombstone":{"text":{"text":"This Post was deleted by the Post author."}}}`)) + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	detail, err := newTestClient(t, ts.URL).GetTweetDetailAll(context.Background(), "100", 1, SortDefault)
+	if !errors.Is(err, ErrTweetDeleted) {
+		t.Fatalf("expected ErrTweetDeleted, got %v", err)
+	}
+	if detail == nil || !detail.Tombstoned || len(detail.Replies) != 1 || detail.Replies[0].ID != "200" {
+		t.Fatalf("expected the replies of the deleted tweet, got %+v", detail)
+	}
+}
+
+func TestGetRetweetersAll(t *testing.T) {
+	pages := map[string]string{
+		"":   userPageFixture("c1", "1", "2"),
+		"c1": userPageFixture("c2", "2", "3"),
+		"c2": userPageFixture("c3", "3", "4"),
+	}
+	var hits atomic.Int32
+	var failAt string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		q := r.URL.Query()
+		if r.URL.Path != "/api/base/apitools/retweetersV2" || q.Get("tweetId") != "456" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		if failAt != "" && q.Get("cursor") == failAt {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"msg":"bad cursor"}`))
+			return
+		}
+		body, ok := pages[q.Get("cursor")]
+		if !ok {
+			t.Fatalf("unexpected cursor %q", q.Get("cursor"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	users, err := client.GetRetweetersAll(context.Background(), "456", 2)
+	if err != nil {
+		t.Fatalf("GetRetweetersAll error: %v", err)
+	}
+	if got := userIDs(users); got != "1,2,3" {
+		t.Fatalf("expected deduped retweeters 1,2,3 within 2 pages, got %s", got)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("expected 2 requests, got %d", got)
+	}
+
+	failAt = "c2"
+	users, err = client.GetRetweetersAll(context.Background(), "456", 0)
+	if err == nil {
+		t.Fatal("expected error from the third page")
+	}
+	if got := userIDs(users); got != "1,2,3" {
+		t.Fatalf("expected partial retweeters 1,2,3, got %s", got)
+	}
+}
+
+func TestGetTweetsByIDsParsed_ReportsMissing(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/tweetResultsByRestIds" || r.URL.Query().Get("tweetIds") != "10,20,30" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":{"data":{"tweetResult":[
+			{"result":{"__typename":"Tweet","rest_id":"30","legacy":{"full_text":"third"}}},
+			{"result":{"__typename":"TweetTombstone","tombstone":{"text":{"text":"This Post was deleted by the Post author."}}}},
+			{"result":{"__typename":"Tweet","rest_id":"10","legacy":{"full_text":"first"}}}
+		]}},"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	found, missing, err := client.GetTweetsByIDsParsed(context.Background(), []string{"10", "20", "30", "10"})
+	if err != nil {
+		t.Fatalf("GetTweetsByIDsParsed error: %v", err)
+	}
+	if len(found) != 2 || found[0].ID != "10" || found[1].ID != "30" || found[0].GetText() != "first" {
+		t.Fatalf("expected tweets 10,30 in request order, got %+v", found)
+	}
+	if len(missing) != 1 || missing[0] != "20" {
+		t.Fatalf("expected tombstoned tweet 20 to be missing, got %v", missing)
+	}
+}
+
+func TestGetTweetsByIDs_MaxBatch(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if n := len(strings.Split(r.URL.Query().Get("tweetIds"), ",")); n != 100 {
+			t.Errorf("expected 100 IDs on the wire, got %d", n)
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":{"data":{"tweetResult":[]}},"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	ids := make([]string, 101)
+	for i := range ids {
+		ids[i] = strconv.Itoa(1000 + i)
+	}
+	if _, err := client.GetTweetsByIDs(context.Background(), ids[:100]); err != nil {
+		t.Fatalf("100 IDs: unexpected error: %v", err)
+	}
+	_, err := client.GetTweetsByIDs(context.Background(), ids)
+	if !errors.Is(err, ErrTooManyIDs) || !strings.Contains(err.Error(), "101 tweet IDs, at most 100") || !strings.Contains(err.Error(), "slices.Chunk") {
+		t.Fatalf("101 IDs: expected a clear ErrTooManyIDs, got %v", err)
+	}
+	if _, _, err := client.GetTweetsByIDsParsed(context.Background(), ids); !errors.Is(err, ErrTooManyIDs) {
+		t.Fatalf("GetTweetsByIDsParsed: expected ErrTooManyIDs, got %v", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("expected only the valid call to reach the server, got %d requests", n)
+	}
+}
+
+func TestGetUserTweetsExpanded(t *testing.T) {
+	user := `"core":{"user_results":{"result":{"rest_id":"7","legacy":{"screen_name":"orig"}}}}`
+	timeline := `{"data":{"user":{"result":{"timeline_v2":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[
+		{"entryId":"tweet-1","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"1","legacy":{
+			"full_text":"RT @orig: a long thought that…",
+			"retweeted_status_result":{"result":{"__typename":"Tweet","rest_id":"100",` + user + `,"legacy":{"full_text":"a long thought that…"}}}}}}}}},
+		{"entryId":"tweet-2","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"2","legacy":{
+			"full_text":"RT @orig: no inline original","retweeted_status_id_str":"200"}}}}}},
+		{"entryId":"tweet-3","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"3","legacy":{
+			"full_text":"RT @orig: complete",
+			"retweeted_status_result":{"result":{"__typename":"Tweet","rest_id":"300",` + user + `,"legacy":{"full_text":"complete"}}}}}}}}},
+		{"entryId":"tweet-4","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"4","legacy":{"full_text":"own tweet"}}}}}}
+	]}]}}}}}}`
+	var (
+		mu      sync.Mutex
+		lookups []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/base/apitools/userTweetsV2":
+			_, _ = w.Write([]byte(`{"code":1,"data":` + timeline + `,"msg":"SUCCESS"}`))
+		case "/api/base/apitools/tweetResultsByRestIds":
+			mu.Lock()
+			lookups = append(lookups, r.URL.Query().Get("tweetIds"))
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"code":1,"data":{"data":{"tweetResult":[
+				{"result":{"__typename":"Tweet","rest_id":"100",` + user + `,"legacy":{"full_text":"a long thought that finally ends","favorite_count":9,
+					"extended_entities":{"media":[{"id_str":"m1","type":"photo","media_url_https":"https://pbs.twimg.com/media/m1.jpg"}]}}}},
+				{"result":{"__typename":"Tweet","rest_id":"200",` + user + `,"legacy":{"full_text":"fetched original","retweet_count":3}}}
+			]}},"msg":"SUCCESS"}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	tweets, err := client.GetUserTweetsExpanded(context.Background(), "7", "", 0)
+	if err != nil {
+		t.Fatalf("GetUserTweetsExpanded error: %v", err)
+	}
+	if got := tweetIDs(tweets); got != "1,2,3,4" {
+		t.Fatalf("expected tweets 1,2,3,4, got %s", got)
+	}
+	if rt := tweets[0].RetweetedStatus; rt == nil || rt.GetText() != "a long thought that finally ends" || rt.FavoriteCount != 9 || len(tweetMedia(rt)) != 1 {
+		t.Fatalf("expected truncated original 100 to be expanded, got %+v", rt)
+	}
+	if rt := tweets[1].RetweetedStatus; rt == nil || rt.ID != "200" || rt.RetweetCount != 3 || rt.User == nil {
+		t.Fatalf("expected missing original 200 to be fetched, got %+v", rt)
+	}
+	if rt := tweets[2].RetweetedStatus; rt == nil || rt.GetText() != "complete" {
+		t.Fatalf("expected complete original 300 to be kept, got %+v", rt)
+	}
+	if tweets[3].RetweetedStatus != nil {
+		t.Fatalf("expected own tweet to stay a non-retweet, got %+v", tweets[3].RetweetedStatus)
+	}
+	if got := strings.Join(lookups, ";"); got != "100,200" {
+		t.Fatalf("expected one batched lookup of the incomplete originals 100,200, got %s", got)
+	}
+
+	// maxFetch bounds the lookups to the first incomplete original.
+	lookups = nil
+	tweets, err = client.GetUserTweetsExpanded(context.Background(), "7", "", 1)
+	if err != nil {
+		t.Fatalf("GetUserTweetsExpanded error: %v", err)
+	}
+	if got := strings.Join(lookups, ";"); got != "100" {
+		t.Fatalf("expected maxFetch to bound the lookup to 100, got %s", got)
+	}
+	if tweets[0].RetweetedStatus.FavoriteCount != 9 || tweets[1].RetweetedStatus != nil {
+		t.Fatalf("expected only original 100 to be expanded, got %+v / %+v", tweets[0].RetweetedStatus, tweets[1].RetweetedStatus)
+	}
+}
+
+func TestCountUserTweetsSince(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entry := func(id string, at time.Time) string {
+		return `{"entryId":"tweet-` + id + `","content":{"itemContent":{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"` + id +
+			`","legacy":{"full_text":"t` + id + `","created_at":"` + at.Format(time.RubyDate) + `"}}}}}}`
+	}
+	page := func(cursor, pin string, entries ...string) string {
+		var instructions []string
+		if pin != "" {
+			instructions = append(instructions, `{"type":"TimelinePinEntry","entry":`+pin+`}`)
+		}
+		entries = append(entries, `{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"`+cursor+`"}}`)
+		instructions = append(instructions, `{"type":"TimelineAddEntries","entries":[`+strings.Join(entries, ",")+`]}`)
+		return `{"data":{"user":{"result":{"timeline_v2":{"timeline":{"instructions":[` + strings.Join(instructions, ",") + `]}}}}}}`
+	}
+	pages := map[string]string{
+		// The pinned tweet is a year old but must not stop the scan.
+		"": page("c1", entry("50", since.AddDate(-1, 0, 0)),
+			entry("103", since.Add(3*time.Hour)), entry("102", since.Add(2*time.Hour)), entry("101", since.Add(time.Hour))),
+		"c1": page("c2", "", entry("101", since.Add(time.Hour)), entry("100", since.Add(time.Minute)), entry("99", since), entry("98", since.Add(-time.Hour))),
+		"c2": page("c3", "", entry("97", since.Add(-2*time.Hour))),
+	}
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/userTweetsV2" || r.URL.Query().Get("userId") != "7" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		cursor := r.URL.Query().Get("cursor")
+		requested = append(requested, cursor)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + pages[cursor] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	n, err := client.CountUserTweetsSince(context.Background(), "7", since, 0)
+	if err != nil {
+		t.Fatalf("CountUserTweetsSince error: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("expected 4 tweets after %v (101 repeated, 99 posted exactly at it), got %d", since, n)
+	}
+	if got := strings.Join(requested, ","); got != ",c1" {
+		t.Fatalf("expected to stop at the first older tweet on page c1, requested %q", got)
+	}
+
+	requested = nil
+	if n, err = client.CountUserTweetsSince(context.Background(), "7", since, 1); err != nil || n != 3 {
+		t.Fatalf("expected maxPages=1 to count the 3 tweets of the first page, got %d, %v", n, err)
+	}
+}
+
+func TestGetTweetSimpleParsed(t *testing.T) {
+	// Flat brief shape: numeric id, string counts, "author" instead of "user".
+	payload := `{"id":1790000000000000001,"text":"hello world","created_at":"Mon May 13 12:00:00 +0000 2024",` +
+		`"lang":"en","conversation_id":"1790000000000000001","favorite_count":"1,204","retweet_count":17,` +
+		`"reply_count":"3","quote_count":0,"views":"98,765",` +
+		`"author":{"id_str":"12","screen_name":"jack","name":"jack"}}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/tweetSimple" || r.URL.Query().Get("tweetId") != "1790000000000000001" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + payload + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	tw, err := client.GetTweetSimpleParsed(context.Background(), "1790000000000000001")
+	if err != nil {
+		t.Fatalf("GetTweetSimpleParsed error: %v", err)
+	}
+	if tw.ID != "1790000000000000001" || tw.RestID != tw.ID || tw.GetText() != "hello world" {
+		t.Fatalf("unexpected identity/text: %+v", tw)
+	}
+	if tw.CreatedAt != "Mon May 13 12:00:00 +0000 2024" || tw.Lang != "en" || tw.ConversationIDStr != tw.ID {
+		t.Fatalf("unexpected metadata: %+v", tw)
+	}
+	if tw.FavoriteCount != 1204 || tw.RetweetCount != 17 || tw.ReplyCount != 3 || tw.QuoteCount != 0 || tw.ViewCount != "98765" {
+		t.Fatalf("unexpected metrics: %+v", tw)
+	}
+	if tw.User == nil || tw.User.ScreenName != "jack" || tw.AuthorID() != "12" {
+		t.Fatalf("unexpected author: %+v", tw.User)
+	}
+}
+
+func TestParseTweetSimple_GraphQLAndMissing(t *testing.T) {
+	tw, err := ParseTweetSimple(json.RawMessage(`{"data":{"tweetResult":{"result":` + threadTweetFixture("101", "7", "100", "7") + `}}}`))
+	if err != nil {
+		t.Fatalf("ParseTweetSimple error: %v", err)
+	}
+	if tw.ID != "101" || tw.AuthorID() != "7" {
+		t.Fatalf("unexpected GraphQL tweet: %+v", tw)
+	}
+
+	for _, raw := range []string{
+		`{"data":{"tweetResult":{"result":{"__typename":"TweetTombstone"}}}}`,
+		`{}`,
+	} {
+		if _, err := ParseTweetSimple(json.RawMessage(raw)); !errors.Is(err, ErrTweetNotFound) {
+			t.Fatalf("expected ErrTweetNotFound for %s, got %v", raw, err)
+		}
+	}
+}
+
+func TestEndpointPathFallback(t *testing.T) {
+	cases := []struct {
+		name  string
+		call  func(c *Client) (json.RawMessage, error)
+		paths string
+	}{
+		{
+			name:  "GetUserHighlights",
+			call:  func(c *Client) (json.RawMessage, error) { return c.GetUserHighlights(context.Background(), "123", "") },
+			paths: "/api/base/apitools/highlightsV2,/api/base/apitools/highlights",
+		},
+		{
+			name: "GetUserArticlesTweets",
+			call: func(c *Client) (json.RawMessage, error) {
+				return c.GetUserArticlesTweets(context.Background(), "123", "")
+			},
+			paths: "/api/base/apitools/userArticlesTweets,/api/base/apitools/userArticlesTweetsV2",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var paths []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if len(paths) == 1 {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"code":404,"msg":"No static resource"}`))
+					return
+				}
+				if r.URL.Query().Get("userId") != "123" {
+					t.Fatalf("params not carried over to the alias: %s", r.URL.RawQuery)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code":1,"data":{"ok":true},"msg":"SUCCESS"}`))
+			}))
+			defer ts.Close()
+
+			raw, err := tc.call(newTestClient(t, ts.URL))
+			if err != nil {
+				t.Fatalf("%s error: %v", tc.name, err)
+			}
+			if string(raw) != `{"ok":true}` {
+				t.Fatalf("unexpected payload: %s", raw)
+			}
+			if got := strings.Join(paths, ","); got != tc.paths {
+				t.Fatalf("unexpected path sequence: %s", got)
+			}
+		})
+	}
+}
+
+func TestEndpointPathFallback_FirstRealErrorAndNoDeadPathRetries(t *testing.T) {
+	hits := map[string]int{}
+	status := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		w.WriteHeader(status[r.URL.Path])
+		_, _ = w.Write([]byte(`{"code":131,"msg":"failed"}`))
+	}))
+	defer ts.Close()
+	client, err := NewClient(&config.Config{
+		BaseURL:                ts.URL,
+		APIKey:                 "test-key",
+		Timeout:                5 * time.Second,
+		MaxRetries:             2,
+		RateLimit:              100,
+		RetryableBusinessCodes: []int{131},
+	}, WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	// The preferred path fails with a 500 and the alias is missing: the
+	// 500 is what the caller needs to see.
+	status["/api/base/apitools/highlightsV2"] = http.StatusInternalServerError
+	status["/api/base/apitools/highlights"] = http.StatusNotFound
+	_, err = client.GetUserHighlights(context.Background(), "123", "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected the preferred path's 500, got %v", err)
+	}
+	// The 404 has a retryable code, yet the dead path is not retried.
+	if n := hits["/api/base/apitools/highlights"]; n != 1 {
+		t.Fatalf("expected the missing alias to be tried once, got %d requests", n)
+	}
+}
+
+func TestEndpointPathFallback_BusinessNotFoundStays(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(`{"code":2,"msg":"User not found"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+	client.authToken = "auth-token"
+
+	_, err := client.GetSimilarUsers(context.Background(), "123")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !strings.Contains(apiErr.Message, "User not found") {
+		t.Fatalf("expected the preferred path's business error, got %v", err)
+	}
+	if got := strings.Join(paths, ","); got != "/api/base/apitools/similarUsersV2" {
+		t.Fatalf("expected no alias to be called, got %s", got)
+	}
+}
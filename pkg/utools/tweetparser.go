@@ -0,0 +1,355 @@
+package utools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// TweetParser parses tweets exactly like ParseTweetTimeline, but reads each
+// tweet node in a single pass and decodes its legacy object through a field
+// table compiled once by NewTweetParser, instead of resolving a dozen gjson
+// paths and running the JSON codec per tweet. Build one and reuse it when
+// parsing many pages; it is safe for concurrent use.
+type TweetParser struct {
+	// IncludeAds keeps promoted tweets in timelines, marked Promoted,
+	// instead of dropping them. Set it before the parser is used.
+	IncludeAds bool
+
+	fields map[string]tweetField // by JSON name
+}
+
+// tweetField decodes one TweetResult field from a JSON value.
+type tweetField func(t reflect.Value, v gjson.Result) error
+
+// NewTweetParser compiles the TweetResult field table.
+func NewTweetParser() *TweetParser {
+	p := &TweetParser{fields: make(map[string]tweetField)}
+	typ := reflect.TypeOf(TweetResult{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+		p.fields[name] = compileTweetField(i, name, f.Type)
+	}
+	return p
+}
+
+// compileTweetField returns the decoder of field i, named name in JSON.
+// Scalars are read straight from gjson with encoding/json's type rules (null
+// leaves the field alone, a type mismatch is an error); everything else goes
+// through the JSON codec.
+func compileTweetField(i int, name string, typ reflect.Type) tweetField {
+	switch typ.Kind() {
+	case reflect.String:
+		return func(t reflect.Value, v gjson.Result) error {
+			switch v.Type {
+			case gjson.Null:
+				return nil
+			case gjson.String:
+				t.Field(i).SetString(v.Str)
+				return nil
+			}
+			return fmt.Errorf("utools: tweet field %s: expected string, got %s", name, v.Type)
+		}
+	case reflect.Int, reflect.Int64:
+		return func(t reflect.Value, v gjson.Result) error {
+			switch v.Type {
+			case gjson.Null:
+				return nil
+			case gjson.Number:
+				n, err := strconv.ParseInt(v.Raw, 10, 64)
+				if err != nil {
+					return fmt.Errorf("utools: tweet field %s: %w", name, err)
+				}
+				t.Field(i).SetInt(n)
+				return nil
+			}
+			return fmt.Errorf("utools: tweet field %s: expected number, got %s", name, v.Type)
+		}
+	case reflect.Bool:
+		return func(t reflect.Value, v gjson.Result) error {
+			switch v.Type {
+			case gjson.Null:
+				return nil
+			case gjson.True, gjson.False:
+				t.Field(i).SetBool(v.Bool())
+				return nil
+			}
+			return fmt.Errorf("utools: tweet field %s: expected bool, got %s", name, v.Type)
+		}
+	}
+	return func(t reflect.Value, v gjson.Result) error {
+		return unmarshalJSON([]byte(v.Raw), t.Field(i).Addr().Interface())
+	}
+}
+
+// decode fills t from a legacy / flat tweet object. retweet receives the
+// object's retweeted_status_result, which is not a TweetResult field.
+func (p *TweetParser) decode(obj gjson.Result, t *TweetResult, retweet *gjson.Result) error {
+	if !obj.IsObject() {
+		return fmt.Errorf("utools: tweet: expected object")
+	}
+	tv := reflect.ValueOf(t).Elem()
+	var err error
+	obj.ForEach(func(k, v gjson.Result) bool {
+		if k.Str == "retweeted_status_result" {
+			if !retweet.Exists() {
+				*retweet = v
+			}
+			return true
+		}
+		set, ok := p.fields[k.Str]
+		if !ok {
+			// encoding/json falls back to a case-insensitive match; the
+			// TweetResult names are all lower case.
+			set, ok = p.fields[strings.ToLower(k.Str)]
+		}
+		if ok {
+			err = set(tv, v)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// tweetNodeParts holds the top-level members of a tweet node that
+// parseTweetNode looks up, gathered in one pass (first occurrence wins, as
+// with gjson paths).
+type tweetNodeParts struct {
+	typename, tweet, legacy, restID, core, noteTweet, views, card, quoted gjson.Result
+}
+
+func splitTweetNode(node gjson.Result) tweetNodeParts {
+	var parts tweetNodeParts
+	node.ForEach(func(k, v gjson.Result) bool {
+		var dst *gjson.Result
+		switch k.Str {
+		case "__typename":
+			dst = &parts.typename
+		case "tweet":
+			dst = &parts.tweet
+		case "legacy":
+			dst = &parts.legacy
+		case "rest_id":
+			dst = &parts.restID
+		case "core":
+			dst = &parts.core
+		case "note_tweet":
+			dst = &parts.noteTweet
+		case "views":
+			dst = &parts.views
+		case "card":
+			dst = &parts.card
+		case "quoted_status_result":
+			dst = &parts.quoted
+		default:
+			return true
+		}
+		if !dst.Exists() {
+			*dst = v
+		}
+		return true
+	})
+	return parts
+}
+
+// parseNode mirrors parseTweetNodeDepth.
+func (p *TweetParser) parseNode(node gjson.Result, depth int) (TweetResult, bool) {
+	var t TweetResult
+	if depth > maxParseDepth || !node.IsObject() {
+		return t, false
+	}
+	parts := splitTweetNode(node)
+	if parts.typename.String() == "TweetWithVisibilityResults" {
+		node = parts.tweet
+		if !node.IsObject() {
+			return t, false
+		}
+		parts = splitTweetNode(node)
+	}
+	switch parts.typename.String() {
+	case "TweetUnavailable", "TweetTombstone":
+		return t, false
+	}
+
+	var retweet gjson.Result
+	if parts.legacy.IsObject() {
+		if err := p.decode(parts.legacy, &t, &retweet); err != nil {
+			return t, false
+		}
+		t.RestID = parts.restID.String()
+		if u, ok := parseUserNode(parts.core.Get("user_results.result")); ok {
+			t.User = &u
+		}
+		if v := parts.noteTweet.Get("note_tweet_results.result.text"); v.Exists() {
+			t.FullText = v.String()
+		}
+		if n, ok := parseCount(parts.views.Get("count")); ok {
+			t.ViewCount = strconv.FormatInt(n, 10)
+		}
+		if parts.card.IsObject() {
+			t.Card = json.RawMessage(parts.card.Raw)
+		}
+		if q, ok := p.parseNode(parts.quoted.Get("result"), depth+1); ok {
+			t.QuotedStatus = &q
+		}
+		if rt, ok := p.parseNode(retweet.Get("result"), depth+1); ok {
+			t.RetweetedStatus = &rt
+		}
+	} else if err := p.decode(node, &t, &retweet); err != nil {
+		return t, false
+	}
+
+	if t.RestID == "" {
+		t.RestID = t.ID
+	}
+	if t.ID == "" {
+		t.ID = t.RestID
+	}
+	return t, t.RestID != ""
+}
+
+func (p *TweetParser) parseTopNode(node gjson.Result) (TweetResult, bool) {
+	return p.parseNode(node, 0)
+}
+
+// Parse extracts a single tweet from a single-tweet response (tweetSimple
+// and friends) or a bare tweet node. It returns ErrTweetNotFound when there
+// is no available tweet.
+func (p *TweetParser) Parse(raw json.RawMessage) (*TweetResult, error) {
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("utools: parse tweet: invalid JSON")
+	}
+	node, ok := tweetNode(gjson.ParseBytes(raw))
+	if !ok {
+		return nil, ErrTweetNotFound
+	}
+	t, ok := p.parseNode(node, 0)
+	if !ok {
+		return nil, ErrTweetNotFound
+	}
+	return &t, nil
+}
+
+// ParseTimeline is ParseTweetTimeline using the parser's compiled tables,
+// keeping ads when IncludeAds is set.
+func (p *TweetParser) ParseTimeline(raw json.RawMessage) ([]TweetResult, error) {
+	return parseTweetTimeline(raw, scanTweetResults, p.parseTopNode, p.IncludeAds)
+}
+
+// tweetResultsKey is the key scanTweetResults looks for.
+const tweetResultsKey = `"tweet_results"`
+
+// scanTweetResults finds the same nodes as walkTweetResults, in the same
+// order, by scanning root's text for the "tweet_results" key instead of
+// descending into every object level by level. Matched nodes are skipped
+// as a whole, so tweets nested inside them are not reported. Like the walk,
+// it ignores keys nested below maxParseDepth.
+func scanTweetResults(root gjson.Result, fn func(gjson.Result)) {
+	raw := root.Raw
+	// pos and depth track the nesting of the text before the current match:
+	// the number of objects and arrays open at pos, which is never inside a
+	// string.
+	pos, depth := 0, 0
+	for i := 0; ; {
+		j := strings.Index(raw[i:], tweetResultsKey)
+		if j < 0 {
+			return
+		}
+		start := i + j
+		i = start + len(tweetResultsKey)
+		var inString bool
+		if pos, depth, inString = scanNesting(raw, pos, start, depth); inString {
+			i = max(i, pos) // the text is inside a string value
+			continue
+		}
+		k := skipSpace(raw, i)
+		if k >= len(raw) || raw[k] != ':' {
+			continue // a string value, not a key
+		}
+		k = skipSpace(raw, k+1)
+		end := compositeEnd(raw, k)
+		if end < 0 {
+			continue // a scalar value, nothing to unwrap
+		}
+		// The walk's depth counts the containers above the key's object.
+		if depth-1 <= maxParseDepth {
+			if result := gjson.Get(raw[k:end], "result"); result.IsObject() {
+				result.Index += root.Index + k
+				fn(result)
+			}
+		}
+		// The skipped value is balanced, so depth is unchanged past it.
+		i, pos = end, end
+	}
+}
+
+// scanNesting advances the nesting count depth of s from pos, which is not
+// inside a string, to target. If target lies inside a string, inString is
+// set and the returned position is just past that string.
+func scanNesting(s string, pos, target, depth int) (newPos, newDepth int, inString bool) {
+	for pos < target {
+		switch s[pos] {
+		case '"':
+			for pos++; pos < len(s) && s[pos] != '"'; pos++ {
+				if s[pos] == '\\' {
+					pos++
+				}
+			}
+			pos++
+			if pos > target {
+				return pos, depth, true
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		}
+		pos++
+	}
+	return pos, depth, false
+}
+
+// compositeEnd returns the index just past the object or array starting at
+// s[i], or -1 if s[i] does not start one.
+func compositeEnd(s string, i int) int {
+	if i >= len(s) || (s[i] != '{' && s[i] != '[') {
+		return -1
+	}
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// skipSpace returns the index of the first non-whitespace byte of s at or
+// after i.
+func skipSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	return i
+}
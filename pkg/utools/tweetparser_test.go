@@ -0,0 +1,262 @@
+package utools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// richTweetFixture builds a GraphQL tweet node exercising most TweetResult
+// fields: entities, media, author, note_tweet, views, card and a quote.
+func richTweetFixture(id string) string {
+	return `{"__typename":"Tweet","rest_id":"` + id + `",` +
+		`"core":{"user_results":{"result":{"__typename":"User","rest_id":"12","is_blue_verified":true,"legacy":{"screen_name":"jack","name":"jack","followers_count":100}}}},` +
+		`"views":{"count":"5120","state":"EnabledWithCount"},` +
+		`"card":{"rest_id":"card-1","legacy":{"name":"summary"}},` +
+		`"note_tweet":{"note_tweet_results":{"result":{"text":"the long text of ` + id + `"}}},` +
+		`"quoted_status_result":{"result":{"__typename":"Tweet","rest_id":"9` + id + `","legacy":{"id_str":"9` + id + `","full_text":"quoted","favorite_count":1}}},` +
+		`"legacy":{"id_str":"` + id + `","full_text":"short","created_at":"Wed Oct 10 20:19:24 +0000 2018",` +
+		`"conversation_id_str":"` + id + `","user_id_str":"12","in_reply_to_status_id_str":null,"lang":"en",` +
+		`"source":"<a href=\"https://mobile.twitter.com\">Web</a>","retweet_count":3,"favorite_count":42,` +
+		`"reply_count":7,"quote_count":1,"bookmark_count":2,"is_quote_status":true,"retweeted":false,"favorited":true,` +
+		`"bookmarked":false,"Lang":"en","unknown_field":{"nested":[1,2,3]},` +
+		`"entities":{"hashtags":[{"text":"go","indices":[0,3]}],"urls":[{"url":"https://t.co/x","expanded_url":"https://go.dev","display_url":"go.dev","indices":[4,27]}],"user_mentions":[],"symbols":[]},` +
+		`"extended_entities":{"media":[{"id_str":"m1","media_url_https":"https://pbs.twimg.com/media/a.jpg","type":"photo","url":"https://t.co/m"}]}}}`
+}
+
+func richTimelineFixture(n int) string {
+	entries := make([]string, 0, n+1)
+	for i := 0; i < n; i++ {
+		id := strconv.Itoa(1000 + i)
+		entries = append(entries, `{"entryId":"tweet-`+id+`","content":{"itemContent":{"tweet_results":{"result":`+richTweetFixture(id)+`}}}}`)
+	}
+	entries = append(entries, `{"entryId":"cursor-bottom","content":{"cursorType":"Bottom","value":"next"}}`)
+	return `{"data":{"user":{"result":{"timeline_v2":{"timeline":{"instructions":[{"type":"TimelineAddEntries","entries":[` +
+		strings.Join(entries, ",") + `]}]}}}}}}`
+}
+
+// deepTimelineFixture nests a tweet_results node right at maxParseDepth,
+// which is parsed, and one below it, which is not, in alternating objects
+// and arrays.
+func deepTimelineFixture() string {
+	nest := func(levels int, inner string) string {
+		for i := range levels {
+			if i%2 == 0 {
+				inner = `[` + inner + `]`
+			} else {
+				inner = `{"a":` + inner + `}`
+			}
+		}
+		return inner
+	}
+	node := func(id string) string {
+		return `{"tweet_results":{"result":{"__typename":"Tweet","rest_id":"` + id + `","legacy":{"full_text":"deep"}}}}`
+	}
+	return `{"shallow":` + nest(maxParseDepth-1, node("50")) + `,"deep":` + nest(maxParseDepth, node("51")) + `}`
+}
+
+func TestTweetParserMatchesParseTweetTimeline(t *testing.T) {
+	p := NewTweetParser()
+	fixtures := map[string]string{
+		"graphql":  richTimelineFixture(3),
+		"retweet":  `{"tweet_results":{"result":{"rest_id":"5","legacy":{"id_str":"5","full_text":"RT","retweeted_status_result":{"result":` + richTweetFixture("6") + `}}}}}`,
+		"legacy":   `{"statuses":[{"id_str":"7","full_text":"flat","favorite_count":3,"user":{"id_str":"12","screen_name":"jack"}},{"id_str":"8","text":"flat2"}]}`,
+		"batch":    `{"data":{"tweetResult":[{"result":` + richTweetFixture("20") + `},{"result":{"__typename":"TweetTombstone"}}]}}`,
+		"mismatch": `{"statuses":[{"id_str":"7","favorite_count":"3"},{"id_str":"8","retweeted":"yes"},{"id_str":"9","full_text":12}]}`,
+		// Key text inside strings, a "tweet_results" string value, spacing
+		// around the colon and module items must not confuse the scanner.
+		"tricky": `{"data":{"note":"see \\\"tweet_results\\\": {\\\"result\\\":{}}","labels":["tweet_results"],"instructions":[{"entries":[` +
+			`{"content":{"items":[{"item":{"itemContent":{"tweet_results" :` + "\n" + ` {"result":` + richTweetFixture("40") + `}}}},` +
+			`{"item":{"itemContent":{"tweet_results":{"result":{"rest_id":"41","legacy":{"full_text":"a \"tweet_results\":{\"result\":{\"rest_id\":\"99\"}} b"}}}}}}]}},` +
+			`{"content":{"itemContent":{"tweet_results":{}}}},` +
+			`{"content":{"itemContent":{"tweet_results":{"result":{"__typename":"TweetWithVisibilityResults","tweet":` + richTweetFixture("42") + `}}}}}]}]}}`,
+		"deep": deepTimelineFixture(),
+	}
+	for name, raw := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			want, wantErr := ParseTweetTimeline(json.RawMessage(raw))
+			got, gotErr := p.ParseTimeline(json.RawMessage(raw))
+			if (wantErr == nil) != (gotErr == nil) {
+				t.Fatalf("error mismatch: ParseTweetTimeline %v, TweetParser %v", wantErr, gotErr)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("results differ:\n got %+v\nwant %+v", got, want)
+			}
+			if name == "tricky" && tweetIDs(got) != "40,41,42" {
+				t.Fatalf("expected tweets 40,41,42, got %s", tweetIDs(got))
+			}
+			if name == "deep" && tweetIDs(got) != "50" {
+				t.Fatalf("expected only tweet 50 within maxParseDepth, got %s", tweetIDs(got))
+			}
+		})
+	}
+}
+
+func TestTweetParserParse(t *testing.T) {
+	p := NewTweetParser()
+	tw, err := p.Parse(json.RawMessage(`{"data":{"tweetResult":{"result":` + richTweetFixture("30") + `}}}`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	want, _ := parseTweetNode(gjson.Parse(richTweetFixture("30")))
+	if !reflect.DeepEqual(*tw, want) {
+		t.Fatalf("Parse differs from parseTweetNode:\n got %+v\nwant %+v", *tw, want)
+	}
+	if tw.FullText != "the long text of 30" || tw.FavoriteCount != 42 || tw.QuotedStatus == nil || tw.User.ScreenName != "jack" {
+		t.Fatalf("unexpected tweet: %+v", tw)
+	}
+
+	if _, err := p.Parse(json.RawMessage(`{"data":{"tweetResult":{"result":{"__typename":"TweetTombstone"}}}}`)); !errors.Is(err, ErrTweetNotFound) {
+		t.Fatalf("expected ErrTweetNotFound, got %v", err)
+	}
+}
+
+func BenchmarkParseTweetTimeline(b *testing.B) {
+	raw := json.RawMessage(richTimelineFixture(50))
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseTweetTimeline(raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTweetParser(b *testing.B) {
+	raw := json.RawMessage(richTimelineFixture(50))
+	p := NewTweetParser()
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ParseTimeline(raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStreamTweetTimelineMatchesParseTweetTimeline(t *testing.T) {
+	p := NewTweetParser()
+	for name, raw := range map[string]string{
+		"graphql": richTimelineFixture(3),
+		"retweet": `{"tweet_results":{"result":{"rest_id":"5","legacy":{"id_str":"5","full_text":"RT","retweeted_status_result":{"result":` + richTweetFixture("6") + `}}}}}`,
+		"legacy":  `{"statuses":[{"id_str":"7","full_text":"flat","favorite_count":3,"user":{"id_str":"12","screen_name":"jack"}},{"id_str":"8","text":"flat2"}]}`,
+		"batch":   `{"data":{"tweetResult":[{"result":` + richTweetFixture("20") + `},{"result":{"__typename":"TweetTombstone"}}]}}`,
+		"bare":    `[{"id_str":"7","full_text":"flat"},{"result":{"rest_id":"8","legacy":{"full_text":"wrapped"}}}]`,
+		"nested":  `{"data":{"statuses":[{"id_str":"7"}],"list":{"tweets":[{"id_str":"8"}]}}}`,
+		"tricky": `{"data":{"note":"see \"tweet_results\": {}","labels":["tweet_results"],"instructions":[{"entries":[` +
+			`{"content":{"itemContent":{"tweet_results" :` + "\n" + ` {"result":` + richTweetFixture("40") + `}}}},` +
+			`{"content":{"itemContent":{"tweet_results":{}}}},` +
+			`{"content":{"itemContent":{"tweet_results":{"result":{"__typename":"TweetWithVisibilityResults","tweet":` + richTweetFixture("42") + `}}}}}]}]}}`,
+		"deep": deepTimelineFixture(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			want, err := ParseTweetTimeline(json.RawMessage(raw))
+			if err != nil {
+				t.Fatalf("ParseTweetTimeline error: %v", err)
+			}
+			for _, stream := range []func(io.Reader, func(TweetResult) error) error{StreamTweetTimeline, p.StreamTimeline} {
+				got := []TweetResult{}
+				if err := stream(strings.NewReader(raw), func(tw TweetResult) error {
+					got = append(got, tw)
+					return nil
+				}); err != nil {
+					t.Fatalf("stream error: %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("streamed tweets differ:\n got %+v\nwant %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamTweetTimelineErrors(t *testing.T) {
+	var n int
+	err := StreamTweetTimeline(strings.NewReader(richTimelineFixture(3)), func(TweetResult) error {
+		if n++; n == 2 {
+			return errors.ErrUnsupported
+		}
+		return nil
+	})
+	if err != errors.ErrUnsupported || n != 2 {
+		t.Fatalf("expected fn's error after 2 tweets, got %v after %d", err, n)
+	}
+
+	for _, raw := range []string{richTimelineFixture(2)[:2000], richTimelineFixture(1) + "}", `{"tweets":[1,]}`} {
+		n = 0
+		err := StreamTweetTimeline(strings.NewReader(raw), func(TweetResult) error { n++; return nil })
+		if err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+			t.Fatalf("expected an invalid JSON error for %.40q..., got %v", raw, err)
+		}
+	}
+}
+
+func BenchmarkStreamTweetTimeline(b *testing.B) {
+	raw := []byte(richTimelineFixture(50))
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := StreamTweetTimeline(bytes.NewReader(raw), func(TweetResult) error { return nil }); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTimelineAds(t *testing.T) {
+	raw := `{"data":{"home":{"home_timeline_urt":{"instructions":[{"type":"TimelineAddEntries","entries":[` +
+		`{"entryId":"tweet-1","content":{"itemContent":{"tweet_results":{"result":{"rest_id":"1","legacy":{"full_text":"organic"}}}}}},` +
+		`{"entryId":"promoted-tweet-2-abc","content":{"itemContent":{"itemType":"TimelineTweet","tweet_results":{"result":` + richTweetFixture("2") + `},` +
+		`"promotedMetadata":{"advertiser_results":{"result":{"rest_id":"77"}},"impressionId":"abc"}}}},` +
+		`{"entryId":"tweet-3","content":{"itemContent":{"tweet_results":{"result":{"rest_id":"3","legacy":{"full_text":"organic"}}}}}}]}]}}}}`
+	collect := func(stream func(io.Reader, func(TweetResult) error) error) []TweetResult {
+		got := []TweetResult{}
+		if err := stream(strings.NewReader(raw), func(tw TweetResult) error {
+			got = append(got, tw)
+			return nil
+		}); err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		return got
+	}
+
+	p := NewTweetParser()
+	byDefault, err := ParseTweetTimeline(json.RawMessage(raw))
+	if err != nil {
+		t.Fatalf("ParseTweetTimeline error: %v", err)
+	}
+	compiled, _ := p.ParseTimeline(json.RawMessage(raw))
+	for name, got := range map[string][]TweetResult{
+		"ParseTweetTimeline": byDefault, "TweetParser": compiled,
+		"StreamTweetTimeline": collect(StreamTweetTimeline), "StreamTimeline": collect(p.StreamTimeline),
+	} {
+		if tweetIDs(got) != "1,3" || got[0].Promoted || got[1].Promoted {
+			t.Fatalf("%s: expected the ad to be dropped by default, got %+v", name, got)
+		}
+	}
+
+	p.IncludeAds = true
+	withAds, err := p.ParseTimeline(json.RawMessage(raw))
+	if err != nil {
+		t.Fatalf("ParseTimeline error: %v", err)
+	}
+	streamed := collect(p.StreamTimeline)
+	if !reflect.DeepEqual(withAds, streamed) {
+		t.Fatalf("streamed tweets differ:\n got %+v\nwant %+v", streamed, withAds)
+	}
+	if tweetIDs(withAds) != "1,2,3" || withAds[0].Promoted || !withAds[1].Promoted || withAds[2].Promoted {
+		t.Fatalf("expected the ad kept and marked, got %+v", withAds)
+	}
+	if ad := withAds[1]; ad.FavoriteCount != 42 || ad.QuotedStatus == nil || ad.QuotedStatus.Promoted {
+		t.Fatalf("expected the ad parsed like any tweet, got %+v", ad)
+	}
+}
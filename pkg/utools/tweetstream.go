@@ -0,0 +1,200 @@
+package utools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tidwall/gjson"
+)
+
+// StreamTweetTimeline parses a timeline response like ParseTweetTimeline,
+// but reads it from r token by token and calls fn with each tweet as soon as
+// its node has been decoded, so neither the whole response nor the full
+// result slice has to be held in memory: only one tweet node is buffered at
+// a time. This suits multi-megabyte pages and batch lookups, read from a
+// file or a page's RawData, and pipelines that write tweets out one by one.
+//
+// The tweets and their order are those of ParseTweetTimeline, with two
+// differences: a response mixing GraphQL tweet_results nodes with a legacy
+// list yields both, where ParseTweetTimeline only reads the list when there
+// are no nodes, and invalid JSON is reported when it is reached, after the
+// tweets before it were passed to fn. An error returned by fn stops the
+// stream and is returned as is.
+func StreamTweetTimeline(r io.Reader, fn func(TweetResult) error) error {
+	return streamTweetTimeline(r, parseTweetNode, false, fn)
+}
+
+// StreamTimeline is StreamTweetTimeline using the parser's compiled tables,
+// keeping ads when IncludeAds is set.
+func (p *TweetParser) StreamTimeline(r io.Reader, fn func(TweetResult) error) error {
+	return streamTweetTimeline(r, p.parseTopNode, p.IncludeAds, fn)
+}
+
+// errStreamJSON reports a response that is not valid JSON.
+var errStreamJSON = errors.New("utools: stream tweet timeline: invalid JSON")
+
+// tweetStream walks a timeline response with a json.Decoder.
+type tweetStream struct {
+	dec        *json.Decoder
+	parse      func(gjson.Result) (TweetResult, bool)
+	includeAds bool
+	fn         func(TweetResult) error
+}
+
+// streamTweetLists are the paths of the legacy and batch tweet lists read
+// by ParseTweetTimeline.
+var streamTweetLists = map[string]bool{
+	"data.tweetResult":  true,
+	"data.tweetResults": true,
+	"tweetResult":       true,
+	"tweets":            true,
+	"statuses":          true,
+}
+
+// callbackError marks an error returned by the caller's fn, which is passed
+// through unwrapped.
+type callbackError struct{ err error }
+
+func (e callbackError) Error() string { return e.err.Error() }
+
+func streamTweetTimeline(r io.Reader, parse func(gjson.Result) (TweetResult, bool), includeAds bool, fn func(TweetResult) error) error {
+	s := &tweetStream{dec: json.NewDecoder(r), parse: parse, includeAds: includeAds, fn: fn}
+	err := s.value("", 0)
+	if err == nil {
+		// Like json.Valid, reject anything after the top-level value.
+		if _, err = s.dec.Token(); err == io.EOF {
+			return nil
+		}
+		err = errStreamJSON
+	}
+	var cbErr callbackError
+	if errors.As(err, &cbErr) {
+		return cbErr.err
+	}
+	if errors.Is(err, errStreamJSON) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", errStreamJSON, err)
+}
+
+// value streams the next value, found at path (its dotted key path from
+// the root for members of the root and of its data member, "" elsewhere)
+// and nesting depth, 0 for the root. Like walkResultNodes, it does not
+// look below maxParseDepth.
+func (s *tweetStream) value(path string, depth int) error {
+	if depth > maxParseDepth {
+		var skip json.RawMessage
+		return s.dec.Decode(&skip)
+	}
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		return s.object(path, depth)
+	case json.Delim('['):
+		if depth == 0 || streamTweetLists[path] {
+			return s.list()
+		}
+		for s.dec.More() {
+			if err := s.value("", depth+1); err != nil {
+				return err
+			}
+		}
+		return s.end()
+	}
+	return nil
+}
+
+// object streams the members of the object whose '{' was just read. The
+// value of a tweet_results key is decoded whole and not searched further,
+// so tweets nested in it (quotes, retweets) are not reported twice. Its
+// tweet is emitted once the object ends, as the promotedMetadata marking an
+// ad usually follows it.
+func (s *tweetStream) object(path string, depth int) error {
+	var node gjson.Result
+	var promoted bool
+	for s.dec.More() {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if key != "tweet_results" || node.Exists() {
+			promoted = promoted || key == promotedMetadataKey
+			if err := s.value(childPath(path, key, depth), depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		var raw json.RawMessage
+		if err := s.dec.Decode(&raw); err != nil {
+			return err
+		}
+		if result := gjson.GetBytes(raw, "result"); result.IsObject() {
+			node = result
+		}
+	}
+	if err := s.end(); err != nil {
+		return err
+	}
+	if !node.Exists() || (promoted && !s.includeAds) {
+		return nil
+	}
+	return s.emit(node, promoted)
+}
+
+// list streams the tweets of a legacy or batch list whose '[' was just
+// read: bare tweets or {"result": {...}} wrappers.
+func (s *tweetStream) list() error {
+	for s.dec.More() {
+		var raw json.RawMessage
+		if err := s.dec.Decode(&raw); err != nil {
+			return err
+		}
+		item := gjson.ParseBytes(raw)
+		if r := item.Get("result"); r.IsObject() {
+			item = r
+		}
+		if err := s.emit(item, false); err != nil {
+			return err
+		}
+	}
+	return s.end()
+}
+
+// end consumes the closing delimiter of the current object or array.
+func (s *tweetStream) end() error {
+	_, err := s.dec.Token()
+	return err
+}
+
+// emit parses node and passes the tweet, if any, to fn, marked Promoted
+// when promoted is set.
+func (s *tweetStream) emit(node gjson.Result, promoted bool) error {
+	t, ok := s.parse(node)
+	if !ok {
+		return nil
+	}
+	t.Promoted = promoted
+	if err := s.fn(t); err != nil {
+		return callbackError{err}
+	}
+	return nil
+}
+
+// childPath returns the path of member key of the object at path and
+// depth. Only the root and its data member are tracked, as no tweet list
+// lives deeper.
+func childPath(path, key string, depth int) string {
+	switch {
+	case depth == 0:
+		return key
+	case depth == 1 && path == "data":
+		return "data." + key
+	}
+	return ""
+}
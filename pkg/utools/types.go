@@ -1,6 +1,14 @@
 package utools
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
 
 // ============================================================
 // Common / Wrapper types
@@ -10,6 +18,15 @@ import "encoding/json"
 // caller wants to do custom parsing.
 type RawResponse = json.RawMessage
 
+// Envelope is the uTools response envelope, {"code":1,"msg":"SUCCESS",
+// "data":...}, as returned by GetEnvelope. Data is the payload unwrapped
+// from its string encoding.
+type Envelope struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
 // ============================================================
 // User types
 //
@@ -46,6 +63,266 @@ type UserResult struct {
 	CanDM               bool     `json:"can_dm"`
 	DefaultProfile      bool     `json:"default_profile"`
 	DefaultProfileImage bool     `json:"default_profile_image"`
+
+	// Entities expands the t.co links in URL and Description; nil when the
+	// payload has none. See ExpandedURL and ExpandedDescription.
+	Entities *UserEntities `json:"entities"`
+
+	// Professional and AffiliateLabel come from the GraphQL (V2) payloads
+	// only: the creator / business profile, nil for personal accounts, and
+	// the highlighted affiliation badge, e.g. an organisation's name.
+	Professional   *UserProfessional `json:"professional,omitempty"`
+	AffiliateLabel string            `json:"affiliate_label,omitempty"`
+
+	// FollowedBy, Following and Blocking describe the account's relationship
+	// to the authenticated user: whether it follows you, you follow it and
+	// you block it. They are only reported for requests sent with an
+	// auth_token and stay false otherwise.
+	FollowedBy bool `json:"followed_by,omitempty"`
+	Following  bool `json:"following,omitempty"`
+	Blocking   bool `json:"blocking,omitempty"`
+
+	// TimeZone, UTCOffset (in seconds) and Lang are only sent by the legacy
+	// (V1) profile payloads, and mostly by older accounts; they are empty
+	// otherwise.
+	TimeZone  string `json:"time_zone,omitempty"`
+	UTCOffset int    `json:"utc_offset,omitempty"`
+	Lang      string `json:"lang,omitempty"`
+
+	// Status is set by the typed parsers; see AccountStatus.
+	Status AccountStatus `json:"-"`
+}
+
+// UserProfessional is the professional profile of a creator or business
+// account.
+type UserProfessional struct {
+	Type       string                 `json:"professional_type"` // e.g. "Creator", "Business"
+	Categories []ProfessionalCategory `json:"category"`
+}
+
+// ProfessionalCategory is a category picked for a professional profile.
+type ProfessionalCategory struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// AccountStatus classifies the availability of an account. The typed parsers
+// return a UserResult only for Active and Protected accounts; the others are
+// reported as ErrUserSuspended, ErrUserDeactivated or ErrUserNotFound, and
+// AccountStatusOf maps those errors back to a status.
+type AccountStatus int
+
+const (
+	AccountActive AccountStatus = iota
+	AccountProtected
+	AccountSuspended
+	AccountDeactivated
+	AccountNotFound
+)
+
+func (s AccountStatus) String() string {
+	switch s {
+	case AccountActive:
+		return "active"
+	case AccountProtected:
+		return "protected"
+	case AccountSuspended:
+		return "suspended"
+	case AccountDeactivated:
+		return "deactivated"
+	case AccountNotFound:
+		return "not_found"
+	}
+	return "unknown"
+}
+
+// AccountStatusOf returns the status carried by a profile lookup error, and
+// false for errors that say nothing about the account (network, auth, ...).
+func AccountStatusOf(err error) (AccountStatus, bool) {
+	switch {
+	case errors.Is(err, ErrUserSuspended):
+		return AccountSuspended, true
+	case errors.Is(err, ErrUserDeactivated):
+		return AccountDeactivated, true
+	case errors.Is(err, ErrUserNotFound):
+		return AccountNotFound, true
+	}
+	return AccountActive, false
+}
+
+// profileImageSizeSuffix matches the size variant X appends to profile image
+// file names, e.g. "_normal", "_bigger", "_mini", "_400x400" or "_x96".
+var profileImageSizeSuffix = regexp.MustCompile(`_(normal|bigger|mini|reasonably_small|\d+x\d+|x\d+)$`)
+
+// profileBannerSizeSuffix matches the size path segment of banner URLs,
+// e.g. "/1500x500", "/600x200", "/web" or "/mobile_retina".
+var profileBannerSizeSuffix = regexp.MustCompile(`/(\d+x\d+|web|web_retina|ipad|ipad_retina|mobile|mobile_retina)$`)
+
+// ProfileImageOriginal returns the full-resolution profile image URL by
+// stripping the size suffix from ProfileImageURL. URLs without a known
+// suffix are returned unchanged.
+func (u *UserResult) ProfileImageOriginal() string {
+	raw := u.ProfileImageURL
+	if raw == "" {
+		return ""
+	}
+
+	// Only touch the last path segment; keep any query string as is.
+	query := ""
+	if i := strings.IndexByte(raw, '?'); i >= 0 {
+		raw, query = raw[:i], raw[i:]
+	}
+	dir, file := "", raw
+	if i := strings.LastIndexByte(raw, '/'); i >= 0 {
+		dir, file = raw[:i+1], raw[i+1:]
+	}
+	name, ext := file, ""
+	if i := strings.LastIndexByte(file, '.'); i > 0 {
+		name, ext = file[:i], file[i:]
+	}
+	return dir + profileImageSizeSuffix.ReplaceAllString(name, "") + ext + query
+}
+
+// ProfileBannerOriginal returns the full-resolution banner URL by stripping
+// the size segment from ProfileBannerURL. URLs without a known size segment
+// are returned unchanged.
+func (u *UserResult) ProfileBannerOriginal() string {
+	raw := u.ProfileBannerURL
+	query := ""
+	if i := strings.IndexByte(raw, '?'); i >= 0 {
+		raw, query = raw[:i], raw[i:]
+	}
+	return profileBannerSizeSuffix.ReplaceAllString(raw, "") + query
+}
+
+// UserEntities holds the link expansions of a profile's url and description.
+type UserEntities struct {
+	URL         UserURLEntities `json:"url"`
+	Description UserURLEntities `json:"description"`
+}
+
+// UserURLEntities lists the t.co links found in one profile field.
+type UserURLEntities struct {
+	URLs []URLEntity `json:"urls"`
+}
+
+// ExpandedURL returns the real destination of the profile link, falling back
+// to the t.co URL when the payload carries no expansion for it.
+func (u *UserResult) ExpandedURL() string {
+	if u.Entities == nil {
+		return u.URL
+	}
+	for _, e := range u.Entities.URL.URLs {
+		if e.ExpandedURL != "" && (e.URL == u.URL || u.URL == "") {
+			return e.ExpandedURL
+		}
+	}
+	return u.URL
+}
+
+// ExpandedDescription returns the bio with its t.co links replaced by their
+// expanded destinations. Links without an expansion are left as they are.
+func (u *UserResult) ExpandedDescription() string {
+	if u.Entities == nil {
+		return u.Description
+	}
+	urls := slices.Clone(u.Entities.Description.URLs)
+	// Longest first, so a link is never replaced inside a longer one.
+	slices.SortFunc(urls, func(a, b URLEntity) int { return len(b.URL) - len(a.URL) })
+	var pairs []string
+	for _, e := range urls {
+		if e.URL != "" && e.ExpandedURL != "" {
+			pairs = append(pairs, e.URL, e.ExpandedURL)
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(u.Description)
+}
+
+// ProfessionalType returns the professional account type, e.g. "Creator"
+// or "Business", or "" for personal accounts.
+func (u *UserResult) ProfessionalType() string {
+	if u.Professional == nil {
+		return ""
+	}
+	return u.Professional.Type
+}
+
+// Category returns the name of the first category of a professional
+// account, or "" for personal accounts and professionals without one.
+func (u *UserResult) Category() string {
+	if u.Professional == nil {
+		return ""
+	}
+	for _, c := range u.Professional.Categories {
+		if c.Name != "" {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// IsBusinessAccount reports whether the account is a professional account
+// of the Business type, as opposed to a creator or personal account.
+func (u *UserResult) IsBusinessAccount() bool {
+	return strings.EqualFold(u.ProfessionalType(), "Business")
+}
+
+// createdAtLayouts are the formats seen in created_at fields.
+var createdAtLayouts = []string{
+	time.RubyDate, // Twitter's "Mon Jan 02 15:04:05 -0700 2006"
+	time.RFC3339,
+}
+
+// CreatedAtTime parses CreatedAt, in Twitter's format or RFC 3339.
+func (u *UserResult) CreatedAtTime() (time.Time, error) {
+	s := strings.TrimSpace(u.CreatedAt)
+	if s == "" {
+		return time.Time{}, errors.New("utools: created_at is empty")
+	}
+	for _, layout := range createdAtLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("utools: unrecognised created_at %q", u.CreatedAt)
+}
+
+// AccountAge returns the time elapsed since the account was created. It
+// fails when CreatedAt is empty or unparseable.
+func (u *UserResult) AccountAge() (time.Duration, error) {
+	created, err := u.CreatedAtTime()
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(created), nil
+}
+
+// TweetsPerDay returns StatusesCount averaged over the account's age, which
+// counts as at least one day so brand-new accounts are not inflated. It is
+// 0 when the age is unknown.
+func (u *UserResult) TweetsPerDay() float64 {
+	age, err := u.AccountAge()
+	if err != nil {
+		return 0
+	}
+	days := max(age.Hours()/24, 1)
+	return float64(u.StatusesCount) / days
+}
+
+// ExpandedProfile bundles a user with the related data the V2 profile
+// endpoint can return inline. See GetUserProfileExpanded.
+type ExpandedProfile struct {
+	User         UserResult
+	PinnedTweet  *TweetResult  // nil when not requested or not returned
+	RecentTweets []TweetResult // empty when not requested or not returned
+}
+
+// UserSample bundles a profile with a small sample of the account's
+// followers and followings. See GetUserWithSample.
+type UserSample struct {
+	User       *UserResult
+	Followers  []UserResult // at most one page
+	Followings []UserResult // at most one page
 }
 
 // UserListResult represents a paginated list of users.
@@ -80,6 +357,46 @@ type RelationshipUser struct {
 	NotificationsEnabled bool   `json:"notifications_enabled"`
 }
 
+// ListResult represents a Twitter list.
+type ListResult struct {
+	ID              string      `json:"id_str"`
+	Name            string      `json:"name"`
+	Slug            string      `json:"slug,omitempty"`
+	Description     string      `json:"description"`
+	Mode            string      `json:"mode"` // "public" or "private"
+	MemberCount     int64       `json:"member_count"`
+	SubscriberCount int64       `json:"subscriber_count"`
+	CreatedAt       string      `json:"created_at"`
+	Owner           *UserResult `json:"user,omitempty"`
+
+	// Owned is set by GetListByUserParsed when the requested user owns the
+	// list; false means the user is subscribed to it (or the owner is
+	// unknown).
+	Owned bool `json:"owned"`
+}
+
+// CommunityResult represents an X community. Optional sections missing from
+// the payload (rules, topic, admin) are left empty.
+type CommunityResult struct {
+	ID             string          `json:"rest_id"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description"`
+	MemberCount    int64           `json:"member_count"`
+	ModeratorCount int64           `json:"moderator_count"`
+	CreatedAt      string          `json:"created_at"`  // as sent, usually Unix milliseconds
+	JoinPolicy     string          `json:"join_policy"` // "open" or "restricted"
+	PrimaryTopic   string          `json:"primary_topic,omitempty"`
+	Rules          []CommunityRule `json:"rules"`
+	Admin          *UserResult     `json:"admin,omitempty"`
+	Creator        *UserResult     `json:"creator,omitempty"`
+}
+
+// CommunityRule is one of the rules a community's moderators set.
+type CommunityRule struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
 // ============================================================
 // Tweet types
 // ============================================================
@@ -92,6 +409,7 @@ type TweetResult struct {
 	Text                string            `json:"text"`
 	CreatedAt           string            `json:"created_at"`
 	ConversationIDStr   string            `json:"conversation_id_str"`
+	UserIDStr           string            `json:"user_id_str"`
 	InReplyToStatusID   string            `json:"in_reply_to_status_id_str"`
 	InReplyToUserID     string            `json:"in_reply_to_user_id_str"`
 	InReplyToScreenName string            `json:"in_reply_to_screen_name"`
@@ -107,12 +425,25 @@ type TweetResult struct {
 	Retweeted           bool              `json:"retweeted"`
 	Favorited           bool              `json:"favorited"`
 	Bookmarked          bool              `json:"bookmarked"`
+	PossiblySensitive   bool              `json:"possibly_sensitive"`
+	WithheldInCountries []string          `json:"withheld_in_countries"` // ISO country codes; "XX" = everywhere
+	WithheldScope       string            `json:"withheld_scope"`        // "status" or "user"
 	User                *UserResult       `json:"user"`
 	Entities            *TweetEntities    `json:"entities"`
 	ExtendedEntities    *ExtendedEntities `json:"extended_entities"`
 	QuotedStatus        *TweetResult      `json:"quoted_status"`
 	RetweetedStatus     *TweetResult      `json:"retweeted_status"`
+	RetweetedStatusID   string            `json:"retweeted_status_id_str"` // original's ID, when given without the original
 	Card                json.RawMessage   `json:"card"`
+
+	// ConversationControl and LimitedActions restrict who may reply; see
+	// ReplyRestriction. ConversationControl is nil when anyone can.
+	ConversationControl *ConversationControl `json:"conversation_control"`
+	LimitedActions      string               `json:"limited_actions"` // e.g. "limited_replies"
+
+	// Promoted marks an ad. Timeline parsing drops ads unless
+	// TweetParser.IncludeAds is set.
+	Promoted bool `json:"promoted,omitempty"`
 }
 
 // GetText returns the best available text content of the tweet.
@@ -123,6 +454,64 @@ func (t *TweetResult) GetText() string {
 	return t.Text
 }
 
+// ConversationControl is the reply restriction an author set on a tweet.
+type ConversationControl struct {
+	Policy string `json:"policy"` // "Community", "ByInvitation", "Verified", ...
+}
+
+// conversationPolicies maps conversation_control policies to the values
+// returned by ReplyRestriction.
+var conversationPolicies = map[string]string{
+	"community":    "following",
+	"byinvitation": "mentioned",
+	"verified":     "verified",
+	"subscribers":  "subscribers",
+}
+
+// ReplyRestriction returns who may reply to the tweet: "everyone" (the
+// default), "following" (accounts the author follows), "mentioned"
+// (accounts mentioned in the tweet), "verified" or "subscribers". An
+// unknown policy, or replies limited without one, gives "limited".
+func (t *TweetResult) ReplyRestriction() string {
+	if t.ConversationControl != nil && t.ConversationControl.Policy != "" {
+		if r, ok := conversationPolicies[strings.ToLower(t.ConversationControl.Policy)]; ok {
+			return r
+		}
+		return "limited"
+	}
+	if strings.Contains(t.LimitedActions, "limited_replies") {
+		return "limited"
+	}
+	return "everyone"
+}
+
+// AuthorID returns the rest_id of the tweet's author, from the embedded user
+// when present and from user_id_str otherwise.
+func (t *TweetResult) AuthorID() string {
+	if t.User != nil && t.User.RestID != "" {
+		return t.User.RestID
+	}
+	return t.UserIDStr
+}
+
+// MediaURLs returns one MediaDownload per media item attached to the tweet,
+// with the URL to download it from: the image for photos, the
+// highest-bitrate MP4 variant for videos and GIFs.
+func (t *TweetResult) MediaURLs() []MediaDownload {
+	media := tweetMedia(t)
+	downloads := make([]MediaDownload, 0, len(media))
+	for _, m := range media {
+		downloads = append(downloads, MediaDownload{
+			TweetID:   t.ID,
+			CreatedAt: t.CreatedAt,
+			MediaID:   m.ID,
+			Type:      m.Type,
+			URL:       m.DownloadURL(),
+		})
+	}
+	return downloads
+}
+
 // TweetEntities holds entity information extracted from tweet text.
 type TweetEntities struct {
 	URLs         []URLEntity     `json:"urls"`
@@ -172,6 +561,31 @@ type MediaEntity struct {
 	Sizes       json.RawMessage `json:"sizes"`
 }
 
+// DownloadURL returns the URL of the media file: the highest-bitrate MP4
+// variant for videos and GIFs, MediaURL otherwise (or when a video lists no
+// MP4 variant).
+func (m *MediaEntity) DownloadURL() string {
+	url, best := m.MediaURL, -1
+	if m.VideoInfo != nil {
+		for _, v := range m.VideoInfo.Variants {
+			if v.ContentType == "video/mp4" && v.Bitrate > best {
+				url, best = v.URL, v.Bitrate
+			}
+		}
+	}
+	return url
+}
+
+// MediaDownload is a media item ready for download, with the tweet it was
+// attached to. See TweetResult.MediaURLs.
+type MediaDownload struct {
+	TweetID   string `json:"tweet_id"`
+	MediaID   string `json:"media_id"`
+	Type      string `json:"type"` // photo, video, animated_gif
+	URL       string `json:"url"`
+	CreatedAt string `json:"created_at"` // the tweet's
+}
+
 // VideoInfo holds video-specific media information.
 type VideoInfo struct {
 	DurationMillis int            `json:"duration_millis"`
@@ -197,6 +611,11 @@ type TweetDetailResult struct {
 	Tweet      TweetResult   `json:"tweet"`
 	Replies    []TweetResult `json:"replies"`
 	NextCursor string        `json:"next_cursor"`
+
+	// Tombstoned is set when the focal tweet came back as a tombstone
+	// (deleted, author suspended, blocked, ...): Tweet is then empty while
+	// Replies holds whatever is still available.
+	Tombstoned bool `json:"tombstoned,omitempty"`
 }
 
 // ============================================================
@@ -222,3 +641,58 @@ type TrendResult struct {
 type TrendsResult struct {
 	Trends []TrendResult `json:"trends"`
 }
+
+// NewsItem is a news story from the news or explore timelines.
+type NewsItem struct {
+	Title       string `json:"title"`
+	Summary     string `json:"summary"`
+	URL         string `json:"url"`
+	Source      string `json:"source"`
+	PublishedAt string `json:"published_at"` // as sent, e.g. "2 hours ago" or a timestamp
+}
+
+// ExploreResult is the parsed explore page: every trending topic on it,
+// plus its featured modules in page order.
+type ExploreResult struct {
+	Trends  []TrendResult   `json:"trends"`
+	Modules []ExploreModule `json:"modules"`
+}
+
+// ExploreModule is a titled group of items on the explore page.
+type ExploreModule struct {
+	Title  string        `json:"title"`
+	News   []NewsItem    `json:"news"`
+	Trends []TrendResult `json:"trends"`
+	Tweets []TweetResult `json:"tweets"`
+}
+
+// TypeaheadResult holds the search box (typeahead) suggestions for a query.
+type TypeaheadResult struct {
+	Users  []UserResult     `json:"users"`
+	Topics []string         `json:"topics"` // suggested queries, hashtags included
+	Events []TypeaheadEvent `json:"events"`
+}
+
+// TypeaheadEvent is an event or news suggestion in a TypeaheadResult.
+type TypeaheadEvent struct {
+	Topic          string `json:"topic"`
+	URL            string `json:"url"`
+	SupportingText string `json:"supporting_text"`
+}
+
+// TrendLocation is a place GetTrends can report trends for.
+type TrendLocation struct {
+	WOEID       int64          `json:"woeid"`
+	Name        string         `json:"name"`
+	Country     string         `json:"country"`
+	CountryCode string         `json:"countryCode"`
+	ParentID    int64          `json:"parentid"`
+	PlaceType   TrendPlaceType `json:"placeType"`
+	URL         string         `json:"url"`
+}
+
+// TrendPlaceType classifies a TrendLocation (e.g. 12 "Country", 7 "Town").
+type TrendPlaceType struct {
+	Code int    `json:"code"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,116 @@
+package utools
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestUserResultProfileImageOriginal(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"normal", "https://pbs.twimg.com/profile_images/1683325380441128960/yRsRRjGO_normal.jpg", "https://pbs.twimg.com/profile_images/1683325380441128960/yRsRRjGO.jpg"},
+		{"bigger", "https://pbs.twimg.com/profile_images/1/abc_bigger.png", "https://pbs.twimg.com/profile_images/1/abc.png"},
+		{"400x400", "https://pbs.twimg.com/profile_images/1/abc_400x400.jpg", "https://pbs.twimg.com/profile_images/1/abc.jpg"},
+		{"already original", "https://pbs.twimg.com/profile_images/1/abc.jpg", "https://pbs.twimg.com/profile_images/1/abc.jpg"},
+		{"no extension", "https://pbs.twimg.com/profile_images/1/abc_normal", "https://pbs.twimg.com/profile_images/1/abc"},
+		{"underscore in name", "https://pbs.twimg.com/profile_images/1/my_pic_mini.jpeg", "https://pbs.twimg.com/profile_images/1/my_pic.jpeg"},
+		{"default image", "https://abs.twimg.com/sticky/default_profile_images/default_profile_normal.png", "https://abs.twimg.com/sticky/default_profile_images/default_profile.png"},
+		{"empty", "", ""},
+	}
+	for _, cse := range cases {
+		t.Run(cse.name, func(t *testing.T) {
+			u := &UserResult{ProfileImageURL: cse.in}
+			if got := u.ProfileImageOriginal(); got != cse.want {
+				t.Fatalf("got %q want %q", got, cse.want)
+			}
+		})
+	}
+}
+
+func TestUserResultProfileBannerOriginal(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"https://pbs.twimg.com/profile_banners/44196397/1690621312/1500x500", "https://pbs.twimg.com/profile_banners/44196397/1690621312"},
+		{"https://pbs.twimg.com/profile_banners/44196397/1690621312/mobile_retina", "https://pbs.twimg.com/profile_banners/44196397/1690621312"},
+		{"https://pbs.twimg.com/profile_banners/44196397/1690621312", "https://pbs.twimg.com/profile_banners/44196397/1690621312"},
+		{"", ""},
+	}
+	for _, cse := range cases {
+		u := &UserResult{ProfileBannerURL: cse.in}
+		if got := u.ProfileBannerOriginal(); got != cse.want {
+			t.Fatalf("ProfileBannerOriginal(%q) = %q, want %q", cse.in, got, cse.want)
+		}
+	}
+}
+
+func TestUserResultExpandedURL(t *testing.T) {
+	u, err := ParseUserProfile(json.RawMessage(`{"data":{"user":{"result":{"__typename":"User","rest_id":"12","legacy":{
+		"screen_name":"jack","url":"https://t.co/abc",
+		"description":"see https://t.co/x and https://t.co/xy",
+		"entities":{
+			"url":{"urls":[{"url":"https://t.co/abc","expanded_url":"https://example.com/jack","display_url":"example.com/jack"}]},
+			"description":{"urls":[
+				{"url":"https://t.co/x","expanded_url":"https://go.dev"},
+				{"url":"https://t.co/xy","expanded_url":"https://pkg.go.dev"}
+			]}
+		}}}}}}`))
+	if err != nil {
+		t.Fatalf("ParseUserProfile error: %v", err)
+	}
+	if got := u.ExpandedURL(); got != "https://example.com/jack" {
+		t.Fatalf("ExpandedURL = %q", got)
+	}
+	if got := u.ExpandedDescription(); got != "see https://go.dev and https://pkg.go.dev" {
+		t.Fatalf("ExpandedDescription = %q", got)
+	}
+
+	// Without entities both fall back to the raw fields.
+	plain := &UserResult{URL: "https://t.co/abc", Description: "bio https://t.co/x"}
+	if plain.ExpandedURL() != "https://t.co/abc" || plain.ExpandedDescription() != "bio https://t.co/x" {
+		t.Fatalf("expected t.co fallbacks, got %q, %q", plain.ExpandedURL(), plain.ExpandedDescription())
+	}
+}
+
+func TestUserResultAccountAge(t *testing.T) {
+	created := time.Now().Add(-100 * 24 * time.Hour).UTC()
+	u := &UserResult{CreatedAt: created.Format(time.RubyDate), StatusesCount: 250}
+
+	age, err := u.AccountAge()
+	if err != nil {
+		t.Fatalf("AccountAge error: %v", err)
+	}
+	if diff := age - 100*24*time.Hour; diff < -time.Second || diff > time.Minute {
+		t.Fatalf("expected an age of about 100 days, got %v", age)
+	}
+	if got := u.TweetsPerDay(); math.Abs(got-2.5) > 0.01 {
+		t.Fatalf("expected 2.5 tweets per day, got %v", got)
+	}
+
+	u.CreatedAt = created.Format(time.RFC3339)
+	if _, err := u.AccountAge(); err != nil {
+		t.Fatalf("expected RFC 3339 to parse, got %v", err)
+	}
+
+	// Accounts younger than a day count as one day old.
+	fresh := &UserResult{CreatedAt: time.Now().Add(-time.Hour).Format(time.RubyDate), StatusesCount: 30}
+	if got := fresh.TweetsPerDay(); got != 30 {
+		t.Fatalf("expected 30 tweets per day for a new account, got %v", got)
+	}
+
+	for _, createdAt := range []string{"", "yesterday"} {
+		u := &UserResult{CreatedAt: createdAt, StatusesCount: 10}
+		if _, err := u.AccountAge(); err == nil {
+			t.Fatalf("expected an error for created_at %q", createdAt)
+		}
+		if got := u.TweetsPerDay(); got != 0 {
+			t.Fatalf("expected 0 tweets per day for created_at %q, got %v", createdAt, got)
+		}
+	}
+}
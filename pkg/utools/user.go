@@ -3,7 +3,12 @@ package utools
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"reflect"
 	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
 )
 
 // ============================================================
@@ -52,6 +57,17 @@ func (c *Client) GetUsernameChanges(ctx context.Context, userID string) (json.Ra
 	return result, err
 }
 
+// GetUsernameChangesParsed retrieves a user's username change history and
+// parses it with ParseUsernameChanges (oldest first; empty, not nil, when the
+// account never changed its name).
+func (c *Client) GetUsernameChangesParsed(ctx context.Context, userID string) ([]UsernameChange, error) {
+	raw, err := c.GetUsernameChanges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUsernameChanges(raw)
+}
+
 // LookupUser retrieves user information by username or user ID.
 // Pass either screenName or userID (the other can be empty).
 func (c *Client) LookupUser(ctx context.Context, screenName, userID string) (json.RawMessage, error) {
@@ -77,6 +93,212 @@ func (c *Client) GetUserByScreenNameV2(ctx context.Context, screenName string) (
 	return result, err
 }
 
+// GetUserProfile retrieves a user by screen name using the V2 endpoint and
+// returns it parsed. Protected accounts come back with Status
+// AccountProtected; suspended, deactivated and missing accounts are reported
+// as ErrUserSuspended, ErrUserDeactivated and ErrUserNotFound. A renamed
+// handle is reported as *ErrHandleChanged unless Config.FollowHandleRedirects
+// is set, in which case the account is fetched under its new handle.
+// Profiles are cached when WithProfileCache is set, and not-found lookups
+// when Config.NegativeCacheTTL is.
+func (c *Client) GetUserProfile(ctx context.Context, screenName string) (*UserResult, error) {
+	user, _, err := c.GetUserProfileRaw(ctx, screenName)
+	return user, err
+}
+
+// GetUserProfileRaw is GetUserProfile that also returns the unmodified
+// response payload, e.g. for archiving it without a second request. The
+// payload is returned even when parsing fails (such as for a suspended
+// account); it is nil only when the request itself failed.
+func (c *Client) GetUserProfileRaw(ctx context.Context, screenName string) (*UserResult, json.RawMessage, error) {
+	key := profileHandleKey(screenName)
+	if user, raw, ok, err := c.profiles.get(key); ok {
+		return user, raw, err
+	}
+	raw, err := c.fetchProfile(ctx, screenName, func(handle string) (json.RawMessage, error) {
+		return c.GetUserByScreenNameV2(ctx, handle)
+	})
+	if err != nil {
+		return nil, raw, err
+	}
+	user, err := ParseUserProfile(raw)
+	c.profiles.put(key, user, raw, err)
+	return user, raw, err
+}
+
+// GetUserProfileByID retrieves a user by rest_id using the V2 endpoint and
+// returns it parsed, reporting unavailable accounts like GetUserProfile.
+// Profiles are cached like those of GetUserProfile.
+func (c *Client) GetUserProfileByID(ctx context.Context, userID string) (*UserResult, error) {
+	key := profileIDKey(userID)
+	if user, _, ok, err := c.profiles.get(key); ok {
+		return user, err
+	}
+	raw, err := c.GetUserByIDV2(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := ParseUserProfile(raw)
+	c.profiles.put(key, user, raw, err)
+	return user, err
+}
+
+// GetUserProfileMerged retrieves a user by screen name from both the V2 and
+// the V1 profile endpoints, concurrently, and merges the two: V2 wins where
+// both have a field and V1 fills in what the V2 payload lacks, so the result
+// carries what is unique to each (V2's Professional and AffiliateLabel, the
+// legacy counts only V1 returns); see mergeUserResult. If one of the calls
+// fails on the transport or the API the other is returned on its own, and
+// if both fail, the V2 error is returned. Errors about the account itself
+// (suspended, deactivated, missing, or a renamed handle) are returned even
+// when the other call succeeded.
+func (c *Client) GetUserProfileMerged(ctx context.Context, screenName string) (*UserResult, error) {
+	var v1, v2 *UserResult
+	var raw2 json.RawMessage
+	var err1, err2 error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		v2, raw2, err2 = c.GetUserProfileRaw(ctx, screenName)
+	}()
+	go func() {
+		defer wg.Done()
+		var raw json.RawMessage
+		raw, err1 = c.fetchProfile(ctx, screenName, func(handle string) (json.RawMessage, error) {
+			return c.GetUserByScreenName(ctx, handle)
+		})
+		if err1 == nil {
+			v1, err1 = ParseUserProfile(raw)
+		}
+	}()
+	wg.Wait()
+
+	switch {
+	case isAccountError(err2):
+		return nil, err2
+	case isAccountError(err1):
+		return nil, err1
+	case err2 != nil && err1 != nil:
+		return nil, err2
+	case err2 != nil:
+		return v1, nil
+	case err1 != nil:
+		return v2, nil
+	}
+	mergeUserResult(v2, v1, payloadUserKeys(raw2))
+	return v2, nil
+}
+
+// isAccountError reports whether err describes the account rather than a
+// failed request: an unavailable account or a renamed handle.
+func isAccountError(err error) bool {
+	var changed *ErrHandleChanged
+	_, unavailable := AccountStatusOf(err)
+	return unavailable || errors.As(err, &changed)
+}
+
+// payloadUserKeys returns the keys of the user node of a profile response
+// and of its legacy object.
+func payloadUserKeys(raw json.RawMessage) map[string]bool {
+	keys := make(map[string]bool)
+	node, ok := userNode(gjson.ParseBytes(raw))
+	if !ok {
+		return keys
+	}
+	for _, obj := range []gjson.Result{node, node.Get("legacy")} {
+		obj.ForEach(func(k, _ gjson.Result) bool {
+			keys[k.String()] = true
+			return true
+		})
+	}
+	return keys
+}
+
+// mergeUserResult fills dst, a V2 profile whose payload had the user keys
+// v2Keys, with what only the V1 profile src carries, field by field:
+// strings, pointers and slices when empty in dst, and counts when the V2
+// payload does not include them. Bools and Status always stay V2's, since
+// V2 returned the user and its false or AccountActive is an answer, not a
+// gap.
+func mergeUserResult(dst, src *UserResult, v2Keys map[string]bool) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	typ := d.Type()
+	for i := range d.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		f := d.Field(i)
+		switch f.Kind() {
+		case reflect.String, reflect.Pointer, reflect.Slice:
+			if f.IsZero() {
+				f.Set(s.Field(i))
+			}
+		case reflect.Int:
+			if name != "-" && !v2Keys[name] {
+				f.Set(s.Field(i))
+			}
+		}
+	}
+}
+
+// fetchProfile calls fetch for screenName and checks the response for a
+// renamed handle. Unless Config.FollowHandleRedirects is set, that is
+// reported as *ErrHandleChanged along with the response; otherwise the
+// response is used as is when it already holds the renamed account, or the
+// new handle is fetched once (later redirects are not followed).
+func (c *Client) fetchProfile(ctx context.Context, screenName string, fetch func(handle string) (json.RawMessage, error)) (json.RawMessage, error) {
+	raw, err := fetch(screenName)
+	if err != nil {
+		return nil, err
+	}
+	newHandle, inline := handleRedirect(raw, screenName)
+	switch {
+	case newHandle == "", c.followHandleRedirects && inline:
+		return raw, nil
+	case !c.followHandleRedirects:
+		return raw, &ErrHandleChanged{OldHandle: strings.TrimPrefix(screenName, "@"), NewHandle: newHandle}
+	}
+	return fetch(newHandle)
+}
+
+// ProfileOptions selects the related data GetUserProfileExpanded asks the V2
+// profile endpoint to inline.
+type ProfileOptions struct {
+	WithPinnedTweet  bool
+	WithRecentTweets bool
+}
+
+// GetUserProfileExpanded retrieves a user by screen name using the V2 endpoint,
+// asking it to inline the pinned tweet and/or recent tweets, and returns them
+// parsed. Extras the upstream does not return are left empty.
+func (c *Client) GetUserProfileExpanded(ctx context.Context, screenName string, opts ProfileOptions) (*ExpandedProfile, error) {
+	profile, _, err := c.GetUserProfileExpandedRaw(ctx, screenName, opts)
+	return profile, err
+}
+
+// GetUserProfileExpandedRaw is GetUserProfileExpanded that also returns the
+// unmodified response payload, with the same semantics as GetUserProfileRaw.
+func (c *Client) GetUserProfileExpandedRaw(ctx context.Context, screenName string, opts ProfileOptions) (*ExpandedProfile, json.RawMessage, error) {
+	result, err := c.fetchProfile(ctx, screenName, func(handle string) (json.RawMessage, error) {
+		params := map[string]string{
+			"screenName": handle,
+		}
+		if opts.WithPinnedTweet {
+			params["includePinnedTweet"] = "true"
+		}
+		if opts.WithRecentTweets {
+			params["includeRecentTweets"] = "true"
+		}
+		var result json.RawMessage
+		err := c.Get(ctx, "/userByScreenNameV2", params, &result)
+		return result, err
+	})
+	if err != nil {
+		return nil, result, err
+	}
+	profile, err := ParseUserProfileExpanded(result)
+	return profile, result, err
+}
+
 // GetUserByIDV2 retrieves user info by user ID using the V2 endpoint.
 func (c *Client) GetUserByIDV2(ctx context.Context, userID string) (json.RawMessage, error) {
 	params := map[string]string{
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -229,3 +231,269 @@ func TestGetAccountAnalytics_PassesAuthTokenAndCT0(t *testing.T) {
 		}
 	})
 }
+
+func TestGetUserProfileExpanded_IncludeParams(t *testing.T) {
+	cases := []struct {
+		name        string
+		opts        ProfileOptions
+		wantPinned  string
+		wantRecents string
+	}{
+		{name: "none", opts: ProfileOptions{}},
+		{name: "pinned", opts: ProfileOptions{WithPinnedTweet: true}, wantPinned: "true"},
+		{name: "both", opts: ProfileOptions{WithPinnedTweet: true, WithRecentTweets: true}, wantPinned: "true", wantRecents: "true"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/base/apitools/userByScreenNameV2" {
+					t.Fatalf("unexpected path: %s", r.URL.Path)
+				}
+				q := r.URL.Query()
+				if q.Get("screenName") != "jack" {
+					t.Fatalf("expected screenName=jack, got %q", q.Get("screenName"))
+				}
+				if q.Get("includePinnedTweet") != tc.wantPinned || q.Get("includeRecentTweets") != tc.wantRecents {
+					t.Fatalf("unexpected include params: %s", r.URL.RawQuery)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code":1,"data":{"data":{"user":{"result":{"rest_id":"12","legacy":{"screen_name":"jack"}}}}},"msg":"SUCCESS"}`))
+			}))
+			defer ts.Close()
+
+			client := newTestClient(t, ts.URL)
+			p, err := client.GetUserProfileExpanded(context.Background(), "jack", tc.opts)
+			if err != nil {
+				t.Fatalf("GetUserProfileExpanded error: %v", err)
+			}
+			if p.User.RestID != "12" || p.PinnedTweet != nil || len(p.RecentTweets) != 0 {
+				t.Fatalf("unexpected profile without extras: %+v", p)
+			}
+		})
+	}
+}
+
+func TestGetUsernameChangesParsed(t *testing.T) {
+	body := `{"usernameChanges":[
+		{"old_name":"jack_b","new_name":"jack","changed_at":"Tue Mar 21 20:50:14 +0000 2017"},
+		{"oldName":"jackd","newName":"jack_b","changedAt":1199145600000},
+		{"old_name":"jack","new_name":"jack2","changed_at":"2021-11-29T16:00:00-05:00"}
+	]}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/base/apitools/usernameChanges" || r.URL.Query().Get("userId") != "12" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	changes, err := client.GetUsernameChangesParsed(context.Background(), "12")
+	if err != nil {
+		t.Fatalf("GetUsernameChangesParsed error: %v", err)
+	}
+	want := []UsernameChange{
+		{OldName: "jackd", NewName: "jack_b", ChangedAt: "2008-01-01T00:00:00Z"},
+		{OldName: "jack_b", NewName: "jack", ChangedAt: "2017-03-21T20:50:14Z"},
+		{OldName: "jack", NewName: "jack2", ChangedAt: "2021-11-29T21:00:00Z"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("change %d:\n got %+v\nwant %+v", i, changes[i], want[i])
+		}
+	}
+
+	body = `{"usernameChanges":[]}`
+	changes, err = client.GetUsernameChangesParsed(context.Background(), "12")
+	if err != nil || changes == nil || len(changes) != 0 {
+		t.Fatalf("expected empty non-nil slice for no changes, got %#v, %v", changes, err)
+	}
+}
+
+func TestGetUserProfileRaw(t *testing.T) {
+	payload := `{"data":{"user":{"result":{"__typename":"User","rest_id":"12","legacy":{"screen_name":"jack","followers_count":42}}}}}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + payload + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	user, raw, err := client.GetUserProfileRaw(context.Background(), "jack")
+	if err != nil {
+		t.Fatalf("GetUserProfileRaw error: %v", err)
+	}
+	if string(raw) != payload {
+		t.Fatalf("expected the unmodified inner payload, got %s", raw)
+	}
+	reparsed, err := ParseUserProfile(raw)
+	if err != nil {
+		t.Fatalf("ParseUserProfile(raw) error: %v", err)
+	}
+	if user.RestID != "12" || user.ScreenName != "jack" || !reflect.DeepEqual(reparsed, user) {
+		t.Fatalf("parsed user inconsistent with raw: %+v vs %+v", user, reparsed)
+	}
+
+	// A payload that fails to parse is still handed back for archival.
+	payload = `{"data":{"user":{"result":{"__typename":"UserUnavailable","reason":"Suspended"}}}}`
+	user, raw, err = client.GetUserProfileRaw(context.Background(), "jack")
+	if !errors.Is(err, ErrUserSuspended) || user != nil || string(raw) != payload {
+		t.Fatalf("expected suspended error with raw payload, got %v, %+v, %s", err, user, raw)
+	}
+}
+
+func TestGetUserProfileHandleChanged(t *testing.T) {
+	renamed := `{"data":{"user":{"result":{"__typename":"User","rest_id":"12","legacy":{"screen_name":"jack_new","followers_count":42}}}}}`
+	responses := map[string]string{
+		"jack":     `{"redirect_to":"https://x.com/jack_new"}`,
+		"jack_new": renamed,
+		"oldjack":  renamed, // upstream resolved the old handle itself
+	}
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handle := r.URL.Query().Get("screenName")
+		requested = append(requested, handle)
+		handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"data":` + responses[handle] + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts.URL)
+
+	for _, old := range []string{"jack", "oldjack"} {
+		_, raw, err := client.GetUserProfileRaw(context.Background(), old)
+		var changed *ErrHandleChanged
+		if !errors.As(err, &changed) || changed.OldHandle != old || changed.NewHandle != "jack_new" {
+			t.Fatalf("%s: expected ErrHandleChanged to jack_new, got %v", old, err)
+		}
+		if string(raw) != responses[old] {
+			t.Fatalf("%s: expected the redirect payload, got %s", old, raw)
+		}
+	}
+	if _, err := client.GetUserProfile(context.Background(), "@Jack_New"); err != nil {
+		t.Fatalf("same handle in another case must not count as a rename: %v", err)
+	}
+
+	client.followHandleRedirects = true // as set by Config.FollowHandleRedirects
+	requested = nil
+	user, err := client.GetUserProfile(context.Background(), "jack")
+	if err != nil || user.ScreenName != "jack_new" || strings.Join(requested, ",") != "jack,jack_new" {
+		t.Fatalf("expected to follow to jack_new, got %+v, %v (requests %v)", user, err, requested)
+	}
+	requested = nil
+	profile, err := client.GetUserProfileExpanded(context.Background(), "oldjack", ProfileOptions{})
+	if err != nil || profile.User.ScreenName != "jack_new" || len(requested) != 1 {
+		t.Fatalf("expected the inline renamed account without a second request, got %+v, %v (requests %v)", profile, err, requested)
+	}
+}
+
+func TestGetUserProfileMerged(t *testing.T) {
+	const v2 = `{"data":{"user":{"result":{"__typename":"User","rest_id":"42","is_blue_verified":true,
+		"professional":{"professional_type":"Creator","category":[{"id":713,"name":"Science & Technology"}]},
+		"affiliates_highlighted_label":{"label":{"description":"Acme Corp"}},
+		"core":{"screen_name":"jack","name":"Jack V2"},
+		"legacy":{"description":"from v2","followers_count":100}}}}}`
+	const v1 = `{"id_str":"42","screen_name":"jack","name":"Jack V1","description":"from v1","followers_count":99,
+		"listed_count":7,"favourites_count":12,"can_dm":true}`
+	v1Fails := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch r.URL.Path {
+		case "/api/base/apitools/userByScreenNameV2":
+			body = v2
+		case "/api/base/apitools/getUserByIdOrNameShow":
+			if v1Fails {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":400,"msg":"nope"}`))
+				return
+			}
+			body = v1
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts.URL)
+	user, err := client.GetUserProfileMerged(context.Background(), "jack")
+	if err != nil {
+		t.Fatalf("GetUserProfileMerged error: %v", err)
+	}
+	// V2 wins where both have a value.
+	if user.RestID != "42" || user.Name != "Jack V2" || user.Description != "from v2" || user.FollowersCount != 100 {
+		t.Fatalf("expected V2 values to win: %+v", user)
+	}
+	// Fields only V2 carries.
+	if !user.IsBlueVerified || user.AffiliateLabel != "Acme Corp" || user.Professional == nil ||
+		user.Professional.Type != "Creator" || len(user.Professional.Categories) != 1 || user.Professional.Categories[0].Name != "Science & Technology" {
+		t.Fatalf("missing V2-only fields: %+v", user)
+	}
+	// Fields only V1 carries.
+	if user.ListedCount != 7 || user.FavouritesCount != 12 || user.ID != "42" {
+		t.Fatalf("missing V1-only fields: %+v", user)
+	}
+	// Bools stay V2's, even when V1 says otherwise.
+	if user.CanDM {
+		t.Fatalf("expected V2's can_dm to win: %+v", user)
+	}
+
+	v1Fails = true
+	user, err = client.GetUserProfileMerged(context.Background(), "jack")
+	if err != nil || user.Name != "Jack V2" || user.ListedCount != 0 {
+		t.Fatalf("expected the V2 profile alone when V1 fails, got %+v, %v", user, err)
+	}
+}
+
+func TestGetUserProfileMerged_PrefersV2ZeroValues(t *testing.T) {
+	const v2 = `{"data":{"user":{"result":{"__typename":"User","rest_id":"42","core":{"screen_name":"jack"},
+		"legacy":{"protected":false,"verified":false,"followers_count":0,"description":""}}}}}`
+	const v1 = `{"id_str":"42","screen_name":"jack","protected":true,"verified":true,"followers_count":5,
+		"description":"from v1","utc_offset":-25200,"time_zone":"Pacific Time (US & Canada)"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := v1
+		if r.URL.Path == "/api/base/apitools/userByScreenNameV2" {
+			body = v2
+		}
+		_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+	}))
+	defer ts.Close()
+
+	user, err := newTestClient(t, ts.URL).GetUserProfileMerged(context.Background(), "jack")
+	if err != nil {
+		t.Fatalf("GetUserProfileMerged error: %v", err)
+	}
+	if user.Protected || user.Verified || user.Status != AccountActive || user.FollowersCount != 0 {
+		t.Fatalf("expected V2's false and zero values to win: %+v", user)
+	}
+	// Gaps in the V2 payload are still filled from V1.
+	if user.Description != "from v1" || user.UTCOffset != -25200 || user.TimeZone != "Pacific Time (US & Canada)" {
+		t.Fatalf("expected V1 to fill what V2 lacks: %+v", user)
+	}
+}
+
+func TestGetUserProfileMerged_AccountErrors(t *testing.T) {
+	const v1 = `{"id_str":"42","screen_name":"jack"}`
+	for name, v2 := range map[string]string{
+		"suspended": `{"data":{"user":{"result":{"__typename":"UserUnavailable","reason":"Suspended"}}}}`,
+		"renamed":   `{"data":{"user":{"result":{"__typename":"User","rest_id":"42","legacy":{"screen_name":"jack_new"}}}}}`,
+	} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body := v1
+			if r.URL.Path == "/api/base/apitools/userByScreenNameV2" {
+				body = v2
+			}
+			_, _ = w.Write([]byte(`{"code":1,"data":` + body + `,"msg":"SUCCESS"}`))
+		}))
+		user, err := newTestClient(t, ts.URL).GetUserProfileMerged(context.Background(), "jack")
+		ts.Close()
+		var changed *ErrHandleChanged
+		if user != nil || !(errors.Is(err, ErrUserSuspended) || errors.As(err, &changed)) {
+			t.Fatalf("%s: expected the V2 account error despite V1 succeeding, got %+v, %v", name, user, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package utoolstest provides a utools.Client that serves canned responses
+// from memory, for testing code built on xCatch without a network or
+// httptest boilerplate.
+package utoolstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xCatch/xcatch/config"
+	"github.com/xCatch/xcatch/pkg/utools"
+)
+
+// apiToolsBasePath is the prefix of every uTools endpoint path.
+const apiToolsBasePath = "/api/base/apitools"
+
+// NewFixtureClient returns a client whose requests are answered from
+// fixtures, keyed by endpoint path ("/userByScreenNameV2", or the full
+// "/api/base/apitools/userByScreenNameV2"). A fixture is the response data
+// only: it is wrapped in the {"code":1,"data":...,"msg":"SUCCESS"} envelope
+// like the real API does, so the client's typed methods parse it as usual.
+// Params are ignored, and a path without a fixture gets a 404 APIError.
+// The client does not rate limit or retry; opts are applied on top.
+func NewFixtureClient(fixtures map[string]string, opts ...utools.Option) *utools.Client {
+	routes := make(fixtureTransport, len(fixtures))
+	for path, data := range fixtures {
+		routes[resolvePath(path)] = data
+	}
+	cfg := &config.Config{
+		BaseURL:          "http://utoolstest.invalid",
+		APIKey:           "utoolstest",
+		Timeout:          10 * time.Second,
+		DisableRateLimit: true,
+	}
+	client, err := utools.NewClient(cfg, append([]utools.Option{utools.WithTransport(routes)}, opts...)...)
+	if err != nil {
+		// The config above always validates.
+		panic(fmt.Sprintf("utoolstest: %v", err))
+	}
+	return client
+}
+
+// fixtureTransport serves fixture data by full endpoint path.
+type fixtureTransport map[string]string
+
+func (f fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := http.StatusOK
+	var body []byte
+	if data, ok := f[req.URL.Path]; ok {
+		// The real API sends data as a JSON-encoded string.
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(`{"code":1,"data":` + string(encoded) + `,"msg":"SUCCESS"}`)
+	} else {
+		status = http.StatusNotFound
+		msg, _ := json.Marshal("utoolstest: no fixture for " + req.URL.Path)
+		body = []byte(`{"code":404,"msg":` + string(msg) + `}`)
+	}
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(string(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// resolvePath returns the full endpoint path of a fixture key.
+func resolvePath(path string) string {
+	if strings.HasPrefix(path, apiToolsBasePath+"/") {
+		return path
+	}
+	return apiToolsBasePath + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,43 @@
+package utoolstest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xCatch/xcatch/pkg/utools"
+)
+
+func TestNewFixtureClient(t *testing.T) {
+	client := NewFixtureClient(map[string]string{
+		"/userByScreenNameV2": `{"data":{"user":{"result":{"__typename":"User","rest_id":"12",
+			"core":{"screen_name":"jack","name":"jack"},"legacy":{"followers_count":42}}}}}`,
+		"/api/base/apitools/tweetResultsByRestIds": `{"data":{"tweetResult":[
+			{"result":{"__typename":"Tweet","rest_id":"20","legacy":{"full_text":"second"}}},
+			{"result":{"__typename":"Tweet","rest_id":"10","legacy":{"full_text":"first"}}}]}}`,
+	})
+	ctx := context.Background()
+
+	user, err := client.GetUserProfile(ctx, "jack")
+	if err != nil {
+		t.Fatalf("GetUserProfile error: %v", err)
+	}
+	if user.RestID != "12" || user.ScreenName != "jack" || user.FollowersCount != 42 {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	found, missing, err := client.GetTweetsByIDsParsed(ctx, []string{"10", "20", "30"})
+	if err != nil {
+		t.Fatalf("GetTweetsByIDsParsed error: %v", err)
+	}
+	if len(found) != 2 || found[0].GetText() != "first" || found[1].GetText() != "second" || len(missing) != 1 || missing[0] != "30" {
+		t.Fatalf("unexpected tweets: %+v, missing %v", found, missing)
+	}
+
+	_, err = client.GetTrending(ctx)
+	var apiErr *utools.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 || !strings.Contains(apiErr.Message, "no fixture for /api/base/apitools/trending") {
+		t.Fatalf("expected a 404 APIError for a missing fixture, got %v", err)
+	}
+}